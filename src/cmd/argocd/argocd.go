@@ -2,14 +2,16 @@ package argocd
 
 import (
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/argocd/sub"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 
 	"github.com/spf13/cobra"
 )
 
 var ArgocdCmd = &cobra.Command{
-	Use:   "argocd",
-	Short: "ArgoCD commands",
-	Long:  "ArgoCD commands to manage ArgoCD deploy, applications, projects and other resources",
+	Use:         "argocd",
+	Short:       "ArgoCD commands",
+	Long:        "ArgoCD commands to manage ArgoCD deploy, applications, projects and other resources",
+	Annotations: map[string]string{helpers.AnnotationCredentials: "argocd"},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
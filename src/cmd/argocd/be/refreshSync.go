@@ -3,6 +3,7 @@ package be
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -69,6 +70,10 @@ type Application struct {
 			Message            string `json:"message"`
 			LastTransitionTime string `json:"lastTransitionTime"`
 		} `json:"conditions"`
+		Summary struct {
+			Images       []string `json:"images"`
+			ExternalURLs []string `json:"externalURLs"`
+		} `json:"summary"`
 		ReconciledAt         string `json:"reconciledAt"`
 		ResourceHealthSource string `json:"resourceHealthSource"`
 		ControllerNamespace  string `json:"controllerNamespace"`
@@ -187,12 +192,13 @@ func InitArgoClientWithLogin(baseURL, username, password string) error {
 	}
 
 	argoClient = helpers.NewApiClient(baseURL, token, "Bearer")
-	fmt.Printf("[Info] Successfully authenticated to ArgoCD\n")
+	slog.Info("Successfully authenticated to ArgoCD", "url", baseURL)
 	return nil
 }
 
-func GetAppNames(gitID, gitlabPath, env string) []string {
-	var matchingNames []string
+// ListApplications returns the ArgoCD applications, filtered by
+// the gitlab repository path when gitlabPath is not empty
+func ListApplications(gitlabPath string) ([]Application, error) {
 	var apps ApplicationListResponse
 
 	// Construct the endpoint with proper URL formatting
@@ -204,34 +210,61 @@ func GetAppNames(gitID, gitlabPath, env string) []string {
 	}
 
 	resp := argoClient.Request("GET", endpoint, nil)
-
 	if !resp.Response {
-		fmt.Printf("[Error] Fetching applications went wrong: %s\n", resp.Message)
-		return nil
+		return nil, fmt.Errorf("fetching applications went wrong: %s", resp.Message)
 	}
 
 	if err := json.Unmarshal(resp.Body, &apps); err != nil {
-		fmt.Printf("[Error] Parsing response failed: %v\n", err)
+		return nil, fmt.Errorf("parsing applications response failed: %v", err)
+	}
+
+	return apps.Items, nil
+}
+
+// GetApplicationImages returns the images referenced in the status summary
+// of every ArgoCD application, mapped to the names of the apps using them
+func GetApplicationImages() (map[string][]string, error) {
+	apps, err := ListApplications("")
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[string][]string)
+	for _, app := range apps {
+		for _, image := range app.Status.Summary.Images {
+			images[image] = append(images[image], app.Metadata.Name)
+		}
+	}
+
+	return images, nil
+}
+
+func GetAppNames(gitID, gitlabPath, env string) []string {
+	var matchingNames []string
+
+	apps, err := ListApplications(gitlabPath)
+	if err != nil {
+		slog.Error("Failed to list the ArgoCD applications", "error", err)
 		return nil
 	}
 
 	// Filter applications based on git_id and profile labels
-	for _, app := range apps.Items {
+	for _, app := range apps {
 		// Check if labels exist and match criteria
 		if app.Metadata.Labels != nil {
 			if app.Metadata.Labels["git_id"] == gitID {
 				// Check if contains the env
 				if strings.Contains(app.Metadata.Name, env+"-") {
 					matchingNames = append(matchingNames, app.Metadata.Name)
-					fmt.Printf("[Info] Found matching app: %s (git_id: %s, profile: %s)\n",
-						app.Metadata.Name, app.Metadata.Labels["git_id"], app.Metadata.Labels["profile"])
+					slog.Info("Found matching app", "app", app.Metadata.Name,
+						"git_id", app.Metadata.Labels["git_id"], "profile", app.Metadata.Labels["profile"])
 				}
 			}
 		}
 	}
 
 	if len(matchingNames) == 0 {
-		fmt.Printf("[Info] No matching applications found for git_id: %s, profile: %s\n", gitID, env)
+		slog.Info("No matching applications found", "git_id", gitID, "profile", env)
 	}
 
 	return matchingNames
@@ -257,10 +290,10 @@ func TriggerArgoHardRefresh(appName string) error {
 
 	resp := argoClient.Request("GET", endpoint+"?refresh=hard", nil)
 	if !resp.Response {
-		return fmt.Errorf("[Error] failed to trigger hard refresh for app %s: %s", appName, resp.Message)
+		return fmt.Errorf("failed to trigger hard refresh for app %s: %s", appName, resp.Message)
 	}
 
-	fmt.Printf("[Info] Hard refresh triggered for application: %s\n", appName)
+	slog.Info("Hard refresh triggered", "app", appName)
 	return nil
 }
 
@@ -277,10 +310,10 @@ func TriggerArgoSync(appName string) error {
 
 	resp := argoClient.Request("POST", endpoint, body)
 	if !resp.Response {
-		return fmt.Errorf("[Error] failed to trigger sync for app %s: %s", appName, resp.Message)
+		return fmt.Errorf("failed to trigger sync for app %s: %s", appName, resp.Message)
 	}
 
-	fmt.Printf("[Info] Sync triggered for application: %s\n", appName)
+	slog.Info("Sync triggered", "app", appName)
 	return nil
 }
 
@@ -290,12 +323,12 @@ func GetArgoAppStatus(appName string) (*ApplicationStatus, error) {
 	resp := argoClient.Request("GET", endpoint, nil)
 
 	if !resp.Response {
-		return nil, fmt.Errorf("[Error] failed to get status for app %s: %s", appName, resp.Message)
+		return nil, fmt.Errorf("failed to get status for app %s: %s", appName, resp.Message)
 	}
 
 	var status ApplicationStatus
 	if err := json.Unmarshal(resp.Body, &status); err != nil {
-		return nil, fmt.Errorf("[Error] failed to parse status for app %s: %v", appName, err)
+		return nil, fmt.Errorf("failed to parse status for app %s: %v", appName, err)
 	}
 
 	return &status, nil
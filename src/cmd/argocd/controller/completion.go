@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/argocd/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// CompleteApplications returns the ArgoCD applications for the shell completion
+// as "name<tab>git_id" completions, cached for a short time
+func CompleteApplications() []string {
+	url := helpers.AppConfig.ARGOCD_URL
+	return helpers.CachedCompletions("argocd applications "+url, func() ([]string, error) {
+		if url == "" {
+			return nil, fmt.Errorf("ARGOCD_URL is not set")
+		}
+		if err := be.InitArgoClientWithLogin("https://"+url, helpers.AppConfig.ARGOCD_USER, helpers.AppConfig.ARGOCD_PASSWORD); err != nil {
+			return nil, err
+		}
+		apps, err := be.ListApplications("")
+		if err != nil {
+			return nil, err
+		}
+		var completions []string
+		for _, app := range apps {
+			completions = append(completions, app.Metadata.Name+"\t"+app.Metadata.Labels["git_id"])
+		}
+		return completions, nil
+	})
+}
+
+// CompleteGitIDs returns the git ids of the applications, described by the names of their apps
+func CompleteGitIDs() []string {
+	apps := map[string][]string{}
+	for _, completion := range CompleteApplications() {
+		name, gitId, _ := strings.Cut(completion, "\t")
+		if gitId != "" {
+			apps[gitId] = append(apps[gitId], name)
+		}
+	}
+
+	var completions []string
+	for gitId, names := range apps {
+		completions = append(completions, gitId+"\t"+strings.Join(names, ", "))
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// CompleteRegions returns the regions (the suffix of the app names) of the
+// applications of the git id and the env, when they are given
+func CompleteRegions(gitId string, env string) []string {
+	regions := map[string][]string{}
+	for _, completion := range CompleteApplications() {
+		name, appGitId, _ := strings.Cut(completion, "\t")
+		if (gitId != "" && appGitId != gitId) || (env != "" && !strings.Contains(name, env+"-")) {
+			continue
+		}
+		if i := strings.LastIndex(name, "-"); i >= 0 && i < len(name)-1 {
+			region := name[i+1:]
+			regions[region] = append(regions[region], name)
+		}
+	}
+
+	var completions []string
+	for region, names := range regions {
+		completions = append(completions, region+"\t"+strings.Join(names, ", "))
+	}
+	sort.Strings(completions)
+	return completions
+}
@@ -2,14 +2,17 @@ package controller
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/argocd/shared"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/argocd"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
 )
 
 func Deploy(params argocd.ArgoCDDeployParams) error {
@@ -17,7 +20,8 @@ func Deploy(params argocd.ArgoCDDeployParams) error {
 
 	// 2 - Checking the image version
 	//     * incremental: we need to get last version from the docker hub
-	//                    and sostitute in the values.
+	//                    and sostitute in the values. With the vulnerability
+	//                    gate the versions with findings are skipped.
 	//     * latest:      Use always the latest image instead of a specific version.
 	//     * unstable:    For develop.
 	//     * void '':     Take the version from the values file.
@@ -47,21 +51,21 @@ func Deploy(params argocd.ArgoCDDeployParams) error {
 	// Step 1 - Remove and create the tmp folder and copy the values.yaml
 	errRmvTmpFolder := os.RemoveAll(outputDir) // Remove the old tmp folder if exists
 	if errRmvTmpFolder != nil {
-		fmt.Fprintln(os.Stderr, "[Error] The deletion of the old tmp folder got an error in ArgoCD.Deploy:", errRmvTmpFolder)
+		slog.Error("Failed to remove the old tmp folder", "path", outputDir, "error", errRmvTmpFolder)
 		return errRmvTmpFolder
 	}
 
 	// Create the output directory
 	errCreateTmpFolder := os.MkdirAll(outputDir, 0755)
 	if errCreateTmpFolder != nil {
-		fmt.Fprintln(os.Stderr, "[Error] The creation of the tmp folder got an error in ArgoCD.Deploy:", errCreateTmpFolder)
+		slog.Error("Failed to create the tmp folder", "path", outputDir, "error", errCreateTmpFolder)
 		return errCreateTmpFolder
 	}
 
 	// Copy the values.yaml file to the tmp folder
 	errCopyValues := helpers.CopyFile(valuesYml, outputDir+"/"+valuesYml)
 	if errCopyValues != nil {
-		fmt.Fprintln(os.Stderr, "[Error] The copy of the values in the tmp folder got an error in ArgoCD.Deploy:", errCopyValues)
+		slog.Error("Failed to copy the values in the tmp folder", "path", outputDir, "error", errCopyValues)
 		return errCopyValues
 	}
 
@@ -73,10 +77,15 @@ func Deploy(params argocd.ArgoCDDeployParams) error {
 	var isUnstable bool = false
 	switch strings.ToLower(params.Tag) {
 	case "incremental":
-		// Get highest version if set to incremental
-		imageTag, errDockerHub = shared.FetchLatestTag(params.RepoURL, params.DockerRepo)
+		// Get highest version (passing the vulnerability gate) if set to incremental
+		gate, errGate := vulnerabilityGate(params)
+		if errGate != nil {
+			slog.Error("Invalid vulnerability gate", "error", errGate)
+			return errGate
+		}
+		imageTag, errDockerHub = shared.FetchLatestTag(params.RepoURL, params.DockerRepo, gate)
 		if errDockerHub != nil {
-			fmt.Fprintln(os.Stderr, "[Error] ArgoCD.Deploy.FetchLatestTag:", errDockerHub)
+			slog.Error("Failed to fetch the latest tag", "repo", params.DockerRepo, "error", errDockerHub)
 			return errDockerHub
 		}
 		// Set isIncremental to true, to replace after
@@ -103,7 +112,7 @@ func Deploy(params argocd.ArgoCDDeployParams) error {
 		secretYml,
 	)
 	if errSecret != nil {
-		fmt.Fprintln(os.Stderr, "[Error] ArgoCD.Deploy.FetchSecret:", errSecret)
+		slog.Error("Failed to fetch the secret", "file", secretYml, "error", errSecret)
 		return errSecret
 	}
 
@@ -118,7 +127,7 @@ func Deploy(params argocd.ArgoCDDeployParams) error {
 			outputDir,
 		)
 		if errExtraSecret != nil {
-			fmt.Fprintln(os.Stderr, "[Error] ArgoCD.Deploy.FetchExtraSecrets:", errExtraSecret)
+			slog.Error("Failed to fetch the extra secrets", "files", params.ExtraSecrets, "error", errExtraSecret)
 			return errExtraSecret
 		}
 	}
@@ -138,7 +147,7 @@ func Deploy(params argocd.ArgoCDDeployParams) error {
 			moduleSecretYml,
 		)
 		if errModuleSecret != nil {
-			fmt.Fprintln(os.Stderr, "[Error] ArgoCD.Deploy.FetchSecretModule:", errModuleSecret)
+			slog.Error("Failed to fetch the secret of the module", "file", moduleSecretYml, "error", errModuleSecret)
 			return errModuleSecret
 		}
 	}
@@ -152,7 +161,7 @@ func Deploy(params argocd.ArgoCDDeployParams) error {
 			"\""+imageTag+"\"",
 		)
 		if errImageV != nil {
-			fmt.Fprintln(os.Stderr, "[Error] ArgoCD.Deploy.UpdateImageTagWithRegex:", errImageV)
+			slog.Error("Failed to update the image tag in the values", "tag", imageTag, "error", errImageV)
 			return errImageV
 		}
 	}
@@ -196,9 +205,33 @@ func Deploy(params argocd.ArgoCDDeployParams) error {
 	// Run the command
 	errCmd := cmd.Run()
 	if errCmd != nil {
-		fmt.Fprintln(os.Stderr, "[Error] ArgoCD.Deploy.Cmd.Run:", errCmd)
+		slog.Error("Helm template failed", "error", errCmd)
 		return errCmd
 	}
 
 	return nil
 }
+
+// vulnerabilityGate builds the vulnerability gate from the plugin env
+func vulnerabilityGate(params argocd.ArgoCDDeployParams) (docker.VulnerabilityGate, error) {
+	gate := docker.VulnerabilityGate{
+		Threshold:      params.VulnThreshold,
+		Reports:        params.VulnReports,
+		AttestationKey: params.VulnAttestationKey,
+	}
+	if params.VulnAllowUnverified != "" {
+		allow, err := strconv.ParseBool(params.VulnAllowUnverified)
+		if err != nil {
+			return gate, fmt.Errorf("invalid ARGOCD_ENV_VULN_ALLOW_UNVERIFIED '%s'", params.VulnAllowUnverified)
+		}
+		gate.AllowUnverified = allow
+	}
+	if params.VulnMaxFallback != "" {
+		maxFallback, err := strconv.Atoi(params.VulnMaxFallback)
+		if err != nil || maxFallback < 0 {
+			return gate, fmt.Errorf("invalid ARGOCD_ENV_VULN_MAX_FALLBACK '%s'", params.VulnMaxFallback)
+		}
+		gate.MaxFallback = maxFallback
+	}
+	return gate, nil
+}
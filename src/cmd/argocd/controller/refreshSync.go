@@ -2,10 +2,12 @@ package controller
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/argocd/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 )
 
 func RefreshSync(
@@ -19,19 +21,19 @@ func RefreshSync(
 ) (string, []string, error) {
 	// 1. Login to ArgoCD and init client
 	if err := be.InitArgoClientWithLogin("https://"+argocdUrl, argocdUsername, argocdPassword); err != nil {
-		return "error", nil, fmt.Errorf("[Error] Failed to authenticate to ArgoCD: %v", err)
+		return "error", nil, helpers.NewCommandError(helpers.ExitAuth, fmt.Errorf("failed to authenticate to ArgoCD: %v", err))
 	}
 
 	// 2. Get all apps matching gitId/env
 	appNames := be.GetAppNames(gitId, gitlabPath, env)
 	if len(appNames) == 0 {
-		return "error", nil, fmt.Errorf("[Error] no applications found for gitId: %s and env: %s", gitId, env)
+		return "error", nil, helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("no applications found for gitId: %s and env: %s", gitId, env))
 	}
 
 	// 3. If no regions specified, sync all apps in appNames as-is
 	if strings.TrimSpace(regions) == "" {
 		for _, appName := range appNames {
-			fmt.Printf("[Info] Refreshing and syncing app: %s\n", appName)
+			slog.Info("Refreshing and syncing app", "app", appName)
 			if status, err := syncAppWithPolling(appName); err != nil {
 				return status, appNames, err
 			}
@@ -43,7 +45,7 @@ func RefreshSync(
 		for _, region := range orderedRegions {
 			for _, appName := range appNames {
 				if strings.HasSuffix(appName, "-"+region) {
-					fmt.Printf("[Info] Refreshing and Syncing app: %s\n", appName)
+					slog.Info("Refreshing and syncing app", "app", appName, "region", region)
 					if status, err := syncAppWithPolling(appName); err != nil {
 						return status, appNames, err
 					}
@@ -57,53 +59,53 @@ func RefreshSync(
 func syncAppWithPolling(appName string) (string, error) {
 	// First perform hard refresh, then sync
 	if err := be.TriggerArgoHardRefreshAndSync(appName); err != nil {
-		return "error", fmt.Errorf("[Error] Failed to trigger hard refresh and sync for %s: %v", appName, err)
+		return "error", fmt.Errorf("failed to trigger hard refresh and sync for %s: %v", appName, err)
 	}
 
 	for {
 		status, err := be.GetArgoAppStatus(appName)
 		if err != nil {
-			return "error", fmt.Errorf("[Error] Failed to get status for %s: %v", appName, err)
+			return "error", fmt.Errorf("failed to get status for %s: %v", appName, err)
 		}
 
 		// Check operationState first (most important for sync operations)
 		if status.Status.OperationState != nil {
 			// Log current operationState for debugging with detailed info
 			phase := status.Status.OperationState.Phase
-			fmt.Printf("[Info] App %s - OperationState Phase: '%s'\n", appName, phase)
+			slog.Debug("Operation state", "app", appName, "phase", phase)
 
 			if phase == "Error" || phase == "Failed" {
-				return "error", fmt.Errorf("[Error] Sync operation failed for %s: %s", appName, status.Status.OperationState.Message)
+				return "error", fmt.Errorf("sync operation failed for %s: %s", appName, status.Status.OperationState.Message)
 			}
 
 			// Check if sync completed successfully with operationState
 			if status.Status.OperationState.Phase == "Succeeded" &&
 				status.Status.Sync.Status == "Synced" &&
 				status.Status.Health.Status == "Healthy" {
-				fmt.Printf("[Info] App %s successfully synced and healthy\n", appName)
+				slog.Info("App successfully synced and healthy", "app", appName)
 				break
 			}
 		} else {
 			// Fallback to basic sync/health check when operationState is null
 			if status.Status.Sync.Status == "Synced" && status.Status.Health.Status == "Healthy" {
-				fmt.Printf("[Info] App %s successfully synced and healthy (no operationState)\n", appName)
+				slog.Info("App successfully synced and healthy", "app", appName, "operation_state", false)
 				break
 			}
 		}
 
 		// Check for degraded health or failed sync status
 		if status.Status.Health.Status == "Degraded" || status.Status.Sync.Status == "Failed" {
-			return "error", fmt.Errorf("[Error] Sync failed for %s - Health: %s, Sync: %s",
+			return "error", fmt.Errorf("sync failed for %s - Health: %s, Sync: %s",
 				appName, status.Status.Health.Status, status.Status.Sync.Status)
 		}
 
 		// Log current status for debugging
 		if status.Status.OperationState != nil {
-			fmt.Printf("[Info] App %s - Phase: %s, Sync: %s, Health: %s\n",
-				appName, status.Status.OperationState.Phase, status.Status.Sync.Status, status.Status.Health.Status)
+			slog.Info("Waiting for app", "app", appName, "phase", status.Status.OperationState.Phase,
+				"sync", status.Status.Sync.Status, "health", status.Status.Health.Status)
 		} else {
-			fmt.Printf("[Info] App %s - Sync: %s, Health: %s (no operationState)\n",
-				appName, status.Status.Sync.Status, status.Status.Health.Status)
+			slog.Info("Waiting for app", "app", appName,
+				"sync", status.Status.Sync.Status, "health", status.Status.Health.Status)
 		}
 
 		time.Sleep(5 * time.Second)
@@ -3,23 +3,23 @@ package shared
 import (
 	"encoding/json"
 	"fmt"
-	"regexp"
-	"sort"
-	"strings"
-
-	"github.com/Masterminds/semver/v3"
+	"log/slog"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/controller"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
 )
 
-func FetchLatestTag(repoUrl string, dockerRepo string) (string, error) {
+// FetchLatestTag returns the highest release tag of the docker repository.
+// When the vulnerability gate is enabled (threshold set) the tags with findings
+// at or above the threshold are refused and the previous version is taken.
+func FetchLatestTag(repoUrl string, dockerRepo string, gate docker.VulnerabilityGate) (string, error) {
 	// Get the complete dockerhub path from repoUrl
 	dockerRepoPath := helpers.ReturnCompleteDockerRepoPath(repoUrl, dockerRepo)
 
 	// Get image list
 	imageListBytes, err := controller.GetImages(
+		"",
 		dockerRepoPath,
 		"100",
 		"",
@@ -27,66 +27,36 @@ func FetchLatestTag(repoUrl string, dockerRepo string) (string, error) {
 		true,
 	)
 	if err != nil {
-		return "error", fmt.Errorf("[Error] failed to fetch image list: %v", err)
+		return "error", fmt.Errorf("failed to fetch image list: %v", err)
 	}
 
 	var response docker.DockerHubResponse
 	if err := json.Unmarshal(imageListBytes, &response); err != nil {
-		return "error", fmt.Errorf("[Error] failed to parse image list JSON: %v", err)
-	}
-
-	// Get highest semver tag
-	highest, err := getHighestSemverFromTags(response.Data.TagList)
-	if err != nil {
-		return "error", err
+		return "error", fmt.Errorf("failed to parse image list JSON: %v", err)
 	}
 
-	return highest, nil
-}
-
-func getHighestSemverFromTags(tags []docker.TagInfoInternal) (string, error) {
-	helpers.LoadConfig()
-	semverRe := regexp.MustCompile(`^v?(\d+\.\d+\.\d+)$`)
-	var versions []*semver.Version
-	tagToOriginal := make(map[string]string)
-
-	for _, tag := range tags {
-		tagName := strings.TrimSpace(tag.Name)
-
-		if !semverRe.MatchString(tagName) {
-			if helpers.AppConfig.SINALOA_DEBUG {
-				fmt.Printf("Skipping non-matching tag: %s\n", tagName)
+	// Get highest semver tag passing the vulnerability gate
+	if gate.Threshold != "" {
+		tag, decisions, err := controller.LatestAcceptableTag(dockerRepoPath, response.Data.TagList, gate)
+		// Stdout is the rendered manifest, the decisions go to stderr
+		for _, decision := range decisions {
+			status := "refused"
+			if decision.Accepted {
+				status = "accepted"
 			}
-			continue
+			slog.Info("Vulnerability gate", "status", status, "tag", decision.Tag, "reason", decision.Reason)
 		}
-
-		matches := semverRe.FindStringSubmatch(tagName)
-		if len(matches) != 2 {
-			if helpers.AppConfig.SINALOA_DEBUG {
-				fmt.Printf("Skipping malformed tag: %s\n", tagName)
-			}
-			continue
-		}
-
-		normalized := matches[1] // e.g., 0.1.0
-		v, err := semver.NewVersion(normalized)
 		if err != nil {
-			if helpers.AppConfig.SINALOA_DEBUG {
-				fmt.Printf("Skipping invalid semver tag: %s (%v)\n", tagName, err)
-			}
-			continue
+			return "error", fmt.Errorf("vulnerability gate: %v", err)
 		}
-
-		versions = append(versions, v)
-		tagToOriginal[v.String()] = tagName
+		return tag, nil
 	}
 
-	if len(versions) == 0 {
-		return "", fmt.Errorf("[Error] no valid semver tags found")
+	// Get highest semver tag
+	highest, err := controller.HighestSemverTag(response.Data.TagList)
+	if err != nil {
+		return "error", err
 	}
 
-	sort.Sort(semver.Collection(versions))
-	highest := versions[len(versions)-1]
-
-	return tagToOriginal[highest.String()], nil
+	return highest, nil
 }
@@ -32,7 +32,7 @@ func FetchSecret(
 		localPathToSaveFile,
 	)
 	if err != nil {
-		return fmt.Errorf("[Error] Failed to fetch manifest from OneDrive (FetchSecret): %v", err)
+		return fmt.Errorf("failed to fetch manifest from OneDrive (FetchSecret): %v", err)
 	}
 
 	return nil
@@ -58,7 +58,7 @@ func FetchExtraSecrets(
 			file,
 		)
 		if errSecret != nil {
-			return fmt.Errorf("[Error] Failed to fetch manifest from OneDrive (FetchExtraSecrets): %v", errSecret)
+			return fmt.Errorf("failed to fetch manifest from OneDrive (FetchExtraSecrets): %v", errSecret)
 		}
 	}
 
@@ -3,11 +3,11 @@ package sub
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/argocd/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/argocd"
 )
 
@@ -19,11 +19,13 @@ var DeployArgocdCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "ArgoCD deploy cmd using the plugin",
 	Long:  "Deploy an application using ArgoCD with the specified JSON configuration, through the argo-plugin cmp.",
-	Run: func(cmd *cobra.Command, args []string) {
+	// The secrets are read from OneDrive and the latest tag from the registry,
+	// the argocd credentials come from the argocd command
+	Annotations: map[string]string{helpers.AnnotationCredentials: "azure,docker-hub,docker-registry"},
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		// Check if the json input is provided
 		if jsonInput == "" {
-			fmt.Fprintln(os.Stderr, "[Error] JSON input is required (-j or --json)")
-			os.Exit(1)
+			return nil, helpers.ValidationError("JSON input is required (-j or --json)")
 		}
 
 		// Convert the json input string
@@ -31,16 +33,16 @@ var DeployArgocdCmd = &cobra.Command{
 		var params argocd.ArgoCDDeployParams
 		errParseClass := json.Unmarshal([]byte(jsonInput), &params)
 		if errParseClass != nil {
-			fmt.Fprintln(os.Stderr, "[Error] Failed to parse JSON input:", errParseClass)
-			os.Exit(1)
+			return nil, helpers.ValidationError("Failed to parse JSON input: %v", errParseClass)
 		}
 
 		// Execute the deploy
 		errDeploy := controller.Deploy(params)
 		if errDeploy != nil {
-			fmt.Fprintln(os.Stderr, "[Error] Failed to deploy with ArgoCD... ", errDeploy)
+			return nil, fmt.Errorf("Failed to deploy with ArgoCD... %w", errDeploy)
 		}
-	},
+		return nil, nil
+	}),
 }
 
 func init() {
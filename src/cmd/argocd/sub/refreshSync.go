@@ -1,9 +1,6 @@
 package sub
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/argocd/controller"
@@ -21,10 +18,7 @@ var SyncArgocdCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "ArgoCD sync apps",
 	Long:  "Sync applications from ArgoCD",
-	Run: func(cmd *cobra.Command, args []string) {
-		// Load configuration from .env
-		helpers.LoadConfig()
-
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		// Start the argocd sync
 		_, _, err := controller.RefreshSync(
 			gitId,
@@ -36,10 +30,8 @@ var SyncArgocdCmd = &cobra.Command{
 			helpers.AppConfig.ARGOCD_PASSWORD,
 		)
 
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%v\n", err)
-		}
-	},
+		return nil, err
+	}),
 }
 
 func init() {
@@ -47,4 +39,12 @@ func init() {
 	SyncArgocdCmd.Flags().StringVarP(&gitlabPath, "gitlab-path", "p", "", "Gitlab path of the application")
 	SyncArgocdCmd.Flags().StringVarP(&env, "env", "e", "", "Environments like dev, test, prod")
 	SyncArgocdCmd.Flags().StringVarP(&regions, "regions", "r", "", "Regions, if an application need to be deployed in multiple clusters at the same time")
+
+	// Shell completion of the applications of ArgoCD
+	SyncArgocdCmd.RegisterFlagCompletionFunc("git-id", helpers.CompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+		return controller.CompleteGitIDs()
+	}))
+	SyncArgocdCmd.RegisterFlagCompletionFunc("regions", helpers.ListCompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+		return controller.CompleteRegions(gitId, env)
+	}))
 }
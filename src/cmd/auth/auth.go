@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/auth/sub"
+	"github.com/spf13/cobra"
+)
+
+var AuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Auth is a palette to store the credentials of the services",
+	Long: `Store the credentials of the services (argocd, azure, docker-hub, docker-registry, github) by profile,
+in the Secret Service keyring when available or in a passphrase-encrypted file next to the config file
+(SINALOA_CREDENTIAL_STORE=keyring|file selects the store, SINALOA_PASSPHRASE gives the passphrase).
+The stored credentials override the profile of the config file, the environment variables override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	AuthCmd.AddCommand(sub.LoginCmd)
+	AuthCmd.AddCommand(sub.StatusCmd)
+	AuthCmd.AddCommand(sub.LogoutCmd)
+}
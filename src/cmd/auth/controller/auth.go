@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// Sources of the value of a credential in auth status
+const (
+	sourceEnv    = "env"
+	sourceStore  = "credential store"
+	sourceConfig = "config file"
+	sourceNotSet = "not set"
+)
+
+// serviceStatus is the status of the credentials of a service
+type serviceStatus struct {
+	Service     string            `json:"service"`
+	Stored      bool              `json:"stored"`
+	Credentials map[string]string `json:"credentials"` // Source of the value by key
+}
+
+// authStatus is the output of auth status
+type authStatus struct {
+	Store    string          `json:"store"`
+	Profile  string          `json:"profile"`
+	Services []serviceStatus `json:"services"`
+}
+
+// Login stores the credentials of the service for the profile, from the key=value
+// values or, when none is given, asked on the terminal
+func Login(profile string, service string, values []string) ([]byte, error) {
+	keys, ok := helpers.CredentialServices[service]
+	if !ok {
+		return nil, unknownService(service)
+	}
+
+	credentials := map[string]string{}
+	for _, value := range values {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, helpers.ValidationError("invalid --value %q: expected key=value", value)
+		}
+		if !contains(keys, key) {
+			return nil, helpers.ValidationError("%s is not a credential of %s (keys: %s)", key, service, strings.Join(keys, ", "))
+		}
+		credentials[key] = v
+	}
+	if len(values) == 0 {
+		if !helpers.IsTerminal(os.Stdin) {
+			return nil, helpers.ValidationError("no terminal to ask the credentials: pass them with --value key=value")
+		}
+		for _, key := range keys {
+			read := helpers.ReadLine
+			if helpers.IsSecretKey(key) {
+				read = helpers.ReadSecret
+			}
+			value, err := read(key + ": ")
+			if err != nil {
+				return nil, err
+			}
+			credentials[key] = strings.TrimSpace(value)
+		}
+	}
+	for key, value := range credentials {
+		if value == "" {
+			delete(credentials, key)
+		}
+	}
+	if len(credentials) == 0 {
+		return nil, helpers.ValidationError("no credentials given for %s", service)
+	}
+
+	name, store, err := openStore(profile)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := store.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	stored[service] = credentials
+	if err := store.Save(name, stored); err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("Stored the %s credentials of profile %s in the %s", service, name, store.Name())), nil
+}
+
+// Status returns the services with stored credentials of the profile and where
+// the value of each credential comes from
+func Status(profile string) ([]byte, error) {
+	name, store, err := openStore(profile)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := store.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := helpers.ReadConfigFile(helpers.ConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	configured := helpers.ConfigValues(file.Profiles[name], true)
+
+	status := authStatus{Store: store.Name(), Profile: name}
+	for _, service := range helpers.CredentialServiceNames() {
+		serviceStatus := serviceStatus{
+			Service:     service,
+			Stored:      len(stored[service]) > 0,
+			Credentials: map[string]string{},
+		}
+		for _, key := range helpers.CredentialServices[service] {
+			env, _ := helpers.ConfigEnvName(key)
+			switch {
+			case os.Getenv(env) != "":
+				serviceStatus.Credentials[key] = sourceEnv
+			case stored[service][key] != "":
+				serviceStatus.Credentials[key] = sourceStore
+			case configured[key] != "":
+				serviceStatus.Credentials[key] = sourceConfig
+			default:
+				serviceStatus.Credentials[key] = sourceNotSet
+			}
+		}
+		status.Services = append(status.Services, serviceStatus)
+	}
+
+	jsonData, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return jsonData, nil
+}
+
+// Logout removes the stored credentials of the service, or of all the services, of the profile
+func Logout(profile string, service string, all bool) ([]byte, error) {
+	if (service == "") == !all {
+		return nil, helpers.ValidationError("expected a service or --all")
+	}
+	if service != "" {
+		if _, ok := helpers.CredentialServices[service]; !ok {
+			return nil, unknownService(service)
+		}
+	}
+
+	name, store, err := openStore(profile)
+	if err != nil {
+		return nil, err
+	}
+	stored := helpers.Credentials{}
+	if service != "" {
+		// Only the other services are kept
+		if stored, err = store.Load(name); err != nil {
+			return nil, err
+		}
+		if _, ok := stored[service]; !ok {
+			return nil, helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("no %s credentials stored for profile %s", service, name))
+		}
+		delete(stored, service)
+	}
+	if err := store.Save(name, stored); err != nil {
+		return nil, err
+	}
+
+	if all {
+		return []byte(fmt.Sprintf("Removed all the credentials of profile %s", name)), nil
+	}
+	return []byte(fmt.Sprintf("Removed the %s credentials of profile %s", service, name)), nil
+}
+
+// openStore returns the selected profile and the credential store
+func openStore(profile string) (string, helpers.CredentialStore, error) {
+	path := helpers.ConfigPath()
+	file, err := helpers.ReadConfigFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	store, err := helpers.OpenCredentialStore(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return helpers.SelectProfile(file, profile), store, nil
+}
+
+// unknownService returns the error of a service that is not one of auth login
+func unknownService(service string) error {
+	return helpers.ValidationError("unknown service %q (services: %s)", service, strings.Join(helpers.CredentialServiceNames(), ", "))
+}
+
+// contains returns true if the list has the value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package sub
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/auth/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var loginValues []string
+
+var LoginCmd = &cobra.Command{
+	Use:       "login <service>",
+	Short:     "Store the credentials of a service",
+	ValidArgs: helpers.CredentialServiceNames(),
+	Long: `Store the credentials of a service in the credential store, for the profile selected with --profile.
+The values are asked on the terminal (the secrets without echo) or given with --value key=value.
+
+Services: ` + strings.Join(helpers.CredentialServiceNames(), ", ") + `
+
+Example:
+  sinaloa auth login argocd
+  sinaloa auth login github --value github_token=$TOKEN`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{helpers.AnnotationManagesProfiles: "true"},
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.Login(helpers.ProfileFlag(cmd), args[0], loginValues)
+	}),
+}
+
+func init() {
+	LoginCmd.Flags().StringArrayVar(&loginValues, "value", nil, "Value of a credential as key=value (e.g. argocd_user=admin), repeatable")
+}
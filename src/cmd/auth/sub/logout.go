@@ -0,0 +1,35 @@
+package sub
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/auth/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var logoutAll bool
+
+var LogoutCmd = &cobra.Command{
+	Use:       "logout [service]",
+	Short:     "Remove the stored credentials of a service",
+	ValidArgs: helpers.CredentialServiceNames(),
+	Long: `Remove the stored credentials of a service, or of all the services with --all,
+for the profile selected with --profile.
+
+Example:
+  sinaloa auth logout argocd
+  sinaloa auth logout --all`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{helpers.AnnotationManagesProfiles: "true"},
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		service := ""
+		if len(args) == 1 {
+			service = args[0]
+		}
+		return controller.Logout(helpers.ProfileFlag(cmd), service, logoutAll)
+	}),
+}
+
+func init() {
+	LogoutCmd.Flags().BoolVar(&logoutAll, "all", false, "Remove the credentials of all the services")
+}
@@ -0,0 +1,22 @@
+package sub
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/auth/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the stored credentials and where each value comes from",
+	Long: `Show the credential store, the services with stored credentials of the profile and,
+for each credential, where its value comes from: env, credential store or config file.
+
+Example:
+  sinaloa auth status --profile prod`,
+	Annotations: map[string]string{helpers.AnnotationManagesProfiles: "true"},
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.Status(helpers.ProfileFlag(cmd))
+	}),
+}
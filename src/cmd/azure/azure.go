@@ -1,6 +1,7 @@
 package azure
 
 import (
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive"
@@ -8,9 +9,10 @@ import (
 
 // AzureCmd represents the azure command
 var AzureCmd = &cobra.Command{
-	Use:   "azure",
-	Short: "Azure-related commands",
-	Long:  "Commands to manage Azure services such as OneDrive, office365, azure resources, etc.",
+	Use:         "azure",
+	Short:       "Azure-related commands",
+	Long:        "Commands to manage Azure services such as OneDrive, office365, azure resources, etc.",
+	Annotations: map[string]string{helpers.AnnotationCredentials: "azure"},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help() // Show help if no subcommands are provided
 	},
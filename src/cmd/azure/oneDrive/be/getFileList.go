@@ -10,21 +10,18 @@ import (
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/azure"
 )
 
-// GetDriveItems uses the helpers.ApiClient to request items from a specific path in OneDrive
-func GetDriveItems(path string) (models.ApiResponse, error) {
+// GetDriveItems uses the helpers.ApiClient to request items from a specific path in the OneDrive of the credentials
+func GetDriveItems(credentials azure.Credentials, path string) (models.ApiResponse, error) {
 	// Declare variables
 	var endpoint string
 	var apiGraph azure.OneDriveGraphResponseApiModel
 	var items []azure.OneDriveItemModel
 
-	// Load configuration from .env
-	helpers.LoadConfig()
-
 	// Initialize the GraphApiClient
 	graphApiClient := shared.NewGraphApiClient(
-		helpers.AppConfig.AZURE_CLIENT_ID,
-		helpers.AppConfig.AZURE_CLIENT_SECRET,
-		helpers.AppConfig.AZURE_TENANT_ID,
+		credentials.ClientID,
+		credentials.ClientSecret,
+		credentials.TenantID,
 	)
 
 	// Get the access token from the GraphApiClient
@@ -40,9 +37,9 @@ func GetDriveItems(path string) (models.ApiResponse, error) {
 
 	// Set the full endpoint URL using the DRIVE ID and path
 	if path == "." {
-		endpoint = fmt.Sprintf("%s/root/children", helpers.AppConfig.AZURE_DRIVE_ID)
+		endpoint = fmt.Sprintf("%s/root/children", credentials.DriveID)
 	} else {
-		endpoint = fmt.Sprintf("%s/root:/%s:/children", helpers.AppConfig.AZURE_DRIVE_ID, path)
+		endpoint = fmt.Sprintf("%s/root:/%s:/children", credentials.DriveID, path)
 	}
 
 	// Use the existing request method from ApiClient to make the GET request
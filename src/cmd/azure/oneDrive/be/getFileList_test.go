@@ -6,7 +6,6 @@ import (
 	"testing"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive/be"
-	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/azure"
 
@@ -14,21 +13,23 @@ import (
 )
 
 // Mocking dependencies
-type MockGraphApiClient struct{}
+type MockGraphApiClient struct {
+	ClientID string
+}
 
 func (m *MockGraphApiClient) GetAccessToken() (string, error) {
-	if helpers.AppConfig.AZURE_CLIENT_ID == "error" {
+	if m.ClientID == "error" {
 		return "", errors.New("mock error: failed to get access token")
 	}
 	return "mock-access-token", nil
 }
 
-func mockLoadConfig(clientID, clientSecret, tenantID, driveID string) {
-	helpers.AppConfig = helpers.Config{
-		AZURE_CLIENT_ID:     clientID,
-		AZURE_CLIENT_SECRET: clientSecret,
-		AZURE_TENANT_ID:     tenantID,
-		AZURE_DRIVE_ID:      driveID,
+func mockLoadConfig(clientID, clientSecret, tenantID, driveID string) azure.Credentials {
+	return azure.Credentials{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TenantID:     tenantID,
+		DriveID:      driveID,
 	}
 }
 
@@ -64,10 +65,10 @@ func mockApiClientRequest(method, endpoint string, body interface{}) models.ApiR
 // Test cases
 func TestGetDriveItems_Success(t *testing.T) {
 	// Arrange: Mock dependencies
-	mockLoadConfig("mock-client-id", "mock-client-secret", "mock-tenant-id", "mock-drive-id")
+	credentials := mockLoadConfig("mock-client-id", "mock-client-secret", "mock-tenant-id", "mock-drive-id")
 
 	// Act: Call GetDriveItems
-	result, _ := be.GetDriveItems("/mock-path")
+	result, _ := be.GetDriveItems(credentials, "/mock-path")
 
 	// Assert: Verify response
 	assert.NotEmpty(t, result, "Response should not empty")
@@ -75,10 +76,10 @@ func TestGetDriveItems_Success(t *testing.T) {
 
 func TestGetDriveItems_AccessTokenError(t *testing.T) {
 	// Arrange: Mock dependencies
-	mockLoadConfig("error", "mock-client-secret", "mock-tenant-id", "mock-drive-id")
+	credentials := mockLoadConfig("error", "mock-client-secret", "mock-tenant-id", "mock-drive-id")
 
 	// Act: Call GetDriveItems
-	result, err := be.GetDriveItems("/mock-path")
+	result, err := be.GetDriveItems(credentials, "/mock-path")
 
 	// Assert: Verify response
 	assert.Error(t, err, "GetDriveItems should return an error if access token retrieval fails")
@@ -87,10 +88,10 @@ func TestGetDriveItems_AccessTokenError(t *testing.T) {
 
 func TestGetDriveItems_UnmarshalError(t *testing.T) {
 	// Arrange: Mock dependencies
-	mockLoadConfig("mock-client-id", "mock-client-secret", "mock-tenant-id", "mock-drive-id")
+	credentials := mockLoadConfig("mock-client-id", "mock-client-secret", "mock-tenant-id", "mock-drive-id")
 
 	// Act: Call GetDriveItems
-	result, err := be.GetDriveItems("/mock-path")
+	result, err := be.GetDriveItems(credentials, "/mock-path")
 
 	// Assert: Verify response
 	assert.Error(t, err, "GetDriveItems should return an error if unmarshalling fails")
@@ -99,10 +100,10 @@ func TestGetDriveItems_UnmarshalError(t *testing.T) {
 
 func TestGetDriveItems_MissingConfig(t *testing.T) {
 	// Arrange: Mock empty configuration
-	mockLoadConfig("", "", "", "")
+	credentials := mockLoadConfig("", "", "", "")
 
 	// Act: Call GetDriveItems
-	result, err := be.GetDriveItems("/mock-path")
+	result, err := be.GetDriveItems(credentials, "/mock-path")
 
 	// Assert: Verify response
 	assert.Error(t, err, "GetDriveItems should return an error if configuration is missing")
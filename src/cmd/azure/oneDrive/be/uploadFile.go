@@ -15,16 +15,14 @@ import (
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/azure"
 )
 
-// UploadItem uploads a local file to a specified path in OneDrive
-func UploadItem(localPath string, pathToUpload string) (bool, error) {
-	// Load environment variables
-	helpers.LoadConfig()
+// UploadItem uploads a local file to a specified path in the OneDrive of the credentials
+func UploadItem(credentials azure.Credentials, localPath string, pathToUpload string) (bool, error) {
 
 	// Initialize the Graph API client
 	graphApiClient := shared.NewGraphApiClient(
-		helpers.AppConfig.AZURE_CLIENT_ID,
-		helpers.AppConfig.AZURE_CLIENT_SECRET,
-		helpers.AppConfig.AZURE_TENANT_ID,
+		credentials.ClientID,
+		credentials.ClientSecret,
+		credentials.TenantID,
 	)
 
 	// Obtain an access token
@@ -45,7 +43,7 @@ func UploadItem(localPath string, pathToUpload string) (bool, error) {
 	uploadSessionUrl, err := CreateUploadSession(
 		graphApiClient.BaseURL+"drives/",
 		accessToken,
-		helpers.AppConfig.AZURE_DRIVE_ID,
+		credentials.DriveID,
 		dir,
 		file,
 	)
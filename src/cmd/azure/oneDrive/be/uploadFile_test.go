@@ -9,6 +9,7 @@ import (
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive/be"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/azure"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -43,7 +44,7 @@ func TestUploadItem(t *testing.T) {
 	_, err = tempFile.Write([]byte("This is a test file"))
 	assert.NoError(t, err)
 
-	success, err := be.UploadItem(tempFile.Name(), "/test/path/file.txt")
+	success, err := be.UploadItem(azure.Credentials{}, tempFile.Name(), "/test/path/file.txt")
 	assert.Empty(t, success)
 }
 
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/azure"
+)
+
+// CompletePaths returns the items of the OneDrive folder of the path being completed for
+// the shell completion, the folders ending with a /, cached for a short time
+func CompletePaths(toComplete string, foldersOnly bool) []string {
+	// Folder of the path, with its trailing /
+	folder := ""
+	if i := strings.LastIndex(toComplete, "/"); i >= 0 {
+		folder = toComplete[:i+1]
+	}
+	listPath := strings.Trim(folder, "/")
+	if listPath == "" {
+		listPath = "."
+	}
+
+	items := helpers.CachedCompletions("onedrive items "+helpers.AppConfig.AZURE_DRIVE_ID+" "+listPath, func() ([]string, error) {
+		apiResponse, err := be.GetDriveItems(azureCredentials(), listPath)
+		if err != nil {
+			return nil, err
+		}
+		if !apiResponse.Response {
+			return nil, fmt.Errorf("%s", apiResponse.Message)
+		}
+		var wrapper azure.OneDriveWrapperModel
+		if err := json.Unmarshal(apiResponse.Body, &wrapper); err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, item := range wrapper.Values {
+			if item.Type == "folder" {
+				names = append(names, item.Name+"/")
+			} else {
+				names = append(names, item.Name)
+			}
+		}
+		return names, nil
+	})
+
+	var completions []string
+	for _, name := range items {
+		if foldersOnly && !strings.HasSuffix(name, "/") {
+			continue
+		}
+		completions = append(completions, folder+name)
+	}
+	return completions
+}
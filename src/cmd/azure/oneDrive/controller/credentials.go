@@ -0,0 +1,16 @@
+package controller
+
+import (
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/azure"
+)
+
+// azureCredentials returns the Azure credentials of the loaded configuration
+func azureCredentials() azure.Credentials {
+	return azure.Credentials{
+		ClientID:     helpers.AppConfig.AZURE_CLIENT_ID,
+		ClientSecret: helpers.AppConfig.AZURE_CLIENT_SECRET,
+		TenantID:     helpers.AppConfig.AZURE_TENANT_ID,
+		DriveID:      helpers.AppConfig.AZURE_DRIVE_ID,
+	}
+}
@@ -20,7 +20,7 @@ func GetFile(path string, pathToSaveFile string) ([]byte, error) {
 	requiredName := filepath.Base(path)
 
 	// Call the GetDriveItems function from the backend
-	apiResponse, err := be.GetDriveItems(directoryPath)
+	apiResponse, err := be.GetDriveItems(azureCredentials(), directoryPath)
 	if err != nil {
 		return helpers.HandleControllerApi(
 			false,
@@ -9,7 +9,7 @@ import (
 
 func GetFileList(path string) ([]byte, error) {
 	// Call the GetDriveItems function from the backend
-	apiResponse, err := be.GetDriveItems(path)
+	apiResponse, err := be.GetDriveItems(azureCredentials(), path)
 	// Handle the response
 	return helpers.HandleControllerApi(
 		apiResponse.Response,
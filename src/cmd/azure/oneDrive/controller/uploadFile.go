@@ -9,7 +9,7 @@ func UploadFile(localPath string, pathToUpload string) ([]byte, error) {
 	// Declare variables
 	var data map[string]interface{}
 	// Call the GetDriveItems function from the backend
-	result, err := be.UploadItem(localPath, pathToUpload)
+	result, err := be.UploadItem(azureCredentials(), localPath, pathToUpload)
 	// Create interface for data return
 	data = map[string]interface{}{
 		"result": result,
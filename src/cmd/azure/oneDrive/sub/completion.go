@@ -0,0 +1,34 @@
+package sub
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// completeDriveFiles is the shell completion of the files and folders of OneDrive
+func completeDriveFiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeDrivePaths(cmd, args, toComplete, false)
+}
+
+// completeDriveFolders is the shell completion of the folders of OneDrive
+func completeDriveFolders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeDrivePaths(cmd, args, toComplete, true)
+}
+
+// completeDrivePaths completes a OneDrive path, no space is added after a folder to go on into it
+func completeDrivePaths(cmd *cobra.Command, args []string, toComplete string, foldersOnly bool) ([]string, cobra.ShellCompDirective) {
+	completions, directive := helpers.CompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+		return controller.CompletePaths(toComplete, foldersOnly)
+	})(cmd, args, toComplete)
+	for _, completion := range completions {
+		if strings.HasSuffix(completion, "/") {
+			directive |= cobra.ShellCompDirectiveNoSpace
+			break
+		}
+	}
+	return completions, directive
+}
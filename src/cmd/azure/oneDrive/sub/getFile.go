@@ -1,11 +1,10 @@
 package sub
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 )
 
 var (
@@ -17,12 +16,10 @@ var GetfileOnedriveCmd = &cobra.Command{
 	Use:   "get-file",
 	Short: "Get a file from onedrive",
 	Long:  "Get a file from onedrive",
-	Run: func(cmd *cobra.Command, args []string) {
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		// Call the controller's GetFileList function
-		result, _ := controller.GetFile(file, path_to_store)
-		// Print the response
-		fmt.Println(string(result))
-	},
+		return controller.GetFile(file, path_to_store)
+	}),
 }
 
 func init() {
@@ -30,4 +27,5 @@ func init() {
 	GetfileOnedriveCmd.MarkFlagRequired("file")
 	GetfileOnedriveCmd.Flags().StringVarP(&path_to_store, "path_to_store", "g", "", "path where you want store the file from onedrive locally, example: -g /tmp/file.txt")
 	GetfileOnedriveCmd.MarkFlagRequired("path_to_store")
+	GetfileOnedriveCmd.RegisterFlagCompletionFunc("file", completeDriveFiles)
 }
@@ -1,11 +1,10 @@
 package sub
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 )
 
 var (
@@ -16,15 +15,14 @@ var GetfileListOnedriveCmd = &cobra.Command{
 	Use:   "get-file-list",
 	Short: "Get a list of file and folders from onedrive",
 	Long:  "Get a list of file and folders from onedrive",
-	Run: func(cmd *cobra.Command, args []string) {
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		// Call the controller's GetFileList function
-		result, _ := controller.GetFileList(path)
-		// Print the response
-		fmt.Println(string(result))
-	},
+		return controller.GetFileList(path)
+	}),
 }
 
 func init() {
 	GetfileListOnedriveCmd.Flags().StringVarP(&path, "path", "g", "", "path where you want see the list of the files or folders, example: -g /docs or . to show the root")
 	GetfileListOnedriveCmd.MarkFlagRequired("path")
+	GetfileListOnedriveCmd.RegisterFlagCompletionFunc("path", completeDriveFolders)
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive/sub"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,6 +14,11 @@ import (
 // Mock the controller's GetFileList function
 type MockGetFileListFunc = func(path string) ([]byte, error)
 
+// A failed command must not end the test process
+func init() {
+	helpers.ExitFunc = func(code int) {}
+}
+
 // InjectedControllerGetFileList allows injecting a custom function for testing
 var InjectedControllerGetFileList MockGetFileListFunc
 
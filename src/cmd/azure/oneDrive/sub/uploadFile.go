@@ -1,11 +1,10 @@
 package sub
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/oneDrive/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 )
 
 var (
@@ -17,12 +16,10 @@ var UploadFileOnedriveCmd = &cobra.Command{
 	Use:   "upload-file",
 	Short: "Upload a file to onedrive",
 	Long:  "Upload a file to onedrive",
-	Run: func(cmd *cobra.Command, args []string) {
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		// Call the controller's UploadFile function
-		result, _ := controller.UploadFile(file_path_to_upload, upload_path)
-		// Print the response
-		fmt.Println(string(result))
-	},
+		return controller.UploadFile(file_path_to_upload, upload_path)
+	}),
 }
 
 func init() {
@@ -30,4 +27,5 @@ func init() {
 	UploadFileOnedriveCmd.MarkFlagRequired("file_path_to_upload")
 	UploadFileOnedriveCmd.Flags().StringVarP(&upload_path, "upload_path", "g", "", "path where you want store the file in onedrive, example: -g config/file.txt")
 	UploadFileOnedriveCmd.MarkFlagRequired("upload_path")
+	UploadFileOnedriveCmd.RegisterFlagCompletionFunc("upload_path", completeDriveFolders)
 }
@@ -7,7 +7,7 @@ import (
 	auth "github.com/microsoft/kiota-authentication-azure-go"
 	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
 
-	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/azure"
 )
 
 type GraphClient struct {
@@ -17,9 +17,9 @@ type GraphClient struct {
 
 // NewGraphClient initializes a new GraphClient and returns its instance
 // https://learn.microsoft.com/en-us/graph/tutorials/go-app-only?tabs=aad
-func NewGraphClient() (*msgraphsdk.GraphServiceClient, error) {
+func NewGraphClient(credentials azure.Credentials) (*msgraphsdk.GraphServiceClient, error) {
 	client := &GraphClient{}
-	err := client.initializeGraphForAppAuth()
+	err := client.initializeGraphForAppAuth(credentials)
 	if err != nil {
 		return nil, err
 	}
@@ -27,14 +27,11 @@ func NewGraphClient() (*msgraphsdk.GraphServiceClient, error) {
 }
 
 // initializeGraphForAppAuth sets up the Graph client for application authentication
-func (g *GraphClient) initializeGraphForAppAuth() error {
-	// Load configuration from .env
-	helpers.LoadConfig()
-
-	// Retrieve Azure secrets from AppConfig
-	clientId := helpers.AppConfig.AZURE_CLIENT_ID
-	tenantId := helpers.AppConfig.AZURE_TENANT_ID
-	clientSecret := helpers.AppConfig.AZURE_CLIENT_SECRET
+func (g *GraphClient) initializeGraphForAppAuth(credentials azure.Credentials) error {
+	// Azure secrets of the app registration
+	clientId := credentials.ClientID
+	tenantId := credentials.TenantID
+	clientSecret := credentials.ClientSecret
 
 	if clientId == "" || tenantId == "" || clientSecret == "" {
 		return fmt.Errorf("Graph Client is missing required environment variables: AZURE_CLIENT_ID, AZURE_TENANT_ID, or AZURE_CLIENT_SECRET")
@@ -4,25 +4,25 @@ import (
 	"testing"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure/shared"
-	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/azure"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func mockLoadConfig(clientID, tenantID, clientSecret string) {
-	helpers.AppConfig = helpers.Config{
-		AZURE_CLIENT_ID:     clientID,
-		AZURE_TENANT_ID:     tenantID,
-		AZURE_CLIENT_SECRET: clientSecret,
+func mockLoadConfig(clientID, tenantID, clientSecret string) azure.Credentials {
+	return azure.Credentials{
+		ClientID:     clientID,
+		TenantID:     tenantID,
+		ClientSecret: clientSecret,
 	}
 }
 
 func TestGraphClient_Success(t *testing.T) {
-	// Arrange: Mock AppConfig
-	mockLoadConfig("mock-client-id", "mock-tenant-id", "mock-client-secret")
+	// Arrange: Mock the credentials
+	credentials := mockLoadConfig("mock-client-id", "mock-tenant-id", "mock-client-secret")
 
 	// Act: Create a GraphClient
-	client, err := shared.NewGraphClient()
+	client, err := shared.NewGraphClient(credentials)
 
 	// Assert: Ensure no errors and the client is initialized
 	assert.NoError(t, err, "GraphClient should initialize without error")
@@ -30,11 +30,11 @@ func TestGraphClient_Success(t *testing.T) {
 }
 
 func TestGraphClient_MissingConfig(t *testing.T) {
-	// Arrange: Mock missing AppConfig
-	mockLoadConfig("", "", "")
+	// Arrange: Mock missing credentials
+	credentials := mockLoadConfig("", "", "")
 
 	// Act: Create a GraphClient
-	client, err := shared.NewGraphClient()
+	client, err := shared.NewGraphClient(credentials)
 
 	// Assert: Ensure error is returned for missing configuration
 	assert.Error(t, err, "GraphClient should return an error for missing configuration")
@@ -43,11 +43,11 @@ func TestGraphClient_MissingConfig(t *testing.T) {
 }
 
 func TestGraphClient_CredentialError(t *testing.T) {
-	// Arrange: Mock AppConfig
-	mockLoadConfig("mock-client-id", "mock-tenant-id", "mock-client-secret")
+	// Arrange: Mock the credentials
+	credentials := mockLoadConfig("mock-client-id", "mock-tenant-id", "mock-client-secret")
 
 	// Act: Create a GraphClient
-	client, _ := shared.NewGraphClient()
+	client, _ := shared.NewGraphClient(credentials)
 
 	// Assert: Ensure error is returned for credential failure
 	assert.NotEmpty(t, client, "GraphClient should not be empty")
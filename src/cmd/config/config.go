@@ -0,0 +1,23 @@
+package config
+
+import (
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/config/sub"
+	"github.com/spf13/cobra"
+)
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Config is a palette to manage the config file and its profiles",
+	Long: `Manage the config file (~/.config/sinaloa/config.yaml, or SINALOA_CONFIG) and its named profiles.
+A profile holds the settings of the environment variables (e.g. argocd_url for ARGOCD_URL),
+the values are taken from the flags, then the environment variables, then the profile.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(sub.ViewCmd)
+	ConfigCmd.AddCommand(sub.SetCmd)
+	ConfigCmd.AddCommand(sub.UseProfileCmd)
+}
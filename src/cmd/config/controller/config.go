@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// configView is the output of config view
+type configView struct {
+	Path           string                       `json:"path"`
+	CurrentProfile string                       `json:"current_profile"`
+	ActiveProfile  string                       `json:"active_profile"`
+	Config         map[string]string            `json:"config"`
+	Profiles       map[string]map[string]string `json:"profiles"`
+}
+
+// View returns the config file with the effective configuration of the active
+// profile (flags, env and profile merged), the secrets redacted unless showSecrets
+func View(showSecrets bool) ([]byte, error) {
+	path := helpers.ConfigPath()
+	file, err := helpers.ReadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	view := configView{
+		Path:           path,
+		CurrentProfile: file.CurrentProfile,
+		ActiveProfile:  helpers.ActiveProfile,
+		Config:         helpers.ConfigValues(helpers.AppConfig, showSecrets),
+		Profiles:       map[string]map[string]string{},
+	}
+	for name, profile := range file.Profiles {
+		view.Profiles[name] = helpers.ConfigValues(profile, showSecrets)
+	}
+
+	jsonData, err := json.MarshalIndent(view, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return jsonData, nil
+}
+
+// Set sets the value of a key in the profile (the current one when empty), creating it if needed.
+// The secrets are not written in clear in the config file: they are stored with auth login.
+func Set(profile string, key string, value string) ([]byte, error) {
+	if helpers.IsSecretKey(key) {
+		if service, ok := helpers.CredentialService(key); ok {
+			return nil, helpers.ValidationError("%s is a secret and is not written to the config file, store it with: sinaloa auth login %s", key, service)
+		}
+		env, _ := helpers.ConfigEnvName(key)
+		return nil, helpers.ValidationError("%s is a secret and is not written to the config file, set the %s environment variable", key, env)
+	}
+
+	path := helpers.ConfigPath()
+	file, err := helpers.ReadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	name := helpers.SelectProfile(file, profile)
+	config := file.Profiles[name]
+	if err := helpers.SetConfigValue(&config, key, value); err != nil {
+		return nil, err
+	}
+	file.Profiles[name] = config
+
+	if err := helpers.WriteConfigFile(path, file); err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("Set %s in profile %s (%s)", key, name, path)), nil
+}
+
+// UseProfile sets the current profile of the config file
+func UseProfile(name string) ([]byte, error) {
+	path := helpers.ConfigPath()
+	file, err := helpers.ReadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := file.Profiles[name]; !ok {
+		var names []string
+		for profile := range file.Profiles {
+			names = append(names, profile)
+		}
+		sort.Strings(names)
+		return nil, helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("profile %q not found in %s (profiles: %v)", name, path, names))
+	}
+	file.CurrentProfile = name
+
+	if err := helpers.WriteConfigFile(path, file); err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("Switched to profile %s", name)), nil
+}
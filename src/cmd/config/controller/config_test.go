@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+func TestSet_RefusesSecrets(t *testing.T) {
+	// Arrange: An empty config file
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("SINALOA_CONFIG", path)
+
+	// Act: Set a plain value and two secrets
+	_, plainErr := Set("prod", "argocd_url", "argocd.example.com")
+	_, passwordErr := Set("prod", "argocd_password", "secret")
+	_, keyErr := Set("prod", "github_app_private_key", "pem")
+
+	// Assert: Only the plain value is written, the secrets point to auth login or the environment
+	assert.NoError(t, plainErr, "Plain value should be set")
+	assert.ErrorContains(t, passwordErr, "sinaloa auth login argocd", "Password should be stored with auth login")
+	assert.ErrorContains(t, keyErr, "GITHUB_APP_PRIVATE_KEY", "Secret without service should point to the environment")
+	file, err := helpers.ReadConfigFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "argocd.example.com", file.Profiles["prod"].ARGOCD_URL, "Plain value should be written")
+	assert.Empty(t, file.Profiles["prod"].ARGOCD_PASSWORD, "Secret should not be written")
+	data, _ := os.ReadFile(path)
+	assert.NotContains(t, string(data), "secret", "Secret should not be in the file")
+}
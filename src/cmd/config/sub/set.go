@@ -0,0 +1,46 @@
+package sub
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/config/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var SetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in a profile of the config file",
+	Long: `Set a value in the profile selected with --profile (the current profile by default),
+the profile is created if it does not exist. The key is the lower-case name of the
+environment variable, e.g. argocd_url for ARGOCD_URL. The secrets (passwords, tokens)
+are refused: store them with auth login.
+
+Keys: ` + strings.Join(settableKeys(), ", ") + `
+
+Example:
+  sinaloa config set --profile prod argocd_url argocd.example.com`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return helpers.FilterCompletions(settableKeys(), toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	Annotations: map[string]string{helpers.AnnotationManagesProfiles: "true"},
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.Set(helpers.ProfileFlag(cmd), args[0], args[1])
+	}),
+}
+
+// settableKeys returns the config keys that are not secrets
+func settableKeys() []string {
+	var keys []string
+	for _, key := range helpers.ConfigKeys() {
+		if !helpers.IsSecretKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
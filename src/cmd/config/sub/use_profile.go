@@ -0,0 +1,28 @@
+package sub
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/config/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var UseProfileCmd = &cobra.Command{
+	Use:   "use-profile <name>",
+	Short: "Set the current profile of the config file",
+	Long: `Set the profile used when --profile and SINALOA_PROFILE are not set.
+
+Example:
+  sinaloa config use-profile dev`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: helpers.CompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return helpers.ProfileCompletions(cmd, args, toComplete)
+	}),
+	Annotations: map[string]string{helpers.AnnotationManagesProfiles: "true"},
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.UseProfile(args[0])
+	}),
+}
@@ -0,0 +1,28 @@
+package sub
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/config/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var viewShowSecrets bool
+
+var ViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show the config file and the effective configuration",
+	Long: `Show the path of the config file, its profiles and the effective configuration of the
+active profile (--profile, SINALOA_PROFILE or the current profile) merged with the environment.
+Passwords, tokens and secrets are redacted unless --show-secrets is set.
+
+Example:
+  sinaloa config view --profile prod`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.View(viewShowSecrets)
+	}),
+}
+
+func init() {
+	ViewCmd.Flags().BoolVar(&viewShowSecrets, "show-secrets", false, "Show the secret values")
+}
@@ -0,0 +1,43 @@
+package be
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Media type of the DSSE envelopes in the cosign attestations
+const dsseMediaType = "application/vnd.dsse.envelope.v1+json"
+
+// Attestations returns the image digest of the tag and the DSSE envelopes attached
+// by cosign to it, stored in the repository under the tag sha256-<digest>.att
+func Attestations(store ManifestStore, repoPath string, tag string) (string, [][]byte, error) {
+	image, err := store.GetManifest(repoPath, tag)
+	if err != nil {
+		return "", nil, err
+	}
+
+	attTag := strings.Replace(image.Digest, ":", "-", 1) + ".att"
+	attestation, err := store.GetManifest(repoPath, attTag)
+	if err != nil {
+		return "", nil, fmt.Errorf("no attestation attached to %s:%s: %w", repoPath, tag, err)
+	}
+
+	var parsed ociManifest
+	if err := json.Unmarshal(attestation.Body, &parsed); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal attestation manifest: %w", err)
+	}
+
+	var envelopes [][]byte
+	for _, layer := range parsed.Layers {
+		if layer.MediaType != dsseMediaType {
+			continue
+		}
+		envelope, err := store.GetBlob(repoPath, layer.Digest)
+		if err != nil {
+			return "", nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return image.Digest, envelopes, nil
+}
@@ -1,50 +1,238 @@
 package be
 
 import (
+	"encoding/json"
 	"fmt"
-	"net/url"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
 )
 
-// DeleteImages deletes a list of tags from the given Docker Hub repository path
-// Returns lists of tags successfully deleted and not deleted
-func DeleteImages(token string, repoPath string, tags []docker.TagInfoInternal) (map[string]interface{}, error) {
+var (
+	dockerHubURL   = "https://hub.docker.com"
+	retryBaseDelay = time.Second // Doubled at every retry
+)
+
+// DeleteImages deletes a list of tags from the given registry repository path
+// using a pool of workers. Deletions answered with 429 or 5xx are retried,
+// every outcome is written to the journal (if configured) and the tags
+// already deleted by a previous run are skipped. When the registry can only
+// delete manifests by digest, a tag sharing its digest with one of the kept
+// tags (opts.KeepTags) is not deleted, it would remove the kept tag too.
+// Returns the tags deleted, not deleted (with the reason), skipped and kept,
+// the error is not nil when at least one tag was not deleted.
+func DeleteImages(registry Registry, repoPath string, tags []docker.TagInfoInternal, opts docker.DeleteOptions) (docker.DeleteResult, error) {
 	// Declare variables
-	client := helpers.NewApiClient("https://hub.docker.com", token, "Bearer")
-	tagsDeleted := []string{}
-	tagsNotDeleted := []string{}
+	result := docker.DeleteResult{
+		TagsDeleted:    docker.DeletedTags{TagList: []string{}},
+		TagsNotDeleted: docker.NotDeletedTags{TagList: []string{}, Failures: []docker.TagFailure{}},
+		TagsSkipped:    docker.DeletedTags{TagList: []string{}},
+		TagsKept:       docker.NotDeletedTags{TagList: []string{}, Failures: []docker.TagFailure{}},
+	}
+
+	// Open the journal and take the tags already deleted
+	journal, alreadyDeleted, err := openDeleteJournal(opts.JournalPath, repoPath)
+	if err != nil {
+		return result, err
+	}
+	defer journal.close()
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	guard := &digestGuard{keep: opts.KeepTags}
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Start workers
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tag := range jobs {
+				failure, kept := deleteTag(registry, repoPath, tag, opts.MaxRetries, guard)
+
+				mu.Lock()
+				switch {
+				case kept != nil:
+					result.TagsKept.TagList = append(result.TagsKept.TagList, tag)
+					result.TagsKept.Failures = append(result.TagsKept.Failures, *kept)
+				case failure == nil:
+					result.TagsDeleted.TagList = append(result.TagsDeleted.TagList, tag)
+				default:
+					result.TagsNotDeleted.TagList = append(result.TagsNotDeleted.TagList, tag)
+					result.TagsNotDeleted.Failures = append(result.TagsNotDeleted.Failures, *failure)
+				}
+				mu.Unlock()
+
+				// Write the outcome on the journal
+				status, reason := "deleted", ""
+				switch {
+				case kept != nil:
+					status, reason = "kept", kept.Reason
+				case failure != nil:
+					status, reason = "failed", failure.Reason
+				}
+				if err := journal.record(tag, status, reason); err != nil {
+					slog.Error("Failed to write the delete journal", "tag", tag, "error", err)
+				}
+			}
+		}()
+	}
 
+	// Send jobs
 	for _, tag := range tags {
-		// URL-encode tag to handle special characters safely
-		encodedTag := url.PathEscape(tag.Name)
+		if alreadyDeleted[tag.Name] {
+			result.TagsSkipped.TagList = append(result.TagsSkipped.TagList, tag.Name)
+			continue
+		}
+		jobs <- tag.Name
+	}
+	close(jobs)
+	wg.Wait()
 
-		// DELETE endpoint for tag: /v2/repositories/{repoPath}/tags/{tag}/
-		endpoint := fmt.Sprintf("/v2/repositories/%s/tags/%s/", repoPath, encodedTag)
-		resp := client.Request("DELETE", endpoint, nil)
+	// Sort the lists, the workers complete in random order
+	sort.Strings(result.TagsDeleted.TagList)
+	sort.Strings(result.TagsNotDeleted.TagList)
+	sort.Slice(result.TagsNotDeleted.Failures, func(i, j int) bool {
+		return result.TagsNotDeleted.Failures[i].Name < result.TagsNotDeleted.Failures[j].Name
+	})
+	sort.Strings(result.TagsKept.TagList)
+	sort.Slice(result.TagsKept.Failures, func(i, j int) bool {
+		return result.TagsKept.Failures[i].Name < result.TagsKept.Failures[j].Name
+	})
+	result.TagsDeleted.Count = len(result.TagsDeleted.TagList)
+	result.TagsNotDeleted.Count = len(result.TagsNotDeleted.TagList)
+	result.TagsSkipped.Count = len(result.TagsSkipped.TagList)
+	result.TagsKept.Count = len(result.TagsKept.TagList)
 
-		// Check if the response is successful
-		// If the response is successful, append the tag to tagsDeleted
-		// If the response is not successful, append the tag to tagsNotDeleted
-		if resp.Response {
-			tagsDeleted = append(tagsDeleted, tag.Name)
-		} else {
-			tagsNotDeleted = append(tagsNotDeleted, tag.Name)
+	if result.TagsNotDeleted.Count > 0 {
+		return result, fmt.Errorf("%d of %d tags not deleted", result.TagsNotDeleted.Count, len(tags))
+	}
+	return result, nil
+}
+
+// deleteTag deletes a tag, retrying on 429, 5xx and network errors.
+// A 404 means the tag is already gone and it is considered deleted.
+// When the registry does not support deleting a tag, the manifest is
+// deleted by digest unless a kept tag points to it: the tag is then
+// returned as kept, with the reason.
+func deleteTag(registry Registry, repoPath string, tag string, maxRetries int, guard *digestGuard) (failure *docker.TagFailure, kept *docker.TagFailure) {
+	resp := withRetry(maxRetries, func() models.ApiResponse {
+		return registry.DeleteTag(repoPath, tag)
+	})
+	if deleter, ok := registry.(ManifestDeleter); ok && isTagDeleteUnsupported(resp) {
+		digest, err := registry.ResolveDigest(repoPath, tag)
+		if err != nil {
+			return &docker.TagFailure{Name: tag, Reason: err.Error()}, nil
+		}
+		keptTags, err := guard.keptTags(registry, repoPath, digest)
+		if err != nil {
+			return &docker.TagFailure{Name: tag, Reason: err.Error()}, nil
+		}
+		if len(keptTags) > 0 {
+			return nil, &docker.TagFailure{
+				Name:   tag,
+				Reason: fmt.Sprintf("manifest %s is shared with the kept tags %s", digest, strings.Join(keptTags, ", ")),
+			}
 		}
+		resp = withRetry(maxRetries, func() models.ApiResponse {
+			return deleter.DeleteManifest(repoPath, digest)
+		})
+	}
+	if resp.Response || resp.StatusCode == 404 {
+		return nil, nil
 	}
 
-	// Prepare the result map with lists of deleted and not deleted tags
-	result := map[string]interface{}{
-		"tags_deleted": map[string]interface{}{
-			"tags_list": tagsDeleted,
-			"count":     len(tagsDeleted),
-		},
-		"tags_not_deleted": map[string]interface{}{
-			"tags_list": tagsNotDeleted,
-			"count":     len(tagsNotDeleted),
-		},
+	return &docker.TagFailure{
+		Name:       tag,
+		StatusCode: resp.StatusCode,
+		Reason:     responseError(resp),
+	}, nil
+}
+
+// withRetry sends the request until it succeeds, retrying 429, 5xx and network errors
+func withRetry(maxRetries int, send func() models.ApiResponse) models.ApiResponse {
+	var resp models.ApiResponse
+	for attempt := 0; ; attempt++ {
+		resp = send()
+		if resp.Response || !isRetryable(resp.StatusCode) || attempt >= maxRetries {
+			return resp
+		}
+		time.Sleep(retryDelay(resp, attempt))
 	}
+}
 
-	return result, nil
+// isTagDeleteUnsupported returns true when the registry refused to delete a
+// tag because it only deletes manifests by digest: 405, or 400 with the error
+// code UNSUPPORTED (any other 400 is a real failure, not a reason to delete the digest)
+func isTagDeleteUnsupported(resp models.ApiResponse) bool {
+	if resp.StatusCode == 405 {
+		return true
+	}
+	if resp.StatusCode != 400 {
+		return false
+	}
+	var body struct {
+		Errors []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		return false
+	}
+	for _, e := range body.Errors {
+		if e.Code == "UNSUPPORTED" {
+			return true
+		}
+	}
+	return false
+}
+
+// digestGuard resolves, once, the digests of the kept tags
+type digestGuard struct {
+	keep    []string
+	once    sync.Once
+	digests map[string][]string // digest -> kept tags
+	err     error
+}
+
+// keptTags returns the kept tags pointing to the digest
+func (g *digestGuard) keptTags(registry Registry, repoPath string, digest string) ([]string, error) {
+	g.once.Do(func() {
+		g.digests = make(map[string][]string, len(g.keep))
+		for _, tag := range g.keep {
+			keptDigest, err := registry.ResolveDigest(repoPath, tag)
+			if err != nil {
+				g.err = fmt.Errorf("failed to resolve the kept tag %s: %w", tag, err)
+				return
+			}
+			g.digests[keptDigest] = append(g.digests[keptDigest], tag)
+		}
+	})
+	return g.digests[digest], g.err
+}
+
+// isRetryable returns true for rate limits, server errors and network errors (status 0)
+func isRetryable(statusCode int) bool {
+	return statusCode == 0 || statusCode == 429 || statusCode >= 500
+}
+
+// retryDelay honors the Retry-After header, otherwise it uses an exponential backoff
+func retryDelay(resp models.ApiResponse, attempt int) time.Duration {
+	if resp.Headers != nil {
+		if seconds, err := strconv.Atoi(resp.Headers.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return retryBaseDelay << attempt
 }
@@ -0,0 +1,137 @@
+package be
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// newDeleteServer starts a fake Docker Hub that answers the tag deletions
+// with the status returned by statusFor, counting the calls per tag
+func newDeleteServer(t *testing.T, statusFor func(tag string, call int) int) (*httptest.Server, map[string]int) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method, "Expected DELETE method")
+		tag := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/repositories/org/repo/tags/"), "/")
+
+		mu.Lock()
+		calls[tag]++
+		call := calls[tag]
+		mu.Unlock()
+
+		w.WriteHeader(statusFor(tag, call))
+	}))
+
+	oldURL, oldDelay := dockerHubURL, retryBaseDelay
+	dockerHubURL, retryBaseDelay = server.URL, time.Millisecond
+	t.Cleanup(func() {
+		server.Close()
+		dockerHubURL, retryBaseDelay = oldURL, oldDelay
+	})
+
+	return server, calls
+}
+
+// staticToken is a token source that never expires
+type staticToken string
+
+func (t staticToken) Token() (string, error)   { return string(t), nil }
+func (t staticToken) Refresh() (string, error) { return string(t), nil }
+
+func tagList(names ...string) []docker.TagInfoInternal {
+	var tags []docker.TagInfoInternal
+	for _, name := range names {
+		tags = append(tags, docker.TagInfoInternal{Name: name})
+	}
+	return tags
+}
+
+func TestDeleteImages_RetryAndFailure(t *testing.T) {
+	// Arrange: "1.0.1" is rate limited once, "1.0.2" always fails
+	_, calls := newDeleteServer(t, func(tag string, call int) int {
+		switch {
+		case tag == "1.0.1" && call == 1:
+			return http.StatusTooManyRequests
+		case tag == "1.0.2":
+			return http.StatusForbidden
+		}
+		return http.StatusNoContent
+	})
+
+	// Act: Delete the tags with 2 workers
+	result, err := DeleteImages(NewDockerHubRegistry(staticToken("token")), "org/repo", tagList("1.0.0", "1.0.1", "1.0.2"), docker.DeleteOptions{Concurrency: 2, MaxRetries: 2})
+
+	// Assert: The rate limited tag is retried, the forbidden one is reported
+	assert.Error(t, err, "An error is expected when a tag is not deleted")
+	assert.Equal(t, []string{"1.0.0", "1.0.1"}, result.TagsDeleted.TagList, "Deleted tags should match")
+	assert.Equal(t, []string{"1.0.2"}, result.TagsNotDeleted.TagList, "Not deleted tags should match")
+	assert.Equal(t, 403, result.TagsNotDeleted.Failures[0].StatusCode, "Failure status should be reported")
+	assert.Equal(t, 2, calls["1.0.1"], "Rate limited tag should be retried once")
+	assert.Equal(t, 1, calls["1.0.2"], "Forbidden tag should not be retried")
+}
+
+func TestDeleteImages_ResumeFromJournal(t *testing.T) {
+	// Arrange: A journal written by an interrupted run
+	_, calls := newDeleteServer(t, func(tag string, call int) int { return http.StatusNoContent })
+	journalPath := filepath.Join(t.TempDir(), "journal.jsonl")
+	previous := `{"repo":"org/repo","tag":"1.0.0","status":"deleted"}` + "\n" +
+		`{"repo":"org/repo","tag":"1.0.1","status":"failed"}` + "\n" +
+		`{"repo":"org/repo","tag":"1.0.2","sta`
+	assert.NoError(t, os.WriteFile(journalPath, []byte(previous), 0644), "Writing the journal should not fail")
+
+	// Act: Resume the cleanup
+	result, err := DeleteImages(NewDockerHubRegistry(staticToken("token")), "org/repo", tagList("1.0.0", "1.0.1"), docker.DeleteOptions{Concurrency: 1, JournalPath: journalPath})
+
+	// Assert: Only the tag not yet deleted is sent again
+	assert.NoError(t, err, "No error is expected")
+	assert.Equal(t, []string{"1.0.0"}, result.TagsSkipped.TagList, "Tag deleted before should be skipped")
+	assert.Equal(t, []string{"1.0.1"}, result.TagsDeleted.TagList, "Failed tag should be deleted now")
+	assert.Equal(t, 0, calls["1.0.0"], "Skipped tag should not be requested")
+
+	content, _ := os.ReadFile(journalPath)
+	assert.Contains(t, string(content), "\n{\"repo\":\"org/repo\",\"tag\":\"1.0.1\",\"status\":\"deleted\"", "Journal should record the new deletion on a new line")
+}
+
+func TestIsTagDeleteUnsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     models.ApiResponse
+		expected bool
+	}{
+		{"405", models.ApiResponse{StatusCode: 405}, true},
+		{"400 UNSUPPORTED", models.ApiResponse{StatusCode: 400, Body: []byte(`{"errors":[{"code":"UNSUPPORTED"}]}`)}, true},
+		{"400 other code", models.ApiResponse{StatusCode: 400, Body: []byte(`{"errors":[{"code":"TAG_INVALID"}]}`)}, false},
+		{"400 without body", models.ApiResponse{StatusCode: 400}, false},
+		{"403", models.ApiResponse{StatusCode: 403, Body: []byte(`{"errors":[{"code":"UNSUPPORTED"}]}`)}, false},
+	}
+	for _, tt := range tests {
+		// Act & Assert: Only a refused tag deletion falls back to the digest
+		assert.Equal(t, tt.expected, isTagDeleteUnsupported(tt.resp), "Fallback for %s should match", tt.name)
+	}
+}
+
+func TestDeleteImages_JournalPermissions(t *testing.T) {
+	// Arrange: A new journal
+	newDeleteServer(t, func(tag string, call int) int { return http.StatusNoContent })
+	journalPath := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	// Act: Delete a tag
+	_, err := DeleteImages(NewDockerHubRegistry(staticToken("token")), "org/repo", tagList("1.0.0"), docker.DeleteOptions{Concurrency: 1, JournalPath: journalPath})
+
+	// Assert: The journal is readable only by the user
+	assert.NoError(t, err, "No error is expected")
+	info, statErr := os.Stat(journalPath)
+	assert.NoError(t, statErr, "Journal should be created")
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "Journal should be created with mode 0600")
+}
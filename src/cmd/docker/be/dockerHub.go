@@ -0,0 +1,116 @@
+package be
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// dockerHubRegistry implements Registry with the Docker Hub api
+type dockerHubRegistry struct {
+	tokens TokenSource
+}
+
+// NewDockerHubRegistry returns the Docker Hub registry authenticated with
+// the tokens of the session (see shared.NewDockerHubSession)
+func NewDockerHubRegistry(tokens TokenSource) Registry {
+	return &dockerHubRegistry{tokens: tokens}
+}
+
+// ListRepositories returns all the repositories of the namespace
+func (r *dockerHubRegistry) ListRepositories(namespace string) ([]string, error) {
+	endpoint := fmt.Sprintf("/v2/repositories/%s/?page_size=100", url.PathEscape(namespace))
+	var repositories []string
+
+	for endpoint != "" {
+		resp := r.request("GET", endpoint)
+		if !resp.Response {
+			return nil, fmt.Errorf("failed to get repositories from dockerhub: %s", responseError(resp))
+		}
+
+		var reposResp docker.HubRepositoriesResponse
+		if err := json.Unmarshal(resp.Body, &reposResp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal repositories response: %w", err)
+		}
+		for _, repo := range reposResp.Results {
+			repositories = append(repositories, repo.Namespace+"/"+repo.Name)
+		}
+
+		endpoint = ""
+		if reposResp.Next != nil {
+			// strip full URL to relative path
+			endpoint = strings.TrimPrefix(*reposResp.Next, dockerHubURL)
+		}
+	}
+
+	return repositories, nil
+}
+
+// ListTags returns all the tags of the repository
+func (r *dockerHubRegistry) ListTags(repoPath string, imagesForPage string) (docker.TagResponseInternal, int, error) {
+	return GetImages(r.tokens, repoPath, imagesForPage)
+}
+
+// ResolveDigest returns the digest of the tag
+func (r *dockerHubRegistry) ResolveDigest(repoPath string, tag string) (string, error) {
+	endpoint := fmt.Sprintf("/v2/repositories/%s/tags/%s", repoPath, url.PathEscape(tag))
+	resp := r.request("GET", endpoint)
+	if !resp.Response {
+		return "", fmt.Errorf("failed to get tag %s from dockerhub (status %d)", tag, resp.StatusCode)
+	}
+
+	var tagResult docker.TagResult
+	if err := json.Unmarshal(resp.Body, &tagResult); err != nil {
+		return "", fmt.Errorf("failed to unmarshal tag response: %w", err)
+	}
+
+	return tagResult.Digest, nil
+}
+
+// Platforms returns the platform images of the tag
+func (r *dockerHubRegistry) Platforms(repoPath string, tag string) ([]docker.PlatformInfo, error) {
+	endpoint := fmt.Sprintf("/v2/repositories/%s/tags/%s", repoPath, url.PathEscape(tag))
+	resp := r.request("GET", endpoint)
+	if !resp.Response {
+		return nil, fmt.Errorf("failed to get tag %s from dockerhub (status %d)", tag, resp.StatusCode)
+	}
+
+	var tagResult docker.TagResult
+	if err := json.Unmarshal(resp.Body, &tagResult); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tag response: %w", err)
+	}
+
+	return imagePlatforms(tagResult.Images), nil
+}
+
+// DeleteTag deletes the tag with the endpoint /v2/repositories/{repoPath}/tags/{tag}/
+func (r *dockerHubRegistry) DeleteTag(repoPath string, tag string) models.ApiResponse {
+	// URL-encode tag to handle special characters safely
+	endpoint := fmt.Sprintf("/v2/repositories/%s/tags/%s/", repoPath, url.PathEscape(tag))
+	return r.request("DELETE", endpoint)
+}
+
+// request sends the request with the current token, on a 401 (token
+// expired or revoked) it refreshes the token and retries once
+func (r *dockerHubRegistry) request(method string, endpoint string) models.ApiResponse {
+	token, err := r.tokens.Token()
+	if err != nil {
+		return models.NewApiResponse(false, 401, nil, err.Error(), nil)
+	}
+
+	resp := helpers.NewApiClient(dockerHubURL, token, "Bearer").Request(method, endpoint, nil)
+	if resp.StatusCode != 401 {
+		return resp
+	}
+
+	token, err = r.tokens.Refresh()
+	if err != nil {
+		return models.NewApiResponse(false, 401, resp.Headers, err.Error(), resp.Body)
+	}
+	return helpers.NewApiClient(dockerHubURL, token, "Bearer").Request(method, endpoint, nil)
+}
@@ -0,0 +1,53 @@
+package be
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rotatingToken returns "old" until it is refreshed
+type rotatingToken struct {
+	token     string
+	refreshes int
+}
+
+func (t *rotatingToken) Token() (string, error) { return t.token, nil }
+func (t *rotatingToken) Refresh() (string, error) {
+	t.refreshes++
+	t.token = "new"
+	return t.token, nil
+}
+
+func TestDockerHubRegistry_RefreshOn401(t *testing.T) {
+	// Arrange: Docker Hub accepts only the new token, repositories on 2 pages
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"results":[{"name":"web","namespace":"org"}]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"next":"%s/v2/repositories/org/?page=2","results":[{"name":"api","namespace":"org"}]}`, dockerHubURL)
+	}))
+	oldURL := dockerHubURL
+	dockerHubURL = server.URL
+	t.Cleanup(func() {
+		server.Close()
+		dockerHubURL = oldURL
+	})
+	tokens := &rotatingToken{token: "old"}
+
+	// Act: List the repositories with the expired token
+	repositories, err := NewDockerHubRegistry(tokens).ListRepositories("org")
+
+	// Assert: The token is refreshed once and every page is read
+	assert.NoError(t, err, "ListRepositories should not return an error")
+	assert.Equal(t, []string{"org/api", "org/web"}, repositories, "Repositories should match")
+	assert.Equal(t, 1, tokens.refreshes, "Token should be refreshed once")
+}
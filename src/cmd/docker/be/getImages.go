@@ -4,20 +4,21 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
 )
 
-func GetImages(token string, refreshToken string, repoPath string, imagesForPage string) (docker.TagResponseInternal, int, error) {
-	baseURL := "https://hub.docker.com"
+// GetImages returns all the tags of the Docker Hub repository,
+// the token is refreshed if it expires while reading the pages
+func GetImages(tokens TokenSource, repoPath string, imagesForPage string) (docker.TagResponseInternal, int, error) {
+	baseURL := dockerHubURL
 	url := fmt.Sprintf("/v2/repositories/%s/tags?page_size=%s", repoPath, imagesForPage)
-	client := helpers.NewApiClient(baseURL, token, "Bearer")
+	registry := &dockerHubRegistry{tokens: tokens}
 
 	var allResults []docker.TagResult
 	var statusCode int
 
 	for url != "" {
-		response := client.Request("GET", url, nil)
+		response := registry.request("GET", url)
 		statusCode = response.StatusCode
 
 		if !response.Response {
@@ -67,6 +68,8 @@ func convertTagsResponse(external docker.TagsResponse) docker.TagResponseInterna
 			TagLastPulled:       tagResult.TagLastPulled,
 			TagLastPushed:       tagResult.TagLastPushed,
 			Digest:              tagResult.Digest,
+			FullSize:            tagSize(tagResult),
+			Platforms:           imagePlatforms(tagResult.Images),
 		}
 		internalTags = append(internalTags, internalTag)
 	}
@@ -76,3 +79,38 @@ func convertTagsResponse(external docker.TagsResponse) docker.TagResponseInterna
 		Count:   len(internalTags),
 	}
 }
+
+// tagSize returns the size of the tag, summing the platform images
+// when Docker Hub does not return the full size
+func tagSize(tagResult docker.TagResult) int64 {
+	if tagResult.FullSize > 0 {
+		return tagResult.FullSize
+	}
+	var size int64
+	for _, image := range tagResult.Images {
+		size += image.Size
+	}
+	return size
+}
+
+// imagePlatforms converts the platform images of a tag, the attestation
+// manifests (unknown/unknown) are not platforms and are skipped
+func imagePlatforms(images []docker.Image) []docker.PlatformInfo {
+	var platforms []docker.PlatformInfo
+	for _, image := range images {
+		if image.OS == "unknown" || image.Architecture == "unknown" {
+			continue
+		}
+		platform := docker.PlatformInfo{
+			OS:           image.OS,
+			Architecture: image.Architecture,
+			Digest:       image.Digest,
+			Size:         image.Size,
+		}
+		if image.Variant != nil {
+			platform.Variant = *image.Variant
+		}
+		platforms = append(platforms, platform)
+	}
+	return platforms
+}
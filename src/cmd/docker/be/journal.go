@@ -0,0 +1,100 @@
+package be
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// deleteJournal records the outcome of every deletion in a JSON lines file,
+// so an interrupted cleanup can be resumed skipping the tags already deleted
+type deleteJournal struct {
+	mu   sync.Mutex
+	file *os.File
+	repo string
+}
+
+// openDeleteJournal opens (or creates) the journal and returns the tags of
+// repoPath already deleted by a previous run. An empty path disables it.
+func openDeleteJournal(journalPath string, repoPath string) (*deleteJournal, map[string]bool, error) {
+	deleted := make(map[string]bool)
+	if journalPath == "" {
+		return &deleteJournal{repo: repoPath}, deleted, nil
+	}
+
+	// Read the previous entries (if any)
+	existing, err := os.Open(journalPath)
+	if err == nil {
+		scanner := bufio.NewScanner(existing)
+		for scanner.Scan() {
+			var entry docker.JournalEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue // Skip truncated lines of an interrupted run
+			}
+			if entry.Repo == repoPath && entry.Status == "deleted" {
+				deleted[entry.Tag] = true
+			}
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, nil, fmt.Errorf("failed to read delete journal: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to open delete journal: %w", err)
+	}
+
+	file, err := os.OpenFile(journalPath, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open delete journal: %w", err)
+	}
+
+	// Terminate the last line if the previous run was killed while writing it
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			if _, err := file.Write([]byte("\n")); err != nil {
+				file.Close()
+				return nil, nil, fmt.Errorf("failed to write delete journal: %w", err)
+			}
+		}
+	}
+
+	return &deleteJournal{file: file, repo: repoPath}, deleted, nil
+}
+
+// record appends the outcome of a deletion to the journal
+func (j *deleteJournal) record(tag string, status string, reason string) error {
+	if j.file == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(docker.JournalEntry{
+		Repo:   j.repo,
+		Tag:    tag,
+		Status: status,
+		Reason: reason,
+		Time:   time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err = j.file.Write(append(line, '\n'))
+	return err
+}
+
+// close closes the journal file
+func (j *deleteJournal) close() error {
+	if j.file == nil {
+		return nil
+	}
+	return j.file.Close()
+}
@@ -0,0 +1,110 @@
+package be
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// Manifest is a raw image manifest (or index). The body is kept as it is
+// so that the digest does not change when it is pushed somewhere else.
+type Manifest struct {
+	MediaType string
+	Digest    string
+	Body      []byte
+}
+
+// ManifestStore reads and writes manifests and blobs with the OCI Distribution
+// api (Docker Hub included, through registry-1.docker.io)
+type ManifestStore interface {
+	// GetManifest returns the manifest of the reference (tag or digest)
+	GetManifest(repoPath string, reference string) (Manifest, error)
+	// PutManifest pushes the manifest under the reference (tag or digest)
+	PutManifest(repoPath string, reference string, manifest Manifest) error
+	// GetBlob returns the content of the blob
+	GetBlob(repoPath string, digest string) ([]byte, error)
+	// CopyBlob makes the blob of fromRepo available in toRepo, mounting it when possible
+	CopyBlob(fromRepo string, toRepo string, digest string) error
+}
+
+// NewManifestStore returns the manifest store of the OCI registry at baseURL
+func NewManifestStore(baseURL string, username string, password string) ManifestStore {
+	return NewOCIRegistry(baseURL, username, password).(*ociRegistry)
+}
+
+// CopyImage copies the image fromRepo:fromRef to toRepo:toTag on the registry side,
+// without pulling the layers locally: blobs are mounted (or streamed) to the target
+// repository, then the manifests are pushed with the same digests. For multi-arch
+// indexes every platform manifest is copied before the index.
+func CopyImage(store ManifestStore, fromRepo string, fromRef string, toRepo string, toTag string) (docker.PromoteResult, error) {
+	result := docker.PromoteResult{
+		From: fromRepo + ":" + fromRef,
+		To:   toRepo + ":" + toTag,
+	}
+	if strings.HasPrefix(fromRef, "sha256:") {
+		result.From = fromRepo + "@" + fromRef
+	}
+
+	manifest, err := store.GetManifest(fromRepo, fromRef)
+	if err != nil {
+		return result, err
+	}
+	result.Digest = manifest.Digest
+	result.MediaType = manifest.MediaType
+
+	if err := copyManifestContent(store, fromRepo, toRepo, manifest, &result); err != nil {
+		return result, err
+	}
+	if err := store.PutManifest(toRepo, toTag, manifest); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// copyManifestContent copies what the manifest references: the platform manifests
+// of an index or the config and the layers of an image
+func copyManifestContent(store ManifestStore, fromRepo string, toRepo string, manifest Manifest, result *docker.PromoteResult) error {
+	var parsed ociManifest
+	if err := json.Unmarshal(manifest.Body, &parsed); err != nil {
+		return fmt.Errorf("failed to unmarshal manifest %s: %w", manifest.Digest, err)
+	}
+
+	// Multi-arch index, copy every platform manifest by digest
+	if len(parsed.Manifests) > 0 {
+		for _, entry := range parsed.Manifests {
+			child, err := store.GetManifest(fromRepo, entry.Digest)
+			if err != nil {
+				return err
+			}
+			if err := copyManifestContent(store, fromRepo, toRepo, child, result); err != nil {
+				return err
+			}
+			if fromRepo != toRepo {
+				if err := store.PutManifest(toRepo, entry.Digest, child); err != nil {
+					return err
+				}
+			}
+			result.Manifests++
+		}
+		return nil
+	}
+
+	// Retag in the same repository, the blobs are already there
+	if fromRepo == toRepo {
+		return nil
+	}
+
+	blobs := append([]ociDescriptor{parsed.Config}, parsed.Layers...)
+	for _, blob := range blobs {
+		if blob.Digest == "" {
+			continue
+		}
+		if err := store.CopyBlob(fromRepo, toRepo, blob.Digest); err != nil {
+			return err
+		}
+		result.Blobs++
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package be
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// storedManifest is a manifest of the fake registry
+type storedManifest struct {
+	mediaType string
+	body      string
+}
+
+// fakeManifestRegistry is an anonymous registry:2-style server with manifests and blobs
+type fakeManifestRegistry struct {
+	mu        sync.Mutex
+	manifests map[string]storedManifest // repo/ref -> manifest
+	blobs     map[string]bool           // repo/digest
+	mounts    int
+	uploads   int
+}
+
+func newFakeManifestRegistry(t *testing.T, mountable bool) (*fakeManifestRegistry, *httptest.Server) {
+	fake := &fakeManifestRegistry{manifests: map[string]storedManifest{}, blobs: map[string]bool{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/v2/")
+		switch {
+		case strings.Contains(path, "/manifests/"):
+			repo, ref, _ := strings.Cut(path, "/manifests/")
+			if r.Method == "PUT" {
+				body, _ := io.ReadAll(r.Body)
+				fake.manifests[repo+"/"+ref] = storedManifest{mediaType: r.Header.Get("Content-Type"), body: string(body)}
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			manifest, ok := fake.manifests[repo+"/"+ref]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", manifest.mediaType)
+			w.Header().Set("Docker-Content-Digest", "sha256:"+ref)
+			fmt.Fprint(w, manifest.body)
+		case strings.HasSuffix(path, "/blobs/uploads/") && r.Method == "POST":
+			repo := strings.TrimSuffix(path, "/blobs/uploads/")
+			digest, from := r.URL.Query().Get("mount"), r.URL.Query().Get("from")
+			if mountable && fake.blobs[from+"/"+digest] {
+				fake.blobs[repo+"/"+digest] = true
+				fake.mounts++
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			w.Header().Set("Location", "/v2/"+repo+"/blobs/uploads/session?state=1")
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasSuffix(path, "/blobs/uploads/session") && r.Method == "PUT":
+			repo := strings.TrimSuffix(path, "/blobs/uploads/session")
+			assert.Equal(t, "1", r.URL.Query().Get("state"), "Upload state should be kept")
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "blob", string(body), "Blob should be streamed as it is")
+			assert.Equal(t, int64(4), r.ContentLength, "Blob size should be sent")
+			fake.blobs[repo+"/"+r.URL.Query().Get("digest")] = true
+			fake.uploads++
+			w.WriteHeader(http.StatusCreated)
+		case strings.Contains(path, "/blobs/"):
+			repo, digest, _ := strings.Cut(path, "/blobs/")
+			if !fake.blobs[repo+"/"+digest] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, "blob")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+// seedIndex stores a multi-arch index with 2 platform images in repo
+func (f *fakeManifestRegistry) seedIndex(repo string, tag string) {
+	f.manifests[repo+"/"+tag] = storedManifest{
+		mediaType: "application/vnd.oci.image.index.v1+json",
+		body:      `{"manifests":[{"digest":"sha256:amd","platform":{"os":"linux","architecture":"amd64"}},{"digest":"sha256:arm","platform":{"os":"linux","architecture":"arm64"}}]}`,
+	}
+	for _, arch := range []string{"amd", "arm"} {
+		f.manifests[repo+"/sha256:"+arch] = storedManifest{
+			mediaType: "application/vnd.oci.image.manifest.v1+json",
+			body:      fmt.Sprintf(`{"config":{"digest":"sha256:cfg-%s"},"layers":[{"digest":"sha256:base"},{"digest":"sha256:app-%s"}]}`, arch, arch),
+		}
+		f.blobs[repo+"/sha256:cfg-"+arch] = true
+		f.blobs[repo+"/sha256:app-"+arch] = true
+	}
+	f.blobs[repo+"/sha256:base"] = true
+}
+
+func TestCopyImage_IndexWithMount(t *testing.T) {
+	// Arrange: A multi-arch index in the staging repository
+	fake, server := newFakeManifestRegistry(t, true)
+	fake.seedIndex("org/app-staging", "1.2.0-rc1")
+	store := NewManifestStore(server.URL, "", "")
+
+	// Act: Promote it to the prod repository
+	result, err := CopyImage(store, "org/app-staging", "1.2.0-rc1", "org/app", "1.2.0")
+
+	// Assert: Index, platform manifests and blobs are in the prod repository
+	assert.NoError(t, err, "CopyImage should not return an error")
+	assert.Equal(t, 2, result.Manifests, "Both platform manifests should be copied")
+	assert.Equal(t, 6, result.Blobs, "Config and layers of both platforms should be copied")
+	assert.Equal(t, 5, fake.mounts, "The shared base layer should be mounted once")
+	assert.Equal(t, 0, fake.uploads, "No blob should be uploaded")
+	assert.Equal(t, fake.manifests["org/app-staging/1.2.0-rc1"], fake.manifests["org/app/1.2.0"], "Index should be copied as it is")
+	assert.Contains(t, fake.manifests, "org/app/sha256:arm", "Platform manifest should be pushed by digest")
+}
+
+func TestCopyImage_UploadWhenMountRefused(t *testing.T) {
+	// Arrange: A registry refusing the cross repository mounts
+	fake, server := newFakeManifestRegistry(t, false)
+	fake.seedIndex("org/app-staging", "rc")
+	store := NewManifestStore(server.URL, "", "")
+
+	// Act: Promote the image
+	_, err := CopyImage(store, "org/app-staging", "rc", "org/app", "1.0.0")
+
+	// Assert: The blobs are streamed through the upload session
+	assert.NoError(t, err, "CopyImage should not return an error")
+	assert.Equal(t, 5, fake.uploads, "Every distinct blob should be uploaded")
+	assert.True(t, fake.blobs["org/app/sha256:base"], "Blob should be in the target repository")
+}
@@ -0,0 +1,531 @@
+package be
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+var (
+	challengeParamRe = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	linkNextRe       = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+// ociAuth is the authorization obtained for a scope
+type ociAuth struct {
+	authType string // "Bearer" or "Basic"
+	token    string
+}
+
+// ociRegistry implements Registry with the OCI Distribution v2 api.
+// The token auth flow is handled transparently: on a 401 the
+// WWW-Authenticate challenge is solved and the request retried.
+type ociRegistry struct {
+	client   *helpers.ApiClient
+	username string
+	password string
+
+	mu    sync.Mutex
+	auths map[string]ociAuth // Cached authorization per repository and action
+}
+
+// ociTagList is the response of /v2/<name>/tags/list
+type ociTagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// ociDescriptor is a content descriptor of a manifest or of an index
+type ociDescriptor struct {
+	MediaType string       `json:"mediaType"`
+	Digest    string       `json:"digest"`
+	Size      int64        `json:"size"`
+	Platform  *ociPlatform `json:"platform,omitempty"`
+}
+
+// ociPlatform is the platform of an index entry or of an image config
+type ociPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// ociManifest is an image manifest or an index (manifest list),
+// only one between Layers and Manifests is set
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// imageSize returns the size of the image (config and layers)
+func (m ociManifest) imageSize() int64 {
+	size := m.Config.Size
+	for _, layer := range m.Layers {
+		size += layer.Size
+	}
+	return size
+}
+
+// ociTokenResponse is the response of the token endpoint
+type ociTokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// NewOCIRegistry returns a registry talking the OCI Distribution v2 api.
+// username and password can be empty for anonymous access.
+func NewOCIRegistry(baseURL string, username string, password string) Registry {
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "https://" + baseURL
+	}
+	return &ociRegistry{
+		client:   helpers.NewApiClient(strings.TrimSuffix(baseURL, "/"), "", "None"),
+		username: username,
+		password: password,
+		auths:    make(map[string]ociAuth),
+	}
+}
+
+// ociCatalog is the response of /v2/_catalog
+type ociCatalog struct {
+	Repositories []string `json:"repositories"`
+}
+
+// ListRepositories returns the repositories of the catalog under the namespace
+func (r *ociRegistry) ListRepositories(namespace string) ([]string, error) {
+	endpoint := "/v2/_catalog?n=100"
+	prefix := strings.TrimSuffix(namespace, "/") + "/"
+	var repositories []string
+
+	for endpoint != "" {
+		resp := r.request("GET", endpoint, nil, nil, "catalog", "*")
+		if !resp.Response {
+			return nil, fmt.Errorf("failed to get the registry catalog: %s", responseError(resp))
+		}
+
+		var catalog ociCatalog
+		if err := json.Unmarshal(resp.Body, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catalog response: %w", err)
+		}
+		for _, repo := range catalog.Repositories {
+			if namespace == "" || strings.HasPrefix(repo, prefix) {
+				repositories = append(repositories, repo)
+			}
+		}
+
+		endpoint = r.nextPage(resp)
+	}
+
+	return repositories, nil
+}
+
+// ListTags returns all the tags of the repository following the Link pagination
+func (r *ociRegistry) ListTags(repoPath string, imagesForPage string) (docker.TagResponseInternal, int, error) {
+	endpoint := fmt.Sprintf("/v2/%s/tags/list?n=%s", repoPath, imagesForPage)
+	internalTags := []docker.TagInfoInternal{}
+	var statusCode int
+
+	for endpoint != "" {
+		resp := r.request("GET", endpoint, nil, nil, repoPath, "pull")
+		statusCode = resp.StatusCode
+		if !resp.Response {
+			return docker.TagResponseInternal{}, statusCode, fmt.Errorf("failed to get tags from registry: %s", responseError(resp))
+		}
+
+		var tagList ociTagList
+		if err := json.Unmarshal(resp.Body, &tagList); err != nil {
+			return docker.TagResponseInternal{}, statusCode, fmt.Errorf("failed to unmarshal tags response: %w", err)
+		}
+		for _, tag := range tagList.Tags {
+			internalTags = append(internalTags, docker.TagInfoInternal{Name: tag})
+		}
+
+		endpoint = r.nextPage(resp)
+	}
+
+	return docker.TagResponseInternal{
+		TagList: internalTags,
+		Count:   len(internalTags),
+	}, statusCode, nil
+}
+
+// ResolveDigest returns the digest of the manifest (or index) the tag points to
+func (r *ociRegistry) ResolveDigest(repoPath string, tag string) (string, error) {
+	endpoint := fmt.Sprintf("/v2/%s/manifests/%s", repoPath, url.PathEscape(tag))
+	headers := map[string]string{"Accept": manifestAcceptTypes}
+
+	resp := r.request("HEAD", endpoint, nil, headers, repoPath, "pull")
+	if !resp.Response {
+		return "", fmt.Errorf("failed to resolve tag %s: %s", tag, responseError(resp))
+	}
+
+	digest := resp.Headers.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry did not return the digest of tag %s", tag)
+	}
+	return digest, nil
+}
+
+// DeleteTag deletes only the tag (OCI distribution spec 1.1), the registries
+// not supporting it answer 400 or 405 (see DeleteManifest)
+func (r *ociRegistry) DeleteTag(repoPath string, tag string) models.ApiResponse {
+	endpoint := fmt.Sprintf("/v2/%s/manifests/%s", repoPath, url.PathEscape(tag))
+	return r.request("DELETE", endpoint, nil, nil, repoPath, "delete")
+}
+
+// DeleteManifest deletes the manifest by digest.
+// Note: every tag pointing to the digest disappears too.
+func (r *ociRegistry) DeleteManifest(repoPath string, digest string) models.ApiResponse {
+	endpoint := fmt.Sprintf("/v2/%s/manifests/%s", repoPath, digest)
+	return r.request("DELETE", endpoint, nil, nil, repoPath, "delete")
+}
+
+// Platforms returns the platform images of the tag: the entries of the index
+// for multi-arch images or the platform of the image config otherwise
+func (r *ociRegistry) Platforms(repoPath string, tag string) ([]docker.PlatformInfo, error) {
+	manifest, digest, err := r.getManifest(repoPath, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	// Multi-arch index, every entry is a platform
+	if len(manifest.Manifests) > 0 {
+		var platforms []docker.PlatformInfo
+		for _, entry := range manifest.Manifests {
+			// Attestation manifests are not platforms
+			if entry.Platform == nil || entry.Platform.OS == "unknown" {
+				continue
+			}
+			size := entry.Size
+			if image, _, err := r.getManifest(repoPath, entry.Digest); err == nil {
+				size = image.imageSize()
+			}
+			platforms = append(platforms, docker.PlatformInfo{
+				OS:           entry.Platform.OS,
+				Architecture: entry.Platform.Architecture,
+				Variant:      entry.Platform.Variant,
+				Digest:       entry.Digest,
+				Size:         size,
+			})
+		}
+		return platforms, nil
+	}
+
+	// Single image, the platform is in the config blob
+	endpoint := fmt.Sprintf("/v2/%s/blobs/%s", repoPath, manifest.Config.Digest)
+	resp := r.request("GET", endpoint, nil, nil, repoPath, "pull")
+	if !resp.Response {
+		return nil, fmt.Errorf("failed to get the config of tag %s: %s", tag, responseError(resp))
+	}
+	var config ociPlatform
+	if err := json.Unmarshal(resp.Body, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the config of tag %s: %w", tag, err)
+	}
+
+	return []docker.PlatformInfo{{
+		OS:           config.OS,
+		Architecture: config.Architecture,
+		Variant:      config.Variant,
+		Digest:       digest,
+		Size:         manifest.imageSize(),
+	}}, nil
+}
+
+// getManifest returns the parsed manifest (or index) of the reference (tag or digest) and its digest
+func (r *ociRegistry) getManifest(repoPath string, reference string) (ociManifest, string, error) {
+	manifest, err := r.GetManifest(repoPath, reference)
+	if err != nil {
+		return ociManifest{}, "", err
+	}
+
+	var parsed ociManifest
+	if err := json.Unmarshal(manifest.Body, &parsed); err != nil {
+		return ociManifest{}, "", fmt.Errorf("failed to unmarshal manifest %s: %w", reference, err)
+	}
+	return parsed, manifest.Digest, nil
+}
+
+// GetManifest returns the raw manifest (or index) of the reference (tag or digest)
+func (r *ociRegistry) GetManifest(repoPath string, reference string) (Manifest, error) {
+	endpoint := fmt.Sprintf("/v2/%s/manifests/%s", repoPath, url.PathEscape(reference))
+	headers := map[string]string{"Accept": manifestAcceptTypes}
+
+	resp := r.request("GET", endpoint, nil, headers, repoPath, "pull")
+	if !resp.Response {
+		return Manifest{}, fmt.Errorf("failed to get manifest %s: %s", reference, responseError(resp))
+	}
+
+	manifest := Manifest{
+		MediaType: strings.TrimSpace(strings.Split(resp.Headers.Get("Content-Type"), ";")[0]),
+		Digest:    resp.Headers.Get("Docker-Content-Digest"),
+		Body:      resp.Body,
+	}
+	if manifest.Digest == "" {
+		manifest.Digest = fmt.Sprintf("sha256:%x", sha256.Sum256(resp.Body))
+	}
+	// Old registries answer with a generic content type, the manifest knows its own
+	if manifest.MediaType == "" || manifest.MediaType == "application/json" {
+		var parsed ociManifest
+		if err := json.Unmarshal(resp.Body, &parsed); err == nil && parsed.MediaType != "" {
+			manifest.MediaType = parsed.MediaType
+		}
+	}
+	return manifest, nil
+}
+
+// PutManifest pushes the raw manifest under the reference (tag or digest)
+func (r *ociRegistry) PutManifest(repoPath string, reference string, manifest Manifest) error {
+	endpoint := fmt.Sprintf("/v2/%s/manifests/%s", repoPath, url.PathEscape(reference))
+	headers := map[string]string{"Content-Type": manifest.MediaType}
+
+	resp := r.request("PUT", endpoint, manifest.Body, headers, repoPath, "push")
+	if !resp.Response {
+		return fmt.Errorf("failed to push manifest %s to %s: %s", reference, repoPath, responseError(resp))
+	}
+	return nil
+}
+
+// GetBlob returns the content of the blob
+func (r *ociRegistry) GetBlob(repoPath string, digest string) ([]byte, error) {
+	resp := r.request("GET", fmt.Sprintf("/v2/%s/blobs/%s", repoPath, digest), nil, nil, repoPath, "pull")
+	if !resp.Response {
+		return nil, fmt.Errorf("failed to get blob %s from %s: %s", digest, repoPath, responseError(resp))
+	}
+	return resp.Body, nil
+}
+
+// CopyBlob makes the blob available in toRepo: nothing to do when it is already there,
+// otherwise it is mounted from fromRepo and, when the registry refuses the mount,
+// streamed (never held in memory) through the upload session opened by the mount request
+func (r *ociRegistry) CopyBlob(fromRepo string, toRepo string, digest string) error {
+	head := r.request("HEAD", fmt.Sprintf("/v2/%s/blobs/%s", toRepo, digest), nil, nil, toRepo, "pull")
+	if head.Response {
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("/v2/%s/blobs/uploads/?mount=%s&from=%s", toRepo, url.QueryEscape(digest), url.QueryEscape(fromRepo))
+	mount := r.request("POST", endpoint, nil, nil, toRepo, "push", "repository:"+fromRepo+":pull")
+	switch mount.StatusCode {
+	case 201:
+		return nil
+	case 202:
+		// Upload session opened, the blob has to be sent
+	default:
+		return fmt.Errorf("failed to mount blob %s in %s: %s", digest, toRepo, responseError(mount))
+	}
+
+	return r.streamBlob(fromRepo, toRepo, digest, mount.Headers.Get("Location"), "repository:"+fromRepo+":pull")
+}
+
+// streamBlob uploads the blob of fromRepo to the upload session at location: the GET
+// body goes straight into the PUT, the layer is never kept in memory. The PUT is sent
+// with the authorization of the mount request (push on toRepo), a streamed body can
+// not be sent again after a 401.
+func (r *ociRegistry) streamBlob(fromRepo string, toRepo string, digest string, location string, mountScope string) error {
+	blobEndpoint := fmt.Sprintf("/v2/%s/blobs/%s", fromRepo, digest)
+	if head := r.request("HEAD", blobEndpoint, nil, nil, fromRepo, "pull"); !head.Response {
+		return fmt.Errorf("failed to get blob %s from %s: %s", digest, fromRepo, responseError(head))
+	}
+	get, err := r.stream("GET", blobEndpoint, nil, -1, nil, r.cachedAuth(fromRepo, "pull"))
+	if err != nil {
+		return fmt.Errorf("failed to get blob %s from %s: %w", digest, fromRepo, err)
+	}
+	defer get.Body.Close()
+	if get.StatusCode != 200 {
+		return fmt.Errorf("failed to get blob %s from %s: status %d", digest, fromRepo, get.StatusCode)
+	}
+
+	location = strings.TrimPrefix(location, r.client.BaseURL)
+	separator := "?"
+	if strings.Contains(location, "?") {
+		separator = "&"
+	}
+	headers := map[string]string{"Content-Type": "application/octet-stream"}
+	put, err := r.stream("PUT", location+separator+"digest="+url.QueryEscape(digest), get.Body, get.ContentLength, headers, r.cachedAuth(toRepo, "push", mountScope))
+	if err != nil {
+		return fmt.Errorf("failed to upload blob %s to %s: %w", digest, toRepo, err)
+	}
+	defer put.Body.Close()
+	if put.StatusCode < 200 || put.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(put.Body, 4096))
+		return fmt.Errorf("failed to upload blob %s to %s: %s", digest, toRepo, responseError(models.NewApiResponse(false, put.StatusCode, put.Header, "ko", body)))
+	}
+	return nil
+}
+
+// stream sends a request with a streamed body and returns the response with its body
+// still open, the caller closes it. size is the length of the body, -1 when unknown.
+func (r *ociRegistry) stream(method string, endpoint string, body io.Reader, size int64, headers map[string]string, auth ociAuth) (*http.Response, error) {
+	req, err := http.NewRequest(method, r.client.BaseURL+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	switch auth.authType {
+	case "Bearer":
+		req.Header.Set("Authorization", "Bearer "+auth.token)
+	case "Basic":
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth.token)))
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	// Same transport, without the timeout of the api client: a large layer takes longer
+	client := &http.Client{Transport: r.client.HTTPClient.Transport}
+	return client.Do(req)
+}
+
+// cachedAuth returns the authorization cached by request for repoPath/action
+func (r *ociRegistry) cachedAuth(repoPath string, action string, extraScopes ...string) ociAuth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.auths[authKey(repoPath, action, extraScopes)]
+}
+
+// authKey is the key of the cached authorization of repoPath/action
+func authKey(repoPath string, action string, extraScopes []string) string {
+	return repoPath + ":" + action + ":" + strings.Join(extraScopes, " ")
+}
+
+// request sends the request with the cached authorization for repoPath/action,
+// on a 401 it solves the challenge, caches the new authorization and retries once.
+// extraScopes are asked together with the challenge scope (e.g. the source of a blob mount).
+func (r *ociRegistry) request(method string, endpoint string, body interface{}, headers map[string]string, repoPath string, action string, extraScopes ...string) models.ApiResponse {
+	key := authKey(repoPath, action, extraScopes)
+
+	r.mu.Lock()
+	auth, cached := r.auths[key]
+	r.mu.Unlock()
+
+	resp := r.send(method, endpoint, body, headers, auth)
+	if resp.StatusCode != 401 {
+		return resp
+	}
+	if cached {
+		// The token is expired, drop it and ask a new one
+		r.mu.Lock()
+		delete(r.auths, key)
+		r.mu.Unlock()
+	}
+
+	auth, err := r.authenticate(resp.Headers.Get("WWW-Authenticate"), extraScopes...)
+	if err != nil {
+		return models.NewApiResponse(false, 401, resp.Headers, err.Error(), resp.Body)
+	}
+
+	r.mu.Lock()
+	r.auths[key] = auth
+	r.mu.Unlock()
+
+	return r.send(method, endpoint, body, headers, auth)
+}
+
+// send makes the request with the given authorization, sharing the http client
+func (r *ociRegistry) send(method string, endpoint string, body interface{}, headers map[string]string, auth ociAuth) models.ApiResponse {
+	client := *r.client
+	if auth.token != "" {
+		client.AuthType = auth.authType
+		client.AuthToken = auth.token
+	}
+	return client.RequestWithHeaders(method, endpoint, body, headers)
+}
+
+// authenticate solves a WWW-Authenticate challenge (Basic or Bearer token auth)
+func (r *ociRegistry) authenticate(challenge string, extraScopes ...string) (ociAuth, error) {
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if r.username == "" {
+			return ociAuth{}, fmt.Errorf("registry requires credentials (DOCKER_REGISTRY_USR, DOCKER_REGISTRY_PWD)")
+		}
+		return ociAuth{authType: "Basic", token: r.username + ":" + r.password}, nil
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return ociAuth{}, fmt.Errorf("registry bearer challenge without realm")
+		}
+
+		query := url.Values{}
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+		// The token server wants a scope parameter for every scope
+		for _, scope := range append(strings.Fields(params["scope"]), extraScopes...) {
+			query.Add("scope", scope)
+		}
+
+		// The token endpoint is usually on another host, use a dedicated client
+		tokenClient := *r.client
+		tokenClient.BaseURL = ""
+		if r.username != "" {
+			tokenClient.AuthType = "Basic"
+			tokenClient.AuthToken = r.username + ":" + r.password
+		}
+		resp := tokenClient.Request("GET", realm+"?"+query.Encode(), nil)
+		if !resp.Response {
+			return ociAuth{}, fmt.Errorf("registry token request failed: %s", responseError(resp))
+		}
+
+		var tokenResp ociTokenResponse
+		if err := json.Unmarshal(resp.Body, &tokenResp); err != nil {
+			return ociAuth{}, fmt.Errorf("failed to parse registry token response: %w", err)
+		}
+		token := tokenResp.Token
+		if token == "" {
+			token = tokenResp.AccessToken
+		}
+		if token == "" {
+			return ociAuth{}, fmt.Errorf("registry token response without token")
+		}
+		return ociAuth{authType: "Bearer", token: token}, nil
+	}
+
+	return ociAuth{}, fmt.Errorf("unsupported registry auth challenge: '%s'", challenge)
+}
+
+// nextPage returns the endpoint of the next page from the Link header
+func (r *ociRegistry) nextPage(resp models.ApiResponse) string {
+	if resp.Headers == nil {
+		return ""
+	}
+	matches := linkNextRe.FindStringSubmatch(resp.Headers.Get("Link"))
+	if len(matches) != 2 {
+		return ""
+	}
+	// The link can be absolute, strip it to a relative path
+	return strings.TrimPrefix(matches[1], r.client.BaseURL)
+}
+
+// parseChallenge splits a WWW-Authenticate header in scheme and parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	for _, match := range challengeParamRe.FindAllStringSubmatch(rest, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	return scheme, params
+}
+
+// responseError builds a readable error from the api response
+func responseError(resp models.ApiResponse) string {
+	if resp.StatusCode == 0 || len(resp.Body) == 0 {
+		return fmt.Sprintf("%s (status %d)", resp.Message, resp.StatusCode)
+	}
+	return fmt.Sprintf("status %d: %s", resp.StatusCode, string(resp.Body))
+}
@@ -0,0 +1,239 @@
+package be
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// fakeRegistry is a minimal registry:2-style server with token auth
+type fakeRegistry struct {
+	mu         sync.Mutex
+	server     *httptest.Server
+	tags       map[string]string // tag -> digest
+	tokenCalls int
+	deleted    []string // Manifests deleted by digest
+	tagDelete  bool     // Registry supports deleting a tag
+}
+
+func newFakeRegistry(t *testing.T, tags map[string]string) *fakeRegistry {
+	fake := &fakeRegistry{tags: tags}
+	mux := http.NewServeMux()
+
+	// Token endpoint, only user:pass is accepted
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fake.mu.Lock()
+		fake.tokenCalls++
+		fake.mu.Unlock()
+		fmt.Fprintf(w, `{"token":"token-%s"}`, r.URL.Query().Get("scope"))
+	})
+
+	// Distribution api, requires the bearer token
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer token-repository:org/repo:") {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake",scope="repository:org/repo:pull,delete"`, fake.server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		path := strings.TrimPrefix(r.URL.Path, "/v2/org/repo/")
+		switch {
+		case path == "tags/list":
+			fake.listTags(w, r)
+		case strings.HasPrefix(path, "manifests/") && r.Method == "HEAD":
+			digest, ok := fake.tags[strings.TrimPrefix(path, "manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json", "Accept should include the OCI index")
+			w.Header().Set("Docker-Content-Digest", digest)
+		case strings.HasPrefix(path, "manifests/sha256:") && r.Method == "DELETE":
+			digest := strings.TrimPrefix(path, "manifests/")
+			for tag, tagDigest := range fake.tags {
+				if tagDigest == digest {
+					delete(fake.tags, tag)
+				}
+			}
+			fake.deleted = append(fake.deleted, digest)
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasPrefix(path, "manifests/") && r.Method == "DELETE":
+			tag := strings.TrimPrefix(path, "manifests/")
+			if _, ok := fake.tags[tag]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if !fake.tagDelete {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				fmt.Fprint(w, `{"errors":[{"code":"UNSUPPORTED"}]}`)
+				return
+			}
+			delete(fake.tags, tag)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	fake.server = httptest.NewServer(mux)
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+// listTags answers /tags/list with the n/last pagination and the Link header
+func (f *fakeRegistry) listTags(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for tag := range f.tags {
+		names = append(names, tag)
+	}
+	sort.Strings(names)
+
+	last := r.URL.Query().Get("last")
+	n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+	var page []string
+	for _, name := range names {
+		if name > last && len(page) < n {
+			page = append(page, name)
+		}
+	}
+	if len(page) == n && page[len(page)-1] != names[len(names)-1] {
+		w.Header().Set("Link", fmt.Sprintf(`</v2/org/repo/tags/list?n=%d&last=%s>; rel="next"`, n, page[len(page)-1]))
+	}
+	fmt.Fprintf(w, `{"name":"org/repo","tags":["%s"]}`, strings.Join(page, `","`))
+}
+
+func TestOCIRegistry_ListTags(t *testing.T) {
+	// Arrange: A registry with 3 tags
+	fake := newFakeRegistry(t, map[string]string{"1.0.0": "sha256:a", "1.1.0": "sha256:b", "latest": "sha256:b"})
+	registry := NewOCIRegistry(fake.server.URL, "user", "pass")
+
+	// Act: List the tags 2 per page
+	result, statusCode, err := registry.ListTags("org/repo", "2")
+
+	// Assert: Every page is read with a single token request
+	assert.NoError(t, err, "ListTags should not return an error")
+	assert.Equal(t, http.StatusOK, statusCode, "Status code should be 200")
+	assert.Equal(t, 3, result.Count, "All the tags should be returned")
+	assert.Equal(t, "latest", result.TagList[2].Name, "Tags should follow the registry order")
+	assert.Equal(t, 1, fake.tokenCalls, "Token should be cached between pages")
+}
+
+func TestOCIRegistry_ResolveAndDelete(t *testing.T) {
+	// Arrange: A registry with 2 tags
+	fake := newFakeRegistry(t, map[string]string{"1.0.0": "sha256:a", "1.1.0": "sha256:b"})
+	registry := NewOCIRegistry(fake.server.URL, "user", "pass")
+
+	// Act: Resolve a tag and delete both, one twice
+	digest, err := registry.ResolveDigest("org/repo", "1.1.0")
+	result, deleteErr := DeleteImages(registry, "org/repo", []docker.TagInfoInternal{{Name: "1.0.0"}, {Name: "1.1.0"}}, docker.DeleteOptions{Concurrency: 2})
+	again := registry.DeleteTag("org/repo", "1.0.0")
+
+	// Assert: Without tag deletion, manifests are deleted by digest
+	assert.NoError(t, err, "ResolveDigest should not return an error")
+	assert.Equal(t, "sha256:b", digest, "Digest should match")
+	assert.NoError(t, deleteErr, "DeleteImages should not return an error")
+	assert.Equal(t, 2, result.TagsDeleted.Count, "Both tags should be deleted")
+	assert.ElementsMatch(t, []string{"sha256:a", "sha256:b"}, fake.deleted, "Manifests should be deleted by digest")
+	assert.Equal(t, http.StatusNotFound, again.StatusCode, "A deleted tag should not be found")
+}
+
+func TestOCIRegistry_DeleteSharedDigest(t *testing.T) {
+	// Arrange: "1.0.0" and the kept "latest" point to the same manifest
+	fake := newFakeRegistry(t, map[string]string{"0.9.0": "sha256:a", "1.0.0": "sha256:b", "latest": "sha256:b"})
+	registry := NewOCIRegistry(fake.server.URL, "user", "pass")
+
+	// Act: Delete the old tags keeping latest
+	result, err := DeleteImages(registry, "org/repo", tagList("0.9.0", "1.0.0"), docker.DeleteOptions{Concurrency: 2, KeepTags: []string{"latest"}})
+
+	// Assert: The shared manifest is not deleted and the tag is reported as kept
+	assert.NoError(t, err, "DeleteImages should not return an error")
+	assert.Equal(t, []string{"0.9.0"}, result.TagsDeleted.TagList, "Only the tag with its own manifest should be deleted")
+	assert.Equal(t, []string{"1.0.0"}, result.TagsKept.TagList, "Tag sharing the manifest should be kept")
+	assert.Contains(t, result.TagsKept.Failures[0].Reason, "latest", "Reason should name the kept tag")
+	assert.Equal(t, []string{"sha256:a"}, fake.deleted, "Only the unshared manifest should be deleted")
+	assert.Contains(t, fake.tags, "latest", "Kept tag should still exist")
+}
+
+func TestOCIRegistry_DeleteTagLevel(t *testing.T) {
+	// Arrange: A registry supporting the deletion of a tag
+	fake := newFakeRegistry(t, map[string]string{"1.0.0": "sha256:b", "latest": "sha256:b"})
+	fake.tagDelete = true
+	registry := NewOCIRegistry(fake.server.URL, "user", "pass")
+
+	// Act: Delete the tag sharing the manifest of latest
+	result, err := DeleteImages(registry, "org/repo", tagList("1.0.0"), docker.DeleteOptions{Concurrency: 1, KeepTags: []string{"latest"}})
+
+	// Assert: Only the tag is deleted, the manifest is untouched
+	assert.NoError(t, err, "DeleteImages should not return an error")
+	assert.Equal(t, []string{"1.0.0"}, result.TagsDeleted.TagList, "Tag should be deleted")
+	assert.Empty(t, fake.deleted, "No manifest should be deleted")
+	assert.Contains(t, fake.tags, "latest", "Kept tag should still exist")
+}
+
+func TestOCIRegistry_WrongCredentials(t *testing.T) {
+	// Arrange: A registry and wrong credentials
+	fake := newFakeRegistry(t, map[string]string{"1.0.0": "sha256:a"})
+	registry := NewOCIRegistry(fake.server.URL, "user", "wrong")
+
+	// Act: List the tags
+	_, statusCode, err := registry.ListTags("org/repo", "10")
+
+	// Assert: The token request fails
+	assert.Error(t, err, "ListTags should fail with wrong credentials")
+	assert.Equal(t, http.StatusUnauthorized, statusCode, "Status code should be 401")
+}
+
+func TestOCIRegistry_Platforms(t *testing.T) {
+	// Arrange: An anonymous registry with a multi-arch index and a single-arch image
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/org/repo/manifests/multi", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:index")
+		fmt.Fprint(w, `{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+			{"digest":"sha256:amd","size":500,"platform":{"os":"linux","architecture":"amd64"}},
+			{"digest":"sha256:arm","size":500,"platform":{"os":"linux","architecture":"arm","variant":"v7"}},
+			{"digest":"sha256:att","size":500,"platform":{"os":"unknown","architecture":"unknown"}}]}`)
+	})
+	mux.HandleFunc("/v2/org/repo/manifests/sha256:amd", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"config":{"digest":"sha256:cfg","size":10},"layers":[{"size":100},{"size":200}]}`)
+	})
+	mux.HandleFunc("/v2/org/repo/manifests/single", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:single")
+		fmt.Fprint(w, `{"config":{"digest":"sha256:cfg","size":10},"layers":[{"size":90}]}`)
+	})
+	mux.HandleFunc("/v2/org/repo/blobs/sha256:cfg", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"os":"linux","architecture":"arm64"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	registry := NewOCIRegistry(server.URL, "", "")
+
+	// Act: Inspect both tags
+	multi, multiErr := registry.Platforms("org/repo", "multi")
+	single, singleErr := registry.Platforms("org/repo", "single")
+
+	// Assert: Index entries are platforms, attestations are skipped
+	assert.NoError(t, multiErr, "Platforms should not return an error for the index")
+	assert.Len(t, multi, 2, "Attestation manifests should be skipped")
+	assert.Equal(t, "linux/amd64", multi[0].String(), "First platform should match")
+	assert.Equal(t, int64(310), multi[0].Size, "Size should be read from the platform manifest")
+	assert.Equal(t, "linux/arm/v7", multi[1].String(), "Variant should be kept")
+	assert.Equal(t, int64(500), multi[1].Size, "Size should fall back to the descriptor size")
+	assert.NoError(t, singleErr, "Platforms should not return an error for the image")
+	assert.Equal(t, []docker.PlatformInfo{{OS: "linux", Architecture: "arm64", Digest: "sha256:single", Size: 100}}, single, "Platform should be read from the config")
+}
@@ -0,0 +1,42 @@
+package be
+
+import (
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// Media types accepted when resolving a manifest
+const manifestAcceptTypes = "application/vnd.oci.image.index.v1+json," +
+	"application/vnd.docker.distribution.manifest.list.v2+json," +
+	"application/vnd.oci.image.manifest.v1+json," +
+	"application/vnd.docker.distribution.manifest.v2+json"
+
+// TokenSource gives the Docker Hub token to the registry: Token returns the
+// current token (renewed when expired), Refresh forces a new one after a 401
+type TokenSource interface {
+	Token() (string, error)
+	Refresh() (string, error)
+}
+
+// Registry is the abstraction over the container registries supported by the
+// docker commands: Docker Hub (hub.docker.com api) and any OCI Distribution v2
+// registry (Harbor, GHCR, ECR-compatible, self-hosted registry:2, ...)
+type Registry interface {
+	// ListRepositories returns the repository paths ("namespace/name") of the namespace
+	ListRepositories(namespace string) ([]string, error)
+	// ListTags returns all the tags of the repository and the last status code
+	ListTags(repoPath string, imagesForPage string) (docker.TagResponseInternal, int, error)
+	// ResolveDigest returns the manifest digest the tag points to
+	ResolveDigest(repoPath string, tag string) (string, error)
+	// Platforms returns the platform images of the tag (one for single-arch images)
+	Platforms(repoPath string, tag string) ([]docker.PlatformInfo, error)
+	// DeleteTag deletes only the tag and returns the api response of the deletion
+	DeleteTag(repoPath string, tag string) models.ApiResponse
+}
+
+// ManifestDeleter is implemented by the registries that can delete a manifest by
+// digest, used when the registry does not support deleting a tag. Deleting the
+// manifest removes every tag pointing to it.
+type ManifestDeleter interface {
+	DeleteManifest(repoPath string, digest string) models.ApiResponse
+}
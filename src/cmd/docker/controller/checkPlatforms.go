@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// CheckPlatforms checks that the tags of the repository (all of them when tags is
+// empty) are built for every required platform (os/arch[/variant]).
+// When some tags miss a platform the report is returned together with an error.
+func CheckPlatforms(registryURL string, repoPath string, imagesForPage string, tags []string, required []string) ([]byte, error) {
+	if err := validatePlatforms(required); err != nil {
+		return helpers.HandleControllerApi(false, "400", err.Error(), "CheckPlatforms", struct{}{}, err)
+	}
+
+	// Get the registry (login included)
+	registry, err := openRegistry(registryURL)
+	if err != nil {
+		errorAuthFailed := fmt.Sprintf("Docker auth error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			"500",
+			errorAuthFailed,
+			"CheckPlatforms",
+			struct{}{},
+			err,
+		)
+	}
+
+	// Get the tag list from BE layer
+	result, statusCode, err := registry.ListTags(repoPath, imagesForPage)
+	if err != nil {
+		errorMessage := fmt.Sprintf("Docker GetImages error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			strconv.Itoa(statusCode),
+			errorMessage,
+			"CheckPlatforms",
+			struct{}{},
+			err,
+		)
+	}
+
+	report := buildPlatformReport(registry, repoPath, selectTags(result.TagList, tags), required)
+	if report.Failed > 0 {
+		// Keep the data, the caller needs to know which platforms are missing
+		err := fmt.Errorf("%d of %d tags miss required platforms", report.Failed, report.Checked)
+		data, _ := helpers.HandleControllerApi(
+			true,
+			"422",
+			fmt.Sprintf("Docker CheckPlatforms error: %s", err.Error()),
+			"CheckPlatforms",
+			report,
+			nil,
+		)
+		return data, err
+	}
+
+	return helpers.HandleControllerApi(
+		true,
+		"200",
+		"Docker tags have all the required platforms",
+		"CheckPlatforms",
+		report,
+		nil,
+	)
+}
+
+// validatePlatforms checks that every platform is in the form os/arch[/variant]
+func validatePlatforms(required []string) error {
+	if len(required) == 0 {
+		return fmt.Errorf("at least a required platform is needed, e.g. linux/amd64")
+	}
+	for _, platform := range required {
+		parts := strings.Split(platform, "/")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid platform '%s', expected os/arch[/variant]", platform)
+		}
+	}
+	return nil
+}
+
+// selectTags returns the listed tags with the given names (all of them when names is empty),
+// a name not listed is returned without platforms so that it is inspected on its own
+func selectTags(listed []docker.TagInfoInternal, names []string) []docker.TagInfoInternal {
+	if len(names) == 0 {
+		return listed
+	}
+	byName := make(map[string]docker.TagInfoInternal, len(listed))
+	for _, tag := range listed {
+		byName[tag.Name] = tag
+	}
+	selected := make([]docker.TagInfoInternal, 0, len(names))
+	for _, name := range names {
+		tag, ok := byName[name]
+		if !ok {
+			tag = docker.TagInfoInternal{Name: name}
+		}
+		selected = append(selected, tag)
+	}
+	return selected
+}
+
+// buildPlatformReport checks the tags in parallel, the platforms not returned
+// with the tag list (OCI registries) are read from the manifests
+func buildPlatformReport(registry be.Registry, repoPath string, tags []docker.TagInfoInternal, required []string) docker.PlatformReport {
+	checks := make([]docker.PlatformCheck, len(tags))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < scanWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				platforms := tags[i].Platforms
+				if len(platforms) == 0 {
+					inspected, err := registry.Platforms(repoPath, tags[i].Name)
+					if err != nil {
+						checks[i] = docker.PlatformCheck{Name: tags[i].Name, Platforms: []string{}, Missing: required, Error: err.Error()}
+						continue
+					}
+					platforms = inspected
+				}
+				checks[i] = checkTagPlatforms(tags[i].Name, platforms, required)
+			}
+		}()
+	}
+	for i := range tags {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := docker.PlatformReport{
+		Repository: repoPath,
+		Required:   required,
+		Checked:    len(checks),
+		Tags:       checks,
+	}
+	for _, check := range checks {
+		if len(check.Missing) > 0 {
+			report.Failed++
+		}
+	}
+	return report
+}
+
+// checkTagPlatforms returns the platforms of the tag and the required ones missing.
+// A required platform without variant (linux/arm) matches any variant.
+func checkTagPlatforms(name string, platforms []docker.PlatformInfo, required []string) docker.PlatformCheck {
+	check := docker.PlatformCheck{Name: name, Platforms: []string{}, Missing: []string{}}
+	for _, platform := range platforms {
+		check.Platforms = append(check.Platforms, platform.String())
+	}
+
+	for _, want := range required {
+		found := false
+		for _, platform := range platforms {
+			if want == platform.String() || want == platform.OS+"/"+platform.Architecture {
+				found = true
+				break
+			}
+		}
+		if !found {
+			check.Missing = append(check.Missing, want)
+		}
+	}
+	return check
+}
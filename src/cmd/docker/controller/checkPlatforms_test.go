@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+func TestCheckTagPlatforms(t *testing.T) {
+	// Arrange: A tag built for amd64 and arm/v7
+	platforms := []docker.PlatformInfo{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm", Variant: "v7"},
+	}
+
+	// Act: Require amd64, any arm, arm/v6 and arm64
+	check := checkTagPlatforms("1.0.0", platforms, []string{"linux/amd64", "linux/arm", "linux/arm/v6", "linux/arm64"})
+
+	// Assert: Only the platforms not built are missing
+	assert.Equal(t, []string{"linux/amd64", "linux/arm/v7"}, check.Platforms, "Platforms should match")
+	assert.Equal(t, []string{"linux/arm/v6", "linux/arm64"}, check.Missing, "Missing platforms should match")
+}
+
+func TestValidatePlatforms(t *testing.T) {
+	// Act & Assert: Only os/arch[/variant] is accepted
+	assert.NoError(t, validatePlatforms([]string{"linux/amd64", "linux/arm/v7"}), "Valid platforms should be accepted")
+	assert.Error(t, validatePlatforms(nil), "At least a platform should be required")
+	assert.Error(t, validatePlatforms([]string{"amd64"}), "Platform without os should be rejected")
+}
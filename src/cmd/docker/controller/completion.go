@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// CompleteRepositories returns the repositories of the registry for the shell completion, cached
+// for a short time. The namespace is the one being completed (before the /), for Docker Hub the
+// namespace of the user by default.
+func CompleteRepositories(registryURL string, toComplete string) []string {
+	namespace, _, ok := strings.Cut(toComplete, "/")
+	if !ok {
+		namespace = ""
+	}
+	return helpers.CachedCompletions("docker repositories "+registryURL+" "+namespace, func() ([]string, error) {
+		registry, err := openRegistry(registryURL)
+		if err != nil {
+			return nil, err
+		}
+		if namespace == "" && isDockerHub(registryURL) && isDockerHub(helpers.AppConfig.DOCKER_REGISTRY_URL) {
+			creds, err := dockerHubCredentials()
+			if err != nil {
+				return nil, err
+			}
+			namespace = creds.Username
+		}
+		return registry.ListRepositories(namespace)
+	})
+}
+
+// CompleteTags returns the tags of the repository for the shell completion,
+// described by their push time and cached for a short time
+func CompleteTags(registryURL string, repoPath string) []string {
+	if repoPath == "" {
+		return nil
+	}
+	return helpers.CachedCompletions("docker tags "+registryURL+" "+repoPath, func() ([]string, error) {
+		registry, err := openRegistry(registryURL)
+		if err != nil {
+			return nil, err
+		}
+		result, _, err := registry.ListTags(repoPath, "100")
+		if err != nil {
+			return nil, err
+		}
+		var completions []string
+		for _, tag := range result.TagList {
+			completion := tag.Name
+			if tag.TagLastPushed != "" {
+				completion += "\tpushed " + tag.TagLastPushed
+			}
+			completions = append(completions, completion)
+		}
+		return completions, nil
+	})
+}
+
+// CompleteImageRefs returns the repo:tag completions: the repositories, then
+// the tags of the repository once the : is typed
+func CompleteImageRefs(registryURL string, toComplete string) []string {
+	repoPath, _, ok := strings.Cut(toComplete, ":")
+	var completions []string
+	if !ok {
+		for _, repository := range CompleteRepositories(registryURL, toComplete) {
+			completions = append(completions, repository+":")
+		}
+		return completions
+	}
+	for _, tag := range CompleteTags(registryURL, repoPath) {
+		completions = append(completions, repoPath+":"+tag)
+	}
+	return completions
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/shared"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// DeleteImages applies the retention policy to the tags of the repository.
+// With dry-run it returns the tags to delete and the decision taken for
+// every tag (with the rule that protected it), otherwise it deletes them.
+// When some tags are not deleted the result (with the failure reasons)
+// is returned together with an error.
+func DeleteImages(registryURL string, repoPath string, imagesForPage string, policy docker.RetentionPolicy, opts docker.DeleteOptions, dryRun bool) ([]byte, error) {
+	// Load the deployed tags (if configured)
+	protected := map[string]string{}
+	if policy.KeepDeployedFile != "" {
+		deployed, err := shared.LoadDeployedTags(policy.KeepDeployedFile, repoPath)
+		if err != nil {
+			return helpers.HandleControllerApi(
+				false,
+				"500",
+				fmt.Sprintf("Docker retention policy error: %s", err.Error()),
+				"DeleteImages",
+				struct{}{},
+				err,
+			)
+		}
+		protected = deployed
+	}
+
+	// Load the tags live in ArgoCD (if configured)
+	if policy.KeepArgoCDDeployed {
+		deployed, err := shared.LoadArgoCDDeployedTags(
+			helpers.AppConfig.ARGOCD_URL,
+			helpers.AppConfig.ARGOCD_USER,
+			helpers.AppConfig.ARGOCD_PASSWORD,
+			repoPath,
+		)
+		if err != nil {
+			return helpers.HandleControllerApi(
+				false,
+				"500",
+				fmt.Sprintf("Docker ArgoCD protection error: %s", err.Error()),
+				"DeleteImages",
+				struct{}{},
+				err,
+			)
+		}
+		for ref, reason := range deployed {
+			protected[ref] = reason
+		}
+	}
+
+	// Get the registry (login included)
+	registry, err := openRegistry(registryURL)
+	if err != nil {
+		errorAuthFailed := fmt.Sprintf("Docker auth error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			"500",
+			errorAuthFailed,
+			"DeleteImages",
+			struct{}{},
+			err,
+		)
+	}
+
+	// Get the tag list from BE layer
+	result, statusCode, err := registry.ListTags(repoPath, imagesForPage)
+	if err != nil {
+		errorMessage := fmt.Sprintf("Docker GetImages error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			strconv.Itoa(statusCode),
+			errorMessage,
+			"DeleteImages",
+			struct{}{},
+			err,
+		)
+	}
+
+	// Take the tags to delete
+	report, err := applyRetentionPolicy(result, policy, protectDigests(result, protected), time.Now())
+	if err != nil {
+		return helpers.HandleControllerApi(
+			false,
+			"500",
+			fmt.Sprintf("Docker retention policy error: %s", err.Error()),
+			"DeleteImages",
+			struct{}{},
+			err,
+		)
+	}
+
+	// Dry-run, print only the tags to delete and the decisions
+	if dryRun {
+		return helpers.HandleControllerApi(
+			true,
+			strconv.Itoa(statusCode),
+			"Docker images to delete successfully retrieved",
+			"DeleteImages",
+			report,
+			nil,
+		)
+	}
+
+	// Delete the images, the manifests of the kept tags are never deleted
+	opts.KeepTags = keptTags(result, report)
+	deletedImages, err := be.DeleteImages(registry, repoPath, report.TagList, opts)
+	if err != nil {
+		// Keep the data, the caller needs the reason of every failure
+		errorMessage := fmt.Sprintf("Docker DeleteImages error: %s", err.Error())
+		result, _ := helpers.HandleControllerApi(
+			true,
+			"207",
+			errorMessage,
+			"DeleteImages",
+			deletedImages,
+			nil,
+		)
+		return result, err
+	}
+
+	// Return the response with data map for delete
+	return helpers.HandleControllerApi(
+		true,
+		"200",
+		"Docker images successfully deleted",
+		"DeleteImages",
+		deletedImages,
+		nil,
+	)
+}
+
+// keptTags returns the tags of the repository not selected for deletion
+func keptTags(result docker.TagResponseInternal, report docker.RetentionReport) []string {
+	deleted := make(map[string]bool, len(report.TagList))
+	for _, tag := range report.TagList {
+		deleted[tag.Name] = true
+	}
+	var kept []string
+	for _, tag := range result.TagList {
+		if !deleted[tag.Name] {
+			kept = append(kept, tag.Name)
+		}
+	}
+	return kept
+}
+
+// protectDigests resolves the protected digests (images deployed by digest)
+// to the tags pointing to them, the tag names are returned as they are
+func protectDigests(result docker.TagResponseInternal, protected map[string]string) map[string]string {
+	resolved := make(map[string]string, len(protected))
+	for ref, reason := range protected {
+		resolved[ref] = reason
+	}
+	for _, tag := range result.TagList {
+		if reason, ok := protected[tag.Digest]; ok && tag.Digest != "" {
+			if _, exists := resolved[tag.Name]; !exists {
+				resolved[tag.Name] = reason
+			}
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// Number of repositories (or tags) scanned in parallel
+const scanWorkers = 5
+
+// Inventory lists all the repositories of the namespace and aggregates for every
+// repository the tag count, the total size, the oldest/newest push and the stale
+// tags (never pulled or not pulled in the last staleDays days). The tags listed
+// without any date (OCI registries) are reported as unknown, not as stale.
+// The csv and table output formats are rendered here, the others from the JSON response.
+func Inventory(registryURL string, namespace string, imagesForPage string, staleDays int, format string) ([]byte, error) {
+	// Get the registry (login included)
+	registry, err := openRegistry(registryURL)
+	if err != nil {
+		errorAuthFailed := fmt.Sprintf("Docker auth error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			"500",
+			errorAuthFailed,
+			"Inventory",
+			struct{}{},
+			err,
+		)
+	}
+
+	// Get the repositories of the namespace
+	repositories, err := registry.ListRepositories(namespace)
+	if err != nil {
+		errorMessage := fmt.Sprintf("Docker Inventory error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			"500",
+			errorMessage,
+			"Inventory",
+			struct{}{},
+			err,
+		)
+	}
+
+	report := buildInventoryReport(registry, namespace, repositories, imagesForPage, staleDays, time.Now().UTC())
+
+	switch format {
+	case helpers.OutputCSV:
+		return inventoryCSV(report)
+	case helpers.OutputTable:
+		return inventoryTable(report), nil
+	}
+	return helpers.HandleControllerApi(
+		true,
+		"200",
+		"Docker inventory successfully retrieved",
+		"Inventory",
+		report,
+		nil,
+	)
+}
+
+// buildInventoryReport scans the repositories in parallel and sums up the totals.
+// A repository whose tags cannot be listed is reported with its error.
+func buildInventoryReport(registry be.Registry, namespace string, repositories []string, imagesForPage string, staleDays int, now time.Time) docker.InventoryReport {
+	inventories := make([]docker.RepositoryInventory, len(repositories))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < scanWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				tags, _, err := registry.ListTags(repositories[i], imagesForPage)
+				if err != nil {
+					inventories[i] = docker.RepositoryInventory{Repository: repositories[i], StaleTags: []string{}, UnknownTags: []string{}, Error: err.Error()}
+					continue
+				}
+				inventories[i] = repositoryInventory(repositories[i], tags.TagList, staleDays, now)
+			}
+		}()
+	}
+	for i := range repositories {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Biggest repositories first, they are the first to clean up
+	sort.SliceStable(inventories, func(i, j int) bool {
+		return inventories[i].TotalSize > inventories[j].TotalSize
+	})
+
+	report := docker.InventoryReport{
+		Namespace:    namespace,
+		GeneratedAt:  now.Format(time.RFC3339),
+		StaleDays:    staleDays,
+		TotalRepos:   len(inventories),
+		Repositories: inventories,
+	}
+	for _, inventory := range inventories {
+		report.TotalTags += inventory.TagCount
+		report.TotalSize += inventory.TotalSize
+		report.TotalStaleTags += inventory.StaleTagCount
+		report.TotalUnknown += len(inventory.UnknownTags)
+	}
+	return report
+}
+
+// repositoryInventory aggregates the tags of a repository
+func repositoryInventory(repository string, tags []docker.TagInfoInternal, staleDays int, now time.Time) docker.RepositoryInventory {
+	inventory := docker.RepositoryInventory{
+		Repository:  repository,
+		TagCount:    len(tags),
+		StaleTags:   []string{},
+		UnknownTags: []string{},
+	}
+
+	var oldest, newest time.Time
+	for _, tag := range tags {
+		inventory.TotalSize += tag.FullSize
+
+		pushed, hasPush := parseDockerTime(tag.TagLastPushed)
+		if hasPush {
+			if oldest.IsZero() || pushed.Before(oldest) {
+				oldest = pushed
+			}
+			if newest.IsZero() || pushed.After(newest) {
+				newest = pushed
+			}
+		}
+
+		// Without any date the registry gives no pull data, a tag pushed and
+		// never pulled is stale
+		pulled, hasPull := parseDockerTime(tag.TagLastPulled)
+		switch {
+		case !hasPull && !hasPush:
+			inventory.UnknownTags = append(inventory.UnknownTags, tag.Name)
+		case !hasPull || now.Sub(pulled) > days(staleDays):
+			inventory.StaleTags = append(inventory.StaleTags, tag.Name)
+		}
+	}
+	inventory.StaleTagCount = len(inventory.StaleTags)
+
+	if !oldest.IsZero() {
+		inventory.OldestPush = oldest.Format(time.RFC3339)
+		inventory.NewestPush = newest.Format(time.RFC3339)
+	}
+	return inventory
+}
+
+// inventoryCSV writes a csv line for every repository
+func inventoryCSV(report docker.InventoryReport) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	writer.Write([]string{"repository", "tag_count", "total_size", "oldest_push", "newest_push", "stale_tag_count", "unknown_stale_tag_count", "error"})
+	for _, r := range report.Repositories {
+		writer.Write([]string{
+			r.Repository,
+			strconv.Itoa(r.TagCount),
+			strconv.FormatInt(r.TotalSize, 10),
+			r.OldestPush,
+			r.NewestPush,
+			strconv.Itoa(r.StaleTagCount),
+			strconv.Itoa(len(r.UnknownTags)),
+			r.Error,
+		})
+	}
+	writer.Flush()
+
+	return buf.Bytes(), writer.Error()
+}
+
+// inventoryTable writes a human readable table with the totals
+func inventoryTable(report docker.InventoryReport) []byte {
+	var buf bytes.Buffer
+	writer := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "REPOSITORY\tTAGS\tSIZE\tOLDEST PUSH\tNEWEST PUSH\tSTALE\t")
+	for _, r := range report.Repositories {
+		if r.Error != "" {
+			fmt.Fprintf(writer, "%s\t-\t-\t-\t-\t-\terror: %s\n", r.Repository, r.Error)
+			continue
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%s\t%s\t\n",
+			r.Repository, r.TagCount, humanSize(r.TotalSize), r.OldestPush, r.NewestPush, staleCell(r.StaleTagCount, len(r.UnknownTags)))
+	}
+	fmt.Fprintf(writer, "TOTAL (%d repos)\t%d\t%s\t\t\t%s\t\n",
+		report.TotalRepos, report.TotalTags, humanSize(report.TotalSize), staleCell(report.TotalStaleTags, report.TotalUnknown))
+	writer.Flush()
+
+	return buf.Bytes()
+}
+
+// staleCell formats the stale count of the table with the tags of unknown status
+func staleCell(stale int, unknown int) string {
+	if unknown == 0 {
+		return strconv.Itoa(stale)
+	}
+	return fmt.Sprintf("%d (%d unknown)", stale, unknown)
+}
+
+// humanSize formats a size in bytes with binary units
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// inventoryRegistry is an in-memory registry for the inventory tests
+type inventoryRegistry struct {
+	be.Registry
+	tags map[string][]docker.TagInfoInternal
+}
+
+func (r *inventoryRegistry) ListTags(repoPath string, imagesForPage string) (docker.TagResponseInternal, int, error) {
+	tags, ok := r.tags[repoPath]
+	if !ok {
+		return docker.TagResponseInternal{}, 404, errors.New("repository not found")
+	}
+	return docker.TagResponseInternal{TagList: tags, Count: len(tags)}, 200, nil
+}
+
+func TestBuildInventoryReport(t *testing.T) {
+	// Arrange: Two repositories and one that cannot be listed
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	registry := &inventoryRegistry{tags: map[string][]docker.TagInfoInternal{
+		"org/api": {
+			{Name: "1.0.0", FullSize: 100, TagLastPushed: "2024-01-01T00:00:00Z", TagLastPulled: "2024-01-02T00:00:00Z"},
+			{Name: "1.1.0", FullSize: 200, TagLastPushed: "2024-05-01T00:00:00Z", TagLastPulled: "2024-05-30T00:00:00Z"},
+			{Name: "dev", FullSize: 50, TagLastPushed: "2024-03-01T00:00:00Z"},
+		},
+		"org/web": {
+			{Name: "2.0.0", FullSize: 1000, TagLastPushed: "2024-04-01T00:00:00Z", TagLastPulled: "2024-05-31T00:00:00Z"},
+		},
+	}}
+
+	// Act: Build the report with 90 stale days
+	report := buildInventoryReport(registry, "org", []string{"org/api", "org/web", "org/missing"}, "100", 90, now)
+
+	// Assert: Totals and per repository aggregates match
+	assert.Equal(t, 3, report.TotalRepos, "All the repositories should be reported")
+	assert.Equal(t, 4, report.TotalTags, "Tags should be summed")
+	assert.Equal(t, int64(1350), report.TotalSize, "Sizes should be summed")
+	assert.Equal(t, 2, report.TotalStaleTags, "Stale tags should be summed")
+	assert.Equal(t, "org/web", report.Repositories[0].Repository, "Biggest repository should be first")
+
+	api := report.Repositories[1]
+	assert.Equal(t, "2024-01-01T00:00:00Z", api.OldestPush, "Oldest push should match")
+	assert.Equal(t, "2024-05-01T00:00:00Z", api.NewestPush, "Newest push should match")
+	assert.Equal(t, []string{"1.0.0", "dev"}, api.StaleTags, "Old and never pulled tags should be stale")
+	assert.NotEmpty(t, report.Repositories[2].Error, "Listing error should be reported")
+}
+
+func TestRepositoryInventory_NoPullData(t *testing.T) {
+	// Arrange: Tags of an OCI registry, listed without dates
+	tags := []docker.TagInfoInternal{{Name: "1.0.0"}, {Name: "1.1.0"}}
+
+	// Act: Aggregate the repository
+	inventory := repositoryInventory("org/api", tags, 90, time.Now())
+
+	// Assert: The stale status is unknown, not counted as stale
+	assert.Equal(t, 0, inventory.StaleTagCount, "Tags without pull data should not be stale")
+	assert.Equal(t, []string{"1.0.0", "1.1.0"}, inventory.UnknownTags, "Tags without pull data should be unknown")
+	assert.Equal(t, "0 (2 unknown)", staleCell(inventory.StaleTagCount, len(inventory.UnknownTags)), "Table should show the unknown tags")
+}
+
+func TestInventoryCSV(t *testing.T) {
+	// Arrange: A report with a repository
+	report := docker.InventoryReport{Repositories: []docker.RepositoryInventory{{Repository: "org/api", TagCount: 2, TotalSize: 300, StaleTagCount: 1}}}
+
+	// Act: Write the csv
+	out, err := inventoryCSV(report)
+
+	// Assert: Header and a line for the repository
+	assert.NoError(t, err, "csv should be written")
+	assert.Equal(t, "repository,tag_count,total_size,oldest_push,newest_push,stale_tag_count,unknown_stale_tag_count,error\norg/api,2,300,,,1,0,\n", string(out), "csv should match")
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// Promote copies the image from (repo:tag or repo@digest) to (repo:tag) on the
+// registry side, multi-arch indexes included, without pulling the layers locally
+func Promote(registryURL string, from string, to string) ([]byte, error) {
+	fromRepo, fromRef, err := parseImageRef(from, true)
+	if err != nil {
+		return helpers.HandleControllerApi(false, "400", err.Error(), "Promote", struct{}{}, err)
+	}
+	toRepo, toTag, err := parseImageRef(to, false)
+	if err != nil {
+		return helpers.HandleControllerApi(false, "400", err.Error(), "Promote", struct{}{}, err)
+	}
+
+	// Get the manifest store (credentials included)
+	store, err := openManifestStore(registryURL)
+	if err != nil {
+		errorAuthFailed := fmt.Sprintf("Docker auth error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			"500",
+			errorAuthFailed,
+			"Promote",
+			struct{}{},
+			err,
+		)
+	}
+
+	result, err := be.CopyImage(store, fromRepo, fromRef, toRepo, toTag)
+	if err != nil {
+		errorMessage := fmt.Sprintf("Docker Promote error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			"500",
+			errorMessage,
+			"Promote",
+			struct{}{},
+			err,
+		)
+	}
+
+	return helpers.HandleControllerApi(
+		true,
+		"200",
+		"Docker image successfully promoted",
+		"Promote",
+		result,
+		nil,
+	)
+}
+
+// Bump computes the next semver tag of the repository incrementing the highest
+// release tag at the given level. When sourceTag is set the image of sourceTag
+// is also tagged with the next version.
+func Bump(registryURL string, repoPath string, imagesForPage string, level string, sourceTag string) ([]byte, error) {
+	// Get the registry (login included)
+	registry, err := openRegistry(registryURL)
+	if err != nil {
+		errorAuthFailed := fmt.Sprintf("Docker auth error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			"500",
+			errorAuthFailed,
+			"Bump",
+			struct{}{},
+			err,
+		)
+	}
+
+	// Get the tag list from BE layer
+	tags, statusCode, err := registry.ListTags(repoPath, imagesForPage)
+	if err != nil {
+		errorMessage := fmt.Sprintf("Docker GetImages error: %s", err.Error())
+		return helpers.HandleControllerApi(
+			false,
+			strconv.Itoa(statusCode),
+			errorMessage,
+			"Bump",
+			struct{}{},
+			err,
+		)
+	}
+
+	result, err := nextRelease(repoPath, tags.TagList, level)
+	if err != nil {
+		return helpers.HandleControllerApi(false, "400", err.Error(), "Bump", struct{}{}, err)
+	}
+
+	// Tag the source image with the next version
+	if sourceTag != "" {
+		store, err := openManifestStore(registryURL)
+		if err != nil {
+			errorAuthFailed := fmt.Sprintf("Docker auth error: %s", err.Error())
+			return helpers.HandleControllerApi(false, "500", errorAuthFailed, "Bump", struct{}{}, err)
+		}
+		promoted, err := be.CopyImage(store, repoPath, sourceTag, repoPath, result.Next)
+		if err != nil {
+			errorMessage := fmt.Sprintf("Docker Bump error: %s", err.Error())
+			return helpers.HandleControllerApi(false, "500", errorMessage, "Bump", struct{}{}, err)
+		}
+		result.Promoted = &promoted
+	}
+
+	return helpers.HandleControllerApi(
+		true,
+		"200",
+		"Docker next tag successfully computed",
+		"Bump",
+		result,
+		nil,
+	)
+}
+
+// nextRelease returns the highest release tag and the next one at the given level
+func nextRelease(repoPath string, tags []docker.TagInfoInternal, level string) (docker.BumpResult, error) {
+	current, err := HighestSemverTag(tags)
+	if err != nil {
+		return docker.BumpResult{}, err
+	}
+	next, err := NextSemverTag(current, level)
+	if err != nil {
+		return docker.BumpResult{}, err
+	}
+	return docker.BumpResult{
+		Repository: repoPath,
+		Level:      level,
+		Current:    current,
+		Next:       next,
+	}, nil
+}
+
+// parseImageRef splits repo:tag (or repo@digest when allowDigest) in repository and reference
+func parseImageRef(ref string, allowDigest bool) (string, string, error) {
+	if repo, digest, ok := strings.Cut(ref, "@"); ok {
+		if !allowDigest {
+			return "", "", fmt.Errorf("invalid image '%s', a tag is required", ref)
+		}
+		if repo == "" || !strings.HasPrefix(digest, "sha256:") {
+			return "", "", fmt.Errorf("invalid image '%s', expected repo@sha256:<digest>", ref)
+		}
+		return repo, digest, nil
+	}
+
+	i := strings.LastIndex(ref, ":")
+	if i <= 0 || i == len(ref)-1 || strings.Contains(ref[i+1:], "/") {
+		return "", "", fmt.Errorf("invalid image '%s', expected repo:tag", ref)
+	}
+	return ref[:i], ref[i+1:], nil
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/shared"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// Registry api of Docker Hub (hub.docker.com has no manifest api)
+const dockerHubRegistryURL = "https://registry-1.docker.io"
+
+// openRegistry returns the registry to use for registryURL, logging in when needed.
+// An empty registryURL falls back to DOCKER_REGISTRY_URL and then to Docker Hub.
+// Credentials come from the environment or from the docker config file (docker login).
+func openRegistry(registryURL string) (be.Registry, error) {
+	if registryURL == "" {
+		registryURL = helpers.AppConfig.DOCKER_REGISTRY_URL
+	}
+
+	// Any other registry talks the OCI Distribution api
+	if !isDockerHub(registryURL) {
+		username, password := registryCredentials(registryURL)
+		return be.NewOCIRegistry(registryURL, username, password), nil
+	}
+
+	creds, err := dockerHubCredentials()
+	if err != nil {
+		return nil, err
+	}
+	session, err := shared.NewDockerHubSession(creds.Username, creds.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return be.NewDockerHubRegistry(session), nil
+}
+
+// openManifestStore returns the manifest store of the registry, for Docker Hub
+// the manifests are served by the registry api at registry-1.docker.io
+func openManifestStore(registryURL string) (be.ManifestStore, error) {
+	if registryURL == "" {
+		registryURL = helpers.AppConfig.DOCKER_REGISTRY_URL
+	}
+
+	if !isDockerHub(registryURL) {
+		username, password := registryCredentials(registryURL)
+		return be.NewManifestStore(registryURL, username, password), nil
+	}
+
+	creds, err := dockerHubCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return be.NewManifestStore(dockerHubRegistryURL, creds.Username, creds.Secret), nil
+}
+
+// dockerHubCredentials returns the Docker Hub credentials of the configuration or of docker login
+func dockerHubCredentials() (shared.DockerCredentials, error) {
+	return shared.DockerHubCredentials(helpers.AppConfig.DOCKER_HUB_USER_RWD, helpers.AppConfig.DOCKER_HUB_PWD_RWD)
+}
+
+// registryCredentials returns the credentials of an OCI registry from DOCKER_REGISTRY_USR/PWD
+// or from the docker login credentials, empty (anonymous access) when missing
+func registryCredentials(registryURL string) (string, string) {
+	username := helpers.AppConfig.DOCKER_REGISTRY_USR
+	password := helpers.AppConfig.DOCKER_REGISTRY_PWD
+	if username == "" {
+		if creds, err := shared.DockerConfigCredentials(registryURL); err == nil {
+			username, password = creds.Username, creds.Secret
+		}
+	}
+	return username, password
+}
+
+// isDockerHub returns true if the registry url points to Docker Hub
+func isDockerHub(registryURL string) bool {
+	host := strings.TrimPrefix(strings.TrimPrefix(registryURL, "https://"), "http://")
+	host = strings.TrimSuffix(host, "/")
+	switch host {
+	case "", "hub", "docker.io", "hub.docker.com", "index.docker.io", "registry-1.docker.io":
+		return true
+	}
+	return false
+}
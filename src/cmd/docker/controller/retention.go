@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// Tags that are never deleted, whatever the policy says
+var reservedTags = map[string]bool{
+	"latest":   true,
+	"unstable": true,
+}
+
+// semverTag pairs a tag with its parsed semantic version
+type semverTag struct {
+	tag     docker.TagInfoInternal
+	version *semver.Version
+}
+
+// applyRetentionPolicy evaluates the retention rules against the tag list and
+// returns the tags to delete together with the decision taken for every tag.
+// protected contains the tags that must be kept because they are deployed,
+// mapped to the reason (e.g. "listed in deployed.txt").
+func applyRetentionPolicy(
+	result docker.TagResponseInternal,
+	policy docker.RetentionPolicy,
+	protected map[string]string,
+	now time.Time,
+) (docker.RetentionReport, error) {
+	// Compile the regex rules first, so a wrong policy fails before any decision
+	var keepRegex []*regexp.Regexp
+	for _, expr := range policy.KeepRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return docker.RetentionReport{}, fmt.Errorf("invalid keep regex '%s': %w", expr, err)
+		}
+		keepRegex = append(keepRegex, re)
+	}
+
+	// Split semantic version tags from the others
+	var semverTags []semverTag
+	for _, tag := range result.TagList {
+		if v, err := semver.NewVersion(strings.TrimPrefix(tag.Name, "v")); err == nil {
+			semverTags = append(semverTags, semverTag{tag: tag, version: v})
+		}
+	}
+
+	// Order the semantic version tags in descending order
+	sort.SliceStable(semverTags, func(i, j int) bool {
+		return semverTags[i].version.GreaterThan(semverTags[j].version)
+	})
+
+	// Pre-compute the semver tags kept by the "last N" rules
+	keptByLast := make(map[string]string)
+	for i := 0; i < policy.KeepLast && i < len(semverTags); i++ {
+		keptByLast[semverTags[i].tag.Name] = fmt.Sprintf("one of the %d highest semver tags", policy.KeepLast)
+	}
+	keptByMajor := keepLastPerLine(semverTags, policy.KeepPerMajor, func(v *semver.Version) string {
+		return fmt.Sprintf("%d.x", v.Major())
+	})
+	keptByMinor := keepLastPerLine(semverTags, policy.KeepPerMinor, func(v *semver.Version) string {
+		return fmt.Sprintf("%d.%d.x", v.Major(), v.Minor())
+	})
+
+	isSemver := make(map[string]bool, len(semverTags))
+	for _, st := range semverTags {
+		isSemver[st.tag.Name] = true
+	}
+
+	// Take a decision for every tag, the first matching rule wins
+	report := docker.RetentionReport{
+		TagList:   []docker.TagInfoInternal{},
+		Decisions: make([]docker.TagDecision, 0, len(result.TagList)),
+	}
+	for _, tag := range result.TagList {
+		decision := decideTag(tag, policy, protected, keepRegex, keptByLast, keptByMajor, keptByMinor, isSemver[tag.Name], now)
+		report.Decisions = append(report.Decisions, decision)
+		if decision.Action == "delete" {
+			report.TagList = append(report.TagList, tag)
+		}
+	}
+	report.Count = len(report.TagList)
+
+	return report, nil
+}
+
+// decideTag returns the decision for a single tag
+func decideTag(
+	tag docker.TagInfoInternal,
+	policy docker.RetentionPolicy,
+	protected map[string]string,
+	keepRegex []*regexp.Regexp,
+	keptByLast map[string]string,
+	keptByMajor map[string]string,
+	keptByMinor map[string]string,
+	isSemver bool,
+	now time.Time,
+) docker.TagDecision {
+	keep := func(rule, reason string) docker.TagDecision {
+		return docker.TagDecision{Name: tag.Name, Action: "keep", Rule: rule, Reason: reason}
+	}
+	remove := func(rule, reason string) docker.TagDecision {
+		return docker.TagDecision{Name: tag.Name, Action: "delete", Rule: rule, Reason: reason}
+	}
+
+	if reservedTags[tag.Name] {
+		return keep("reserved", "reserved tag name")
+	}
+	if reason, ok := protected[tag.Name]; ok {
+		return keep("deployed", reason)
+	}
+	for _, re := range keepRegex {
+		if re.MatchString(tag.Name) {
+			return keep("keep-regex", fmt.Sprintf("matches '%s'", re.String()))
+		}
+	}
+	if policy.KeepPulledWithinDays > 0 {
+		if pulled, ok := parseDockerTime(tag.TagLastPulled); ok && now.Sub(pulled) <= days(policy.KeepPulledWithinDays) {
+			return keep("pulled-within", fmt.Sprintf("pulled on %s", tag.TagLastPulled))
+		}
+	}
+	if reason, ok := keptByLast[tag.Name]; ok {
+		return keep("keep-last", reason)
+	}
+	if reason, ok := keptByMajor[tag.Name]; ok {
+		return keep("keep-per-major", reason)
+	}
+	if reason, ok := keptByMinor[tag.Name]; ok {
+		return keep("keep-per-minor", reason)
+	}
+	if isSemver {
+		return remove("semver-surplus", "semver tag not retained by any rule")
+	}
+
+	// Non-semver tags are deleted straight away unless a max age is set
+	if policy.NonSemverMaxAgeDays <= 0 {
+		return remove("non-semver", "not a semver tag")
+	}
+	pushed, ok := parseDockerTime(tag.TagLastPushed)
+	if !ok {
+		pushed, ok = parseDockerTime(tag.LastUpdated)
+	}
+	if !ok {
+		// The OCI registries do not list the push dates: a tag of unknown age is not deleted
+		return keep("non-semver-unknown-age", fmt.Sprintf("non-semver tag with no push date, its age against %d days is unknown", policy.NonSemverMaxAgeDays))
+	}
+	if now.Sub(pushed) <= days(policy.NonSemverMaxAgeDays) {
+		return keep("non-semver-grace", fmt.Sprintf("non-semver tag younger than %d days", policy.NonSemverMaxAgeDays))
+	}
+	return remove("non-semver-expired", fmt.Sprintf("non-semver tag older than %d days", policy.NonSemverMaxAgeDays))
+}
+
+// keepLastPerLine groups the (already sorted) semver tags by the line returned
+// by lineOf and keeps the first n tags of every group
+func keepLastPerLine(tags []semverTag, n int, lineOf func(*semver.Version) string) map[string]string {
+	kept := make(map[string]string)
+	if n <= 0 {
+		return kept
+	}
+
+	perLine := make(map[string]int)
+	for _, st := range tags {
+		line := lineOf(st.version)
+		if perLine[line] < n {
+			perLine[line]++
+			kept[st.tag.Name] = fmt.Sprintf("one of the last %d tags of line %s", n, line)
+		}
+	}
+	return kept
+}
+
+// parseDockerTime parses the timestamps returned by Docker Hub (RFC3339)
+func parseDockerTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// days converts a number of days to a duration
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+func tagsFromNames(names ...string) docker.TagResponseInternal {
+	var tags []docker.TagInfoInternal
+	for _, name := range names {
+		tags = append(tags, docker.TagInfoInternal{Name: name})
+	}
+	return docker.TagResponseInternal{TagList: tags, Count: len(tags)}
+}
+
+func deletedNames(report docker.RetentionReport) []string {
+	var names []string
+	for _, tag := range report.TagList {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+func ruleOf(report docker.RetentionReport, name string) string {
+	for _, decision := range report.Decisions {
+		if decision.Name == name {
+			return decision.Rule
+		}
+	}
+	return ""
+}
+
+func TestApplyRetentionPolicy_KeepLast(t *testing.T) {
+	// Arrange: Same behavior of the old "keep N" filter
+	tags := tagsFromNames("latest", "1.0.0", "1.2.0", "v1.1.0", "feature-x", "unstable")
+
+	// Act: Keep the 2 highest semver tags
+	report, err := applyRetentionPolicy(tags, docker.RetentionPolicy{KeepLast: 2}, nil, time.Now())
+
+	// Assert: Reserved and highest tags are kept, the rest is deleted
+	assert.NoError(t, err, "Policy should be valid")
+	assert.ElementsMatch(t, []string{"1.0.0", "feature-x"}, deletedNames(report), "Deleted tags should match")
+	assert.Equal(t, 2, report.Count, "Count should match the deleted tags")
+	assert.Equal(t, "reserved", ruleOf(report, "latest"), "latest should be reserved")
+	assert.Equal(t, "keep-last", ruleOf(report, "v1.1.0"), "v1.1.0 should be kept by keep-last")
+}
+
+func TestApplyRetentionPolicy_Rules(t *testing.T) {
+	// Arrange: Tags with pull and push dates
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tags := docker.TagResponseInternal{TagList: []docker.TagInfoInternal{
+		{Name: "1.0.0"},
+		{Name: "1.0.1"},
+		{Name: "1.1.0"},
+		{Name: "2.0.0"},
+		{Name: "0.9.0", TagLastPulled: "2025-05-25T10:00:00.000000Z"},
+		{Name: "0.8.0"},
+		{Name: "release-2024"},
+		{Name: "pr-10", TagLastPushed: "2025-05-30T10:00:00Z"},
+		{Name: "pr-9", TagLastPushed: "2025-01-30T10:00:00Z"},
+	}}
+	policy := docker.RetentionPolicy{
+		KeepRegex:            []string{"^release-"},
+		KeepPulledWithinDays: 14,
+		KeepPerMinor:         1,
+		NonSemverMaxAgeDays:  7,
+	}
+	protected := map[string]string{"0.8.0": "listed in deployed.txt"}
+
+	// Act: Evaluate the policy
+	report, err := applyRetentionPolicy(tags, policy, protected, now)
+
+	// Assert: Every rule has been applied
+	assert.NoError(t, err, "Policy should be valid")
+	assert.ElementsMatch(t, []string{"1.0.0", "pr-9"}, deletedNames(report), "Deleted tags should match")
+	assert.Equal(t, "deployed", ruleOf(report, "0.8.0"), "0.8.0 should be protected as deployed")
+	assert.Equal(t, "keep-regex", ruleOf(report, "release-2024"), "release-2024 should match the regex")
+	assert.Equal(t, "pulled-within", ruleOf(report, "0.9.0"), "0.9.0 should be kept because recently pulled")
+	assert.Equal(t, "keep-per-minor", ruleOf(report, "1.0.1"), "1.0.1 should be the last of line 1.0")
+	assert.Equal(t, "non-semver-grace", ruleOf(report, "pr-10"), "pr-10 should be younger than the max age")
+	assert.Equal(t, "non-semver-expired", ruleOf(report, "pr-9"), "pr-9 should be older than the max age")
+}
+
+func TestApplyRetentionPolicy_NonSemverUnknownAge(t *testing.T) {
+	// Arrange: Tags of an OCI registry, listed without dates
+	tags := tagsFromNames("pr-10", "feature-x")
+
+	// Act: Evaluate a policy with a max age of the non-semver tags
+	report, err := applyRetentionPolicy(tags, docker.RetentionPolicy{NonSemverMaxAgeDays: 7}, nil, time.Now())
+
+	// Assert: The tags of unknown age are kept
+	assert.NoError(t, err, "Policy should be valid")
+	assert.Empty(t, deletedNames(report), "Tags without a push date should not be deleted")
+	assert.Equal(t, "non-semver-unknown-age", ruleOf(report, "pr-10"), "pr-10 should be kept for its unknown age")
+}
+
+func TestApplyRetentionPolicy_InvalidRegex(t *testing.T) {
+	// Act: Evaluate a policy with a broken regex
+	_, err := applyRetentionPolicy(tagsFromNames("1.0.0"), docker.RetentionPolicy{KeepRegex: []string{"("}}, nil, time.Now())
+
+	// Assert: The policy is rejected
+	assert.Error(t, err, "Invalid regex should return an error")
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// Release tags, e.g. 1.2.3 or v1.2.3 (pre-releases are not releases)
+var releaseTagRe = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)$`)
+
+// HighestSemverTag returns the highest release tag (x.y.z or vx.y.z) as it is
+// named in the registry, the other tags are skipped
+func HighestSemverTag(tags []docker.TagInfoInternal) (string, error) {
+	var versions []*semver.Version
+	tagToOriginal := make(map[string]string)
+
+	for _, tag := range tags {
+		tagName := strings.TrimSpace(tag.Name)
+
+		if !releaseTagRe.MatchString(tagName) {
+			slog.Debug("Skipping non-matching tag", "tag", tagName)
+			continue
+		}
+
+		matches := releaseTagRe.FindStringSubmatch(tagName)
+		if len(matches) != 2 {
+			slog.Debug("Skipping malformed tag", "tag", tagName)
+			continue
+		}
+
+		normalized := matches[1] // e.g., 0.1.0
+		v, err := semver.NewVersion(normalized)
+		if err != nil {
+			slog.Debug("Skipping invalid semver tag", "tag", tagName, "error", err)
+			continue
+		}
+
+		versions = append(versions, v)
+		tagToOriginal[v.String()] = tagName
+	}
+
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no valid semver tags found")
+	}
+
+	sort.Sort(semver.Collection(versions))
+	highest := versions[len(versions)-1]
+
+	return tagToOriginal[highest.String()], nil
+}
+
+// NextSemverTag increments the tag at the given level (major, minor or patch),
+// the "v" prefix of the tag is kept
+func NextSemverTag(tag string, level string) (string, error) {
+	v, err := semver.NewVersion(strings.TrimPrefix(tag, "v"))
+	if err != nil {
+		return "", fmt.Errorf("invalid semver tag '%s': %v", tag, err)
+	}
+
+	var next semver.Version
+	switch level {
+	case "major":
+		next = v.IncMajor()
+	case "minor":
+		next = v.IncMinor()
+	case "patch":
+		next = v.IncPatch()
+	default:
+		return "", fmt.Errorf("invalid level '%s' (major, minor, patch)", level)
+	}
+
+	if strings.HasPrefix(tag, "v") {
+		return "v" + next.String(), nil
+	}
+	return next.String(), nil
+}
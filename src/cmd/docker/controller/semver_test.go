@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighestSemverTag(t *testing.T) {
+	// Arrange: Release, pre-release and non-semver tags
+	tags := tagsFromNames("latest", "1.2.0", "v1.10.0", "1.11.0-rc1", "feature-x").TagList
+
+	// Act: Take the highest release
+	highest, err := HighestSemverTag(tags)
+	_, noneErr := HighestSemverTag(tagsFromNames("latest").TagList)
+
+	// Assert: The tag is returned as it is named
+	assert.NoError(t, err, "A release tag should be found")
+	assert.Equal(t, "v1.10.0", highest, "Highest release should match")
+	assert.Error(t, noneErr, "No release tags should return an error")
+}
+
+func TestNextSemverTag(t *testing.T) {
+	// Act & Assert: Every level increments and resets the lower ones
+	for _, tc := range []struct{ tag, level, next string }{
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"v1.2.3", "major", "v2.0.0"},
+	} {
+		next, err := NextSemverTag(tc.tag, tc.level)
+		assert.NoError(t, err, "Bump should not return an error")
+		assert.Equal(t, tc.next, next, "Next tag of %s at %s should match", tc.tag, tc.level)
+	}
+	_, err := NextSemverTag("1.2.3", "build")
+	assert.Error(t, err, "Unknown level should return an error")
+}
+
+func TestParseImageRef(t *testing.T) {
+	// Act: Parse tags and digests
+	repo, tag, err := parseImageRef("org/app-staging:1.2.0", false)
+	digestRepo, digest, digestErr := parseImageRef("org/app@sha256:abc", true)
+	_, _, toDigestErr := parseImageRef("org/app@sha256:abc", false)
+	_, _, noTagErr := parseImageRef("org/app", true)
+
+	// Assert: Repository and reference are split
+	assert.NoError(t, err, "repo:tag should be valid")
+	assert.Equal(t, "org/app-staging", repo, "Repository should match")
+	assert.Equal(t, "1.2.0", tag, "Tag should match")
+	assert.NoError(t, digestErr, "repo@digest should be valid as source")
+	assert.Equal(t, "org/app", digestRepo, "Repository should match")
+	assert.Equal(t, "sha256:abc", digest, "Digest should match")
+	assert.Error(t, toDigestErr, "A digest should not be a target")
+	assert.Error(t, noTagErr, "A reference is required")
+}
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"crypto"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/shared"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// Findings listed in the reason of a refused tag
+const maxFindingsInReason = 5
+
+// reportLoader returns the vulnerability report of a tag
+type reportLoader func(tag string) (docker.VulnerabilityReport, error)
+
+// LatestAcceptableTag returns the highest release tag that passes the vulnerability gate.
+// A tag with findings at or above the threshold (or without a report) is refused and
+// the previous version is tried, up to gate.MaxFallback versions. The decisions
+// explain why every tried tag was accepted or refused.
+func LatestAcceptableTag(repoPath string, tags []docker.TagInfoInternal, gate docker.VulnerabilityGate) (string, []docker.GateDecision, error) {
+	if err := shared.ValidSeverity(gate.Threshold); err != nil {
+		return "", nil, err
+	}
+	load, err := newReportLoader(repoPath, gate)
+	if err != nil {
+		return "", nil, err
+	}
+	return evaluateGate(releaseTagsDesc(tags), gate, load)
+}
+
+// evaluateGate tries the candidates in order and returns the first acceptable one
+func evaluateGate(candidates []string, gate docker.VulnerabilityGate, load reportLoader) (string, []docker.GateDecision, error) {
+	decisions := []docker.GateDecision{}
+	if len(candidates) == 0 {
+		return "", decisions, fmt.Errorf("no valid semver tags found")
+	}
+
+	for i, tag := range candidates {
+		// The first candidate is not a fallback
+		if gate.MaxFallback > 0 && i > gate.MaxFallback {
+			break
+		}
+
+		report, err := load(tag)
+		if err != nil {
+			decisions = append(decisions, docker.GateDecision{Tag: tag, Reason: err.Error()})
+			continue
+		}
+
+		findings := shared.FindingsAtOrAbove(report, gate.Threshold)
+		if len(findings) > 0 {
+			decisions = append(decisions, docker.GateDecision{Tag: tag, Reason: findingsReason(findings, gate.Threshold)})
+			continue
+		}
+
+		source := report.Scanner
+		if report.Unverified {
+			source += ", unverified attestation"
+		}
+		decisions = append(decisions, docker.GateDecision{
+			Tag:      tag,
+			Accepted: true,
+			Reason:   fmt.Sprintf("no findings at or above %s (%s)", strings.ToUpper(gate.Threshold), source),
+		})
+		return tag, decisions, nil
+	}
+
+	return "", decisions, fmt.Errorf("no release tag passes the vulnerability gate (threshold %s)", strings.ToUpper(gate.Threshold))
+}
+
+// newReportLoader reads the reports from the files stored alongside the
+// tags or from the attestations attached in the registry
+func newReportLoader(repoPath string, gate docker.VulnerabilityGate) (reportLoader, error) {
+	switch gate.Reports {
+	case "":
+		return nil, fmt.Errorf("vulnerability gate needs the reports (file template or 'attestation')")
+	case "attestation":
+		// The attestations gate only when signed by the key, unless explicitly allowed unverified
+		var key crypto.PublicKey
+		if gate.AttestationKey != "" {
+			loaded, err := shared.LoadAttestationKey(gate.AttestationKey)
+			if err != nil {
+				return nil, err
+			}
+			key = loaded
+		} else if !gate.AllowUnverified {
+			return nil, fmt.Errorf("vulnerability gate on attestations needs the public key verifying their signature (ARGOCD_ENV_VULN_ATTESTATION_KEY), or ARGOCD_ENV_VULN_ALLOW_UNVERIFIED=true")
+		}
+		store, err := openManifestStore(gate.RegistryURL)
+		if err != nil {
+			return nil, err
+		}
+		return func(tag string) (docker.VulnerabilityReport, error) {
+			digest, envelopes, err := be.Attestations(store, repoPath, tag)
+			if err != nil {
+				return docker.VulnerabilityReport{}, err
+			}
+			return reportFromAttestations(tag, digest, envelopes, key)
+		}, nil
+	}
+	return func(tag string) (docker.VulnerabilityReport, error) {
+		return shared.LoadVulnerabilityReport(gate.Reports, repoPath, tag)
+	}, nil
+}
+
+// reportFromAttestations returns the report of the newest vulnerability attestation of the
+// image digest signed by the key, with a nil key the attestations are not verified. An
+// attestation of another image (copied next to the tag) is refused.
+func reportFromAttestations(tag string, digest string, envelopes [][]byte, key crypto.PublicKey) (docker.VulnerabilityReport, error) {
+	var newest *shared.VulnerabilityAttestation
+	unsigned, otherImage := false, false
+	for _, envelope := range envelopes {
+		attestation, err := shared.ParseVulnerabilityAttestation(envelope)
+		if err != nil {
+			continue
+		}
+		if !attestation.Attests(digest) {
+			otherImage = true
+			continue
+		}
+		if key != nil {
+			if err := shared.VerifyAttestation(envelope, key); err != nil {
+				unsigned = true
+				continue
+			}
+		}
+		if newest == nil || attestation.ScannedAt.After(newest.ScannedAt) {
+			newest = &attestation
+		}
+	}
+
+	switch {
+	case newest != nil && key == nil:
+		slog.Warn("Vulnerability attestation accepted without verifying its signature", "tag", tag)
+		newest.Report.Unverified = true
+		return newest.Report, nil
+	case newest != nil:
+		return newest.Report, nil
+	case unsigned:
+		return docker.VulnerabilityReport{}, fmt.Errorf("no vulnerability attestation of %s is signed by the attestation key", tag)
+	case otherImage:
+		return docker.VulnerabilityReport{}, fmt.Errorf("no vulnerability attestation attached to %s is about its image %s", tag, digest)
+	}
+	return docker.VulnerabilityReport{}, fmt.Errorf("no vulnerability attestation attached to %s", tag)
+}
+
+// releaseTagsDesc returns the release tags from the highest to the lowest
+func releaseTagsDesc(tags []docker.TagInfoInternal) []string {
+	type release struct {
+		name    string
+		version *semver.Version
+	}
+	var releases []release
+	for _, tag := range tags {
+		matches := releaseTagRe.FindStringSubmatch(strings.TrimSpace(tag.Name))
+		if len(matches) != 2 {
+			continue
+		}
+		if v, err := semver.NewVersion(matches[1]); err == nil {
+			releases = append(releases, release{name: strings.TrimSpace(tag.Name), version: v})
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].version.GreaterThan(releases[j].version)
+	})
+
+	names := make([]string, 0, len(releases))
+	for _, r := range releases {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// findingsReason summarizes the findings refusing a tag
+func findingsReason(findings []docker.Vulnerability, threshold string) string {
+	var ids []string
+	for i, finding := range findings {
+		if i == maxFindingsInReason {
+			ids = append(ids, "...")
+			break
+		}
+		ids = append(ids, fmt.Sprintf("%s %s (%s)", finding.Severity, finding.ID, finding.Package))
+	}
+	return fmt.Sprintf("%d findings at or above %s: %s", len(findings), strings.ToUpper(threshold), strings.Join(ids, ", "))
+}
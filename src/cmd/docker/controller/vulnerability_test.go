@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// fakeReports returns the severities of the findings of every tag
+func fakeReports(severities map[string][]string) reportLoader {
+	return func(tag string) (docker.VulnerabilityReport, error) {
+		list, ok := severities[tag]
+		if !ok {
+			return docker.VulnerabilityReport{}, errors.New("no vulnerability report")
+		}
+		report := docker.VulnerabilityReport{Scanner: "trivy"}
+		for _, severity := range list {
+			report.Vulnerabilities = append(report.Vulnerabilities, docker.Vulnerability{ID: "CVE-" + tag, Severity: severity})
+		}
+		return report, nil
+	}
+}
+
+func TestEvaluateGate_FallbackToPreviousVersion(t *testing.T) {
+	// Arrange: The highest release has a critical finding, the previous one has no report
+	candidates := releaseTagsDesc(tagsFromNames("latest", "1.0.0", "1.1.0", "1.2.0", "1.3.0-rc1").TagList)
+	load := fakeReports(map[string][]string{
+		"1.2.0": {"CRITICAL", "LOW"},
+		"1.0.0": {"HIGH"},
+	})
+
+	// Act: Gate on CRITICAL
+	tag, decisions, err := evaluateGate(candidates, docker.VulnerabilityGate{Threshold: "CRITICAL"}, load)
+
+	// Assert: The first acceptable previous version is taken
+	assert.NoError(t, err, "An acceptable tag should be found")
+	assert.Equal(t, []string{"1.2.0", "1.1.0", "1.0.0"}, candidates, "Only releases, highest first")
+	assert.Equal(t, "1.0.0", tag, "Tag should fall back to 1.0.0")
+	assert.Len(t, decisions, 3, "Every tried tag should have a decision")
+	assert.False(t, decisions[0].Accepted, "1.2.0 should be refused")
+	assert.Contains(t, decisions[0].Reason, "CRITICAL CVE-1.2.0", "Reason should list the finding")
+	assert.True(t, decisions[2].Accepted, "1.0.0 should be accepted")
+}
+
+func TestEvaluateGate_MaxFallback(t *testing.T) {
+	// Arrange: Every release has a critical finding
+	candidates := []string{"1.2.0", "1.1.0", "1.0.0"}
+	load := fakeReports(map[string][]string{"1.2.0": {"CRITICAL"}, "1.1.0": {"CRITICAL"}, "1.0.0": {}})
+
+	// Act: Allow a single fallback
+	_, decisions, err := evaluateGate(candidates, docker.VulnerabilityGate{Threshold: "HIGH", MaxFallback: 1}, load)
+
+	// Assert: The gate gives up after the fallback
+	assert.Error(t, err, "No tag should pass the gate")
+	assert.Len(t, decisions, 2, "Only the highest and a fallback should be tried")
+}
+
+func TestNewReportLoader_AttestationNeedsKey(t *testing.T) {
+	// Act: Gate on the attestations without a key
+	_, err := newReportLoader("org/app", docker.VulnerabilityGate{Threshold: "HIGH", Reports: "attestation"})
+
+	// Assert: Unverified attestations must be allowed explicitly
+	assert.ErrorContains(t, err, "ARGOCD_ENV_VULN_ALLOW_UNVERIFIED", "Gate should require the key or the opt-in")
+}
+
+// signedAttestation returns a vuln attestation of the digest, with the severities of its findings
+// and the scan time, signed (DSSE PAE) with the key
+func signedAttestation(t *testing.T, key *ecdsa.PrivateKey, digest string, scannedAt string, severities ...string) []byte {
+	var vulns []string
+	for i, severity := range severities {
+		vulns = append(vulns, fmt.Sprintf(`{"VulnerabilityID":"CVE-%d","PkgName":"pkg","Severity":"%s"}`, i, severity))
+	}
+	payload := fmt.Sprintf(`{"predicateType":"https://cosign.sigstore.dev/attestation/vuln/v1","subject":[{"name":"org/app","digest":{"sha256":"%s"}}],`+
+		`"predicate":{"scanner":{"result":{"SchemaVersion":2,"Results":[{"Vulnerabilities":[%s]}]}},"metadata":{"scanFinishedOn":"%s"}}}`,
+		strings.TrimPrefix(digest, "sha256:"), strings.Join(vulns, ","), scannedAt)
+	payloadType := "application/vnd.in-toto+json"
+	pae := sha256.Sum256([]byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, pae[:])
+	assert.NoError(t, err)
+	return []byte(fmt.Sprintf(`{"payloadType":"%s","payload":"%s","signatures":[{"sig":"%s"}]}`,
+		payloadType, base64.StdEncoding.EncodeToString([]byte(payload)), base64.StdEncoding.EncodeToString(sig)))
+}
+
+func TestReportFromAttestations_OtherImage(t *testing.T) {
+	// Arrange: A clean attestation of image A, correctly signed
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	envelopes := [][]byte{signedAttestation(t, key, "sha256:aaaa", "2024-06-01T10:00:00Z")}
+
+	// Act: Gate the tag of image B with it
+	_, otherErr := reportFromAttestations("1.2.0", "sha256:bbbb", envelopes, &key.PublicKey)
+	_, sameErr := reportFromAttestations("1.1.0", "sha256:aaaa", envelopes, &key.PublicKey)
+
+	// Assert: The attestation only gates its own image
+	assert.ErrorContains(t, otherErr, "sha256:bbbb", "Attestation of another image should be refused")
+	assert.NoError(t, sameErr, "Attestation of the image should be accepted")
+}
+
+func TestReportFromAttestations_Newest(t *testing.T) {
+	// Arrange: An old scan without findings and a newer one with a critical finding, in any order
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	oldScan := signedAttestation(t, key, "sha256:aaaa", "2024-01-01T10:00:00Z")
+	newScan := signedAttestation(t, key, "sha256:aaaa", "2024-06-01T10:00:00Z", "CRITICAL")
+
+	// Act: Read the report with both orders of the layers
+	first, err1 := reportFromAttestations("1.2.0", "sha256:aaaa", [][]byte{oldScan, newScan}, &key.PublicKey)
+	second, err2 := reportFromAttestations("1.2.0", "sha256:aaaa", [][]byte{newScan, oldScan}, &key.PublicKey)
+
+	// Assert: The newest scan is used
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.Len(t, first.Vulnerabilities, 1, "Newest scan should be used")
+	assert.Len(t, second.Vulnerabilities, 1, "Newest scan should be used whatever the order")
+}
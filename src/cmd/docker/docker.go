@@ -2,13 +2,15 @@ package docker
 
 import (
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/sub"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/spf13/cobra"
 )
 
 var DockerCmd = &cobra.Command{
-	Use:   "docker",
-	Short: "Docker commands",
-	Long:  "Docker commands to manage registries repos, images, containers and other docker resources",
+	Use:         "docker",
+	Short:       "Docker commands",
+	Long:        "Docker commands to manage registries repos, images, containers and other docker resources",
+	Annotations: map[string]string{helpers.AnnotationCredentials: "docker-hub,docker-registry"},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -17,4 +19,8 @@ var DockerCmd = &cobra.Command{
 func init() {
 	DockerCmd.AddCommand(sub.GetImagesDockerCmd)
 	DockerCmd.AddCommand(sub.DeleteImagesDockerCmd)
+	DockerCmd.AddCommand(sub.InventoryDockerCmd)
+	DockerCmd.AddCommand(sub.CheckPlatformsDockerCmd)
+	DockerCmd.AddCommand(sub.PromoteDockerCmd)
+	DockerCmd.AddCommand(sub.BumpDockerCmd)
 }
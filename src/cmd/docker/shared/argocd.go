@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/argocd/be"
+)
+
+// LoadArgoCDDeployedTags logs into ArgoCD and returns the tags (or digests)
+// of repoPath referenced by the status summary of the applications,
+// mapped to the reason "protected: in use by <app>"
+func LoadArgoCDDeployedTags(argocdUrl string, argocdUsername string, argocdPassword string, repoPath string) (map[string]string, error) {
+	if argocdUrl == "" {
+		return nil, fmt.Errorf("ARGOCD_URL is required to protect the tags deployed in ArgoCD")
+	}
+
+	// Login to ArgoCD and init client
+	if err := be.InitArgoClientWithLogin(
+		"https://"+argocdUrl,
+		argocdUsername,
+		argocdPassword,
+	); err != nil {
+		return nil, fmt.Errorf("failed to authenticate to ArgoCD: %v", err)
+	}
+
+	// Get the images used by the applications
+	images, err := be.GetApplicationImages()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep only the images of the repository
+	apps := make(map[string][]string)
+	for image, appNames := range images {
+		if ref, ok := ImageRefForRepo(image, repoPath); ok {
+			apps[ref] = append(apps[ref], appNames...)
+		}
+	}
+
+	deployed := make(map[string]string, len(apps))
+	for ref, appNames := range apps {
+		sort.Strings(appNames)
+		deployed[ref] = "protected: in use by " + strings.Join(appNames, ", ")
+	}
+
+	return deployed, nil
+}
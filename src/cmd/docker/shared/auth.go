@@ -1,12 +1,30 @@
 package shared
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 )
 
+var (
+	dockerHubURL = "https://hub.docker.com"
+	// Endpoint exchanging the refresh token for a new token
+	dockerHubRefreshPath = "/v2/auth/refresh"
+	// Tokens expiring within this margin are renewed before use
+	tokenExpiryMargin = time.Minute
+)
+
+// Sessions opened in this run, the token is reused across calls
+var (
+	sessionsMu sync.Mutex
+	sessions   = map[string]*DockerHubSession{}
+)
+
 type DockerHubLoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -17,24 +35,191 @@ type DockerHubLoginResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// DockerHubTokenRequest is the login with a personal (or organization) access token
+type DockerHubTokenRequest struct {
+	Identifier string `json:"identifier"`
+	Secret     string `json:"secret"`
+}
+
+type DockerHubTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+type DockerHubRefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// DockerHubSession keeps the Docker Hub token of a user. The token is renewed
+// when it expires: with the refresh token when there is one, logging in again otherwise.
+// It implements be.TokenSource.
+type DockerHubSession struct {
+	mu           sync.Mutex
+	username     string
+	secret       string // Password or access token
+	token        string
+	refreshToken string
+	expiresAt    time.Time // Zero when the token does not carry an expiration
+}
+
+// NewDockerHubSession logs into Docker Hub, the session of the same
+// credentials is reused when it was already opened in this run.
+// The secret can be the password or a personal access token (dckr_pat_...).
+func NewDockerHubSession(username, secret string) (*DockerHubSession, error) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	key := username + ":" + secret
+	if session, ok := sessions[key]; ok {
+		return session, nil
+	}
+
+	session := &DockerHubSession{username: username, secret: secret}
+	if err := session.login(); err != nil {
+		return nil, err
+	}
+	sessions[key] = session
+	return session, nil
+}
+
 // LoginToDockerHub logs into Docker Hub and returns the auth token and refresh token.
 func LoginToDockerHub(username, password string) (string, string, error) {
-	client := helpers.NewApiClient("https://hub.docker.com", "", "None")
+	session, err := NewDockerHubSession(username, password)
+	if err != nil {
+		return "", "", err
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.token, session.refreshToken, nil
+}
+
+// Token returns the current token, renewed when it is expired
+func (s *DockerHubSession) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expiresAt.IsZero() || time.Until(s.expiresAt) > tokenExpiryMargin) {
+		return s.token, nil
+	}
+	if err := s.renew(); err != nil {
+		return "", err
+	}
+	return s.token, nil
+}
+
+// Refresh renews the token (e.g. after a 401) and returns the new one
+func (s *DockerHubSession) Refresh() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.renew(); err != nil {
+		return "", err
+	}
+	return s.token, nil
+}
+
+// renew uses the refresh token and falls back to a new login
+func (s *DockerHubSession) renew() error {
+	if s.refreshToken != "" {
+		if err := s.refresh(); err == nil {
+			return nil
+		}
+	}
+	return s.login()
+}
+
+// login logs in with the password or the access token
+func (s *DockerHubSession) login() error {
+	client := helpers.NewApiClient(dockerHubURL, "", "None")
+
+	// Access tokens have their own endpoint and no refresh token
+	if isAccessToken(s.secret) {
+		res := client.Request("POST", "/v2/auth/token", DockerHubTokenRequest{
+			Identifier: s.username,
+			Secret:     s.secret,
+		})
+		if !res.Response {
+			return fmt.Errorf("login failed: %s", res.Message)
+		}
+
+		var tokenResp DockerHubTokenResponse
+		if err := json.Unmarshal(res.Body, &tokenResp); err != nil {
+			return fmt.Errorf("failed to parse login response: %v", err)
+		}
+		s.setToken(tokenResp.AccessToken, "")
+		return nil
+	}
+
 	payload := DockerHubLoginRequest{
-		Username: username,
-		Password: password,
+		Username: s.username,
+		Password: s.secret,
 	}
 
 	res := client.Request("POST", "/v2/users/login", payload)
 	if !res.Response {
-		return "", "", fmt.Errorf("login failed: %s", res.Message)
+		return fmt.Errorf("login failed: %s", res.Message)
 	}
 
 	var loginResp DockerHubLoginResponse
 	err := json.Unmarshal(res.Body, &loginResp)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to parse login response: %v", err)
+		return fmt.Errorf("failed to parse login response: %v", err)
 	}
+	s.setToken(loginResp.Token, loginResp.RefreshToken)
+	return nil
+}
 
-	return loginResp.Token, loginResp.RefreshToken, nil
+// refresh exchanges the refresh token for a new token
+func (s *DockerHubSession) refresh() error {
+	client := helpers.NewApiClient(dockerHubURL, "", "None")
+	res := client.Request("POST", dockerHubRefreshPath, DockerHubRefreshRequest{RefreshToken: s.refreshToken})
+	if !res.Response {
+		return fmt.Errorf("token refresh failed: %s", res.Message)
+	}
+
+	var loginResp DockerHubLoginResponse
+	if err := json.Unmarshal(res.Body, &loginResp); err != nil {
+		return fmt.Errorf("failed to parse refresh response: %v", err)
+	}
+	if loginResp.Token == "" {
+		return fmt.Errorf("token refresh returned an empty token")
+	}
+
+	refreshToken := loginResp.RefreshToken
+	if refreshToken == "" {
+		refreshToken = s.refreshToken
+	}
+	s.setToken(loginResp.Token, refreshToken)
+	return nil
+}
+
+// setToken stores the token with its expiration
+func (s *DockerHubSession) setToken(token string, refreshToken string) {
+	s.token = token
+	s.refreshToken = refreshToken
+	s.expiresAt = tokenExpiry(token)
+}
+
+// isAccessToken returns true for Docker Hub personal and organization access tokens
+func isAccessToken(secret string) bool {
+	return strings.HasPrefix(secret, "dckr_pat_") || strings.HasPrefix(secret, "dckr_oat_")
+}
+
+// tokenExpiry reads the exp claim of a JWT without verifying it,
+// it returns the zero time when the token is not a JWT
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
 }
@@ -0,0 +1,113 @@
+package shared
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// jwtWithExpiry returns an unsigned JWT expiring at exp
+func jwtWithExpiry(exp time.Time) string {
+	payload, _ := json.Marshal(map[string]int64{"exp": exp.Unix()})
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+// newHubServer starts a fake Docker Hub auth api counting the calls per endpoint
+func newHubServer(t *testing.T, loginToken string) map[string]*int32 {
+	calls := map[string]*int32{"login": new(int32), "token": new(int32), "refresh": new(int32)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/users/login", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls["login"], 1)
+		fmt.Fprintf(w, `{"token":"%s","refresh_token":"refresh"}`, loginToken)
+	})
+	mux.HandleFunc("/v2/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls["token"], 1)
+		fmt.Fprint(w, `{"access_token":"pat-token"}`)
+	})
+	mux.HandleFunc(dockerHubRefreshPath, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls["refresh"], 1)
+		fmt.Fprint(w, `{"token":"refreshed"}`)
+	})
+	server := httptest.NewServer(mux)
+
+	oldURL := dockerHubURL
+	dockerHubURL = server.URL
+	t.Cleanup(func() {
+		server.Close()
+		dockerHubURL = oldURL
+		sessions = map[string]*DockerHubSession{}
+	})
+	return calls
+}
+
+func TestDockerHubSession_ReuseAndRefresh(t *testing.T) {
+	// Arrange: Login returns an already expired token
+	calls := newHubServer(t, jwtWithExpiry(time.Now().Add(-time.Hour)))
+
+	// Act: Open the session twice and ask the token
+	first, err := NewDockerHubSession("user", "password")
+	second, _ := NewDockerHubSession("user", "password")
+	token, tokenErr := first.Token()
+
+	// Assert: One login, the expired token is refreshed
+	assert.NoError(t, err, "Login should not return an error")
+	assert.Same(t, first, second, "Session should be reused in the same run")
+	assert.NoError(t, tokenErr, "Token should not return an error")
+	assert.Equal(t, "refreshed", token, "Expired token should be refreshed")
+	assert.Equal(t, int32(1), *calls["login"], "Login should be done once")
+	assert.Equal(t, int32(1), *calls["refresh"], "Refresh token should be used")
+}
+
+func TestDockerHubSession_AccessToken(t *testing.T) {
+	// Arrange: A valid token for the password login
+	calls := newHubServer(t, jwtWithExpiry(time.Now().Add(time.Hour)))
+
+	// Act: Login with a personal access token
+	session, err := NewDockerHubSession("user", "dckr_pat_secret")
+	token, _ := session.Token()
+
+	// Assert: The access token endpoint is used
+	assert.NoError(t, err, "Login should not return an error")
+	assert.Equal(t, "pat-token", token, "Token should come from the access token login")
+	assert.Equal(t, int32(1), *calls["token"], "Access token endpoint should be used")
+	assert.Equal(t, int32(0), *calls["login"], "Password login should not be used")
+}
+
+func TestDockerConfigCredentials(t *testing.T) {
+	// Arrange: A docker config with a plain entry for Docker Hub and a helper for ghcr.io
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	config := `{
+		"auths": {"https://index.docker.io/v1/": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass")) + `"}, "ghcr.io": {}},
+		"credsStore": "desktop"
+	}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600))
+
+	oldHelper := credentialHelper
+	credentialHelper = func(helper string, serverURL string) ([]byte, error) {
+		return []byte(fmt.Sprintf(`{"Username":"%s","Secret":"%s-secret"}`, helper, serverURL)), nil
+	}
+	t.Cleanup(func() { credentialHelper = oldHelper })
+
+	// Act: Read the credentials of both registries
+	hub, hubErr := DockerConfigCredentials("docker.io")
+	ghcr, ghcrErr := DockerConfigCredentials("https://ghcr.io")
+	_, missingErr := DockerConfigCredentials("quay.io")
+
+	// Assert: Plain entries are decoded, the others are asked to the credentials store
+	assert.NoError(t, hubErr, "Docker Hub credentials should be found")
+	assert.Equal(t, DockerCredentials{Username: "hubuser", Secret: "hubpass", Source: filepath.Join(dir, "config.json")}, hub, "Plain auth should be decoded")
+	assert.NoError(t, ghcrErr, "ghcr.io credentials should be found")
+	assert.Equal(t, "desktop", ghcr.Username, "Credentials store should be used")
+	assert.Equal(t, "ghcr.io-secret", ghcr.Secret, "Server should be passed to the helper")
+	assert.Error(t, missingErr, "Unknown registry should have no credentials")
+}
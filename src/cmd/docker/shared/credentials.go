@@ -0,0 +1,182 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Server of Docker Hub in the docker config file
+const dockerHubServer = "https://index.docker.io/v1/"
+
+// Hosts under which docker login can store the Docker Hub credentials
+var dockerHubHosts = []string{"index.docker.io", "docker.io", "registry-1.docker.io", "hub.docker.com"}
+
+// Runs a docker credential helper, replaced in the tests
+var credentialHelper = func(helper string, serverURL string) ([]byte, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s failed: %v %s", helper, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// DockerCredentials are the credentials of a registry and where they were found
+type DockerCredentials struct {
+	Username string
+	Secret   string // Password or access token
+	Source   string
+}
+
+// dockerConfigFile is the part of ~/.docker/config.json with the credentials
+type dockerConfigFile struct {
+	Auths       map[string]dockerConfigAuth `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+type dockerConfigAuth struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// credentialHelperOutput is the output of docker-credential-<helper> get
+type credentialHelperOutput struct {
+	Username string `json:"Username"`
+	Secret   string `json:"Secret"`
+}
+
+// DockerHubCredentials returns the configured Docker Hub credentials (DOCKER_HUB_USER_RWD/DOCKER_HUB_PWD_RWD)
+// or, when the username is empty, the credentials of the docker config file (docker login)
+func DockerHubCredentials(username string, secret string) (DockerCredentials, error) {
+	if username != "" {
+		return DockerCredentials{
+			Username: username,
+			Secret:   secret,
+			Source:   "env",
+		}, nil
+	}
+
+	creds, err := DockerConfigCredentials(dockerHubServer)
+	if err != nil {
+		return DockerCredentials{}, fmt.Errorf("DOCKER_HUB_USER_RWD is not set and %v", err)
+	}
+	return creds, nil
+}
+
+// DockerConfigCredentials returns the credentials of the registry stored by docker login in
+// $DOCKER_CONFIG/config.json (default ~/.docker/config.json): the registry credential helper,
+// the plain auths entry or the default credentials store, in the same order of docker
+func DockerConfigCredentials(registryURL string) (DockerCredentials, error) {
+	path, err := dockerConfigPath()
+	if err != nil {
+		return DockerCredentials{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DockerCredentials{}, fmt.Errorf("no docker credentials found: %v", err)
+	}
+	var config dockerConfigFile
+	if err := json.Unmarshal(data, &config); err != nil {
+		return DockerCredentials{}, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	hosts := registryHosts(registryURL)
+
+	// Credential helper of the registry
+	for server, helper := range config.CredHelpers {
+		if matchesHost(server, hosts) {
+			return helperCredentials(helper, server)
+		}
+	}
+
+	// Plain auths entry (the entries written by the helpers are empty)
+	for server, auth := range config.Auths {
+		if !matchesHost(server, hosts) {
+			continue
+		}
+		if auth.Username != "" && auth.Password != "" {
+			return DockerCredentials{Username: auth.Username, Secret: auth.Password, Source: path}, nil
+		}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return DockerCredentials{}, fmt.Errorf("invalid auth of %s in %s: %v", server, path, err)
+			}
+			username, secret, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return DockerCredentials{}, fmt.Errorf("invalid auth of %s in %s", server, path)
+			}
+			return DockerCredentials{Username: username, Secret: secret, Source: path}, nil
+		}
+		if config.CredsStore != "" {
+			return helperCredentials(config.CredsStore, server)
+		}
+	}
+
+	return DockerCredentials{}, fmt.Errorf("no docker credentials found for %s in %s", hosts[0], path)
+}
+
+// helperCredentials asks the credentials of the server to the docker credential helper
+func helperCredentials(helper string, serverURL string) (DockerCredentials, error) {
+	out, err := credentialHelper(helper, serverURL)
+	if err != nil {
+		return DockerCredentials{}, err
+	}
+	var creds credentialHelperOutput
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return DockerCredentials{}, fmt.Errorf("failed to parse the output of credential helper %s: %v", helper, err)
+	}
+	return DockerCredentials{Username: creds.Username, Secret: creds.Secret, Source: "docker-credential-" + helper}, nil
+}
+
+// dockerConfigPath returns the path of the docker config file
+func dockerConfigPath() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("no docker credentials found: %v", err)
+	}
+	return filepath.Join(home, ".docker", "config.json"), nil
+}
+
+// registryHosts returns the hosts the registry can be stored under
+func registryHosts(registryURL string) []string {
+	host := serverHost(registryURL)
+	for _, hubHost := range dockerHubHosts {
+		if host == hubHost {
+			return dockerHubHosts
+		}
+	}
+	return []string{host}
+}
+
+// matchesHost returns true if the server of the config file is one of the hosts
+func matchesHost(server string, hosts []string) bool {
+	host := serverHost(server)
+	for _, h := range hosts {
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+// serverHost strips scheme and path from a server url
+func serverHost(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	host, _, _ := strings.Cut(server, "/")
+	return host
+}
@@ -0,0 +1,98 @@
+package shared
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// LoadRetentionPolicy reads a retention policy from a YAML (or JSON) file
+// The tags deployed in ArgoCD are protected unless the file sets keep_argocd_deployed: false
+func LoadRetentionPolicy(policyPath string) (docker.RetentionPolicy, error) {
+	policy := docker.RetentionPolicy{KeepArgoCDDeployed: true}
+
+	content, err := os.ReadFile(policyPath)
+	if err != nil {
+		return policy, fmt.Errorf("failed to read retention policy: %w", err)
+	}
+
+	// YAML is a superset of JSON, so both formats are accepted
+	if err := yaml.Unmarshal(content, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse retention policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// ValidateRetentionPolicy checks that the policy has at least a rule keeping
+// tags, without one every semver tag would be deleted
+func ValidateRetentionPolicy(policy docker.RetentionPolicy) error {
+	if policy.KeepLast > 0 || len(policy.KeepRegex) > 0 || policy.KeepPulledWithinDays > 0 ||
+		policy.KeepPerMajor > 0 || policy.KeepPerMinor > 0 {
+		return nil
+	}
+	return fmt.Errorf("retention policy has no keep rule: set keep_last, keep_regex, keep_pulled_within_days, keep_per_major or keep_per_minor")
+}
+
+// LoadDeployedTags reads the tags currently deployed from a text file.
+// Every line contains a tag ("1.2.3") or an image reference ("org/repo:1.2.3"),
+// empty lines and lines starting with '#' are ignored. Image references
+// are taken into account only when they point to repoPath.
+// Returns a map tag -> reason, ready to be used as protected tags.
+func LoadDeployedTags(deployedPath string, repoPath string) (map[string]string, error) {
+	file, err := os.Open(deployedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open deployed tags file: %w", err)
+	}
+	defer file.Close()
+
+	reason := fmt.Sprintf("listed in %s", filepath.Base(deployedPath))
+	deployed := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Image reference, keep it only if it is the same repository
+		if strings.ContainsAny(line, ":@") {
+			ref, ok := ImageRefForRepo(line, repoPath)
+			if !ok {
+				continue
+			}
+			line = ref
+		}
+
+		deployed[line] = reason
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read deployed tags file: %w", err)
+	}
+
+	return deployed, nil
+}
+
+// ImageRefForRepo checks if the image reference ("docker.io/org/repo:1.2.3",
+// "org/repo:1.2.3", "org/repo@sha256:..." or "org/repo:1.2.3@sha256:...") points
+// to repoPath and returns the tag, or the digest when the image is referenced
+// by digest only
+func ImageRefForRepo(image string, repoPath string) (string, bool) {
+	name, digest, _ := strings.Cut(image, "@")
+	ref := digest
+	// The tag is after the last ':' following the last '/' (not the port of the registry)
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		name, ref = name[:idx], name[idx+1:]
+	}
+
+	if ref == "" || (name != repoPath && !strings.HasSuffix(name, "/"+repoPath)) {
+		return "", false
+	}
+	return ref, true
+}
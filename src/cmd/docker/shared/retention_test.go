@@ -0,0 +1,65 @@
+package shared_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/shared"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+func TestImageRefForRepo(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+		ok       bool
+	}{
+		{"org/repo:1.2.3", "1.2.3", true},
+		{"docker.io/org/repo:1.2.3", "1.2.3", true},
+		{"org/repo@sha256:abc", "sha256:abc", true},
+		{"org/repo:1.2.3@sha256:abc", "1.2.3", true},
+		{"registry:5000/org/repo@sha256:abc", "sha256:abc", true},
+		{"registry:5000/org/repo:2.0.0", "2.0.0", true},
+		{"org/other:1.2.3", "", false},
+		{"org/repo", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			// Act: Resolve the reference for org/repo
+			ref, ok := shared.ImageRefForRepo(tt.image, "org/repo")
+
+			// Assert: Verify the tag or digest
+			assert.Equal(t, tt.ok, ok, "Match should be as expected")
+			assert.Equal(t, tt.expected, ref, "Reference should match")
+		})
+	}
+}
+
+func TestLoadDeployedTags(t *testing.T) {
+	// Arrange: Write a deployed tags file
+	deployedPath := filepath.Join(t.TempDir(), "deployed.txt")
+	content := "# deployed in prod\n1.0.0\n\norg/repo:1.1.0\norg/other:9.9.9\n"
+	assert.NoError(t, os.WriteFile(deployedPath, []byte(content), 0644), "Writing the file should not fail")
+
+	// Act: Load the tags of org/repo
+	deployed, err := shared.LoadDeployedTags(deployedPath, "org/repo")
+
+	// Assert: Only the tags of the repository are returned
+	assert.NoError(t, err, "Loading the file should not fail")
+	assert.Equal(t, map[string]string{
+		"1.0.0": "listed in deployed.txt",
+		"1.1.0": "listed in deployed.txt",
+	}, deployed, "Deployed tags should match")
+}
+
+func TestValidateRetentionPolicy(t *testing.T) {
+	// Act & Assert: A policy needs at least a keep rule
+	assert.Error(t, shared.ValidateRetentionPolicy(docker.RetentionPolicy{}), "Empty policy should be rejected")
+	assert.Error(t, shared.ValidateRetentionPolicy(docker.RetentionPolicy{KeepArgoCDDeployed: true, NonSemverMaxAgeDays: 30}), "Policy without keep rule should be rejected")
+	assert.NoError(t, shared.ValidateRetentionPolicy(docker.RetentionPolicy{KeepLast: 5}), "keep_last should be accepted")
+	assert.NoError(t, shared.ValidateRetentionPolicy(docker.RetentionPolicy{KeepRegex: []string{"^release-"}}), "keep_regex should be accepted")
+}
@@ -0,0 +1,276 @@
+package shared
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
+)
+
+// Severities from the lowest to the highest
+var severityRank = map[string]int{
+	"UNKNOWN":    0,
+	"NEGLIGIBLE": 1,
+	"LOW":        2,
+	"MEDIUM":     3,
+	"HIGH":       4,
+	"CRITICAL":   5,
+}
+
+// trivyReport is the part of a Trivy json report (trivy image -f json) used by the gate
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID string `json:"VulnerabilityID"`
+			PkgName         string `json:"PkgName"`
+			Severity        string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// grypeReport is the part of a Grype json report (grype -o json) used by the gate
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name string `json:"name"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+// dsseEnvelope is the envelope of an attestation layer
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		KeyID string `json:"keyid"`
+		Sig   string `json:"sig"`
+	} `json:"signatures"`
+}
+
+// vulnStatement is an in-toto statement with the cosign vuln predicate
+type vulnStatement struct {
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate struct {
+		Scanner struct {
+			Result json.RawMessage `json:"result"`
+		} `json:"scanner"`
+		Metadata struct {
+			ScanStartedOn  string `json:"scanStartedOn"`
+			ScanFinishedOn string `json:"scanFinishedOn"`
+		} `json:"metadata"`
+	} `json:"predicate"`
+}
+
+// VulnerabilityAttestation is the content of a cosign vuln attestation
+type VulnerabilityAttestation struct {
+	Report    docker.VulnerabilityReport
+	Subjects  []string  // Digests of the attested images (sha256:<hex>)
+	ScannedAt time.Time // End of the scan, zero when the attestation has no scan time
+}
+
+// Attests returns true if the digest (sha256:<hex>) is one of the subjects of the attestation
+func (a VulnerabilityAttestation) Attests(digest string) bool {
+	for _, subject := range a.Subjects {
+		if strings.EqualFold(subject, digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidSeverity returns an error if the severity is not known
+func ValidSeverity(severity string) error {
+	if _, ok := severityRank[strings.ToUpper(severity)]; !ok {
+		return fmt.Errorf("invalid severity '%s' (LOW, MEDIUM, HIGH, CRITICAL)", severity)
+	}
+	return nil
+}
+
+// FindingsAtOrAbove returns the findings with a severity at or above the threshold
+func FindingsAtOrAbove(report docker.VulnerabilityReport, threshold string) []docker.Vulnerability {
+	min := severityRank[strings.ToUpper(threshold)]
+	var findings []docker.Vulnerability
+	for _, vuln := range report.Vulnerabilities {
+		if severityRank[strings.ToUpper(vuln.Severity)] >= min {
+			findings = append(findings, vuln)
+		}
+	}
+	return findings
+}
+
+// ParseVulnerabilityReport reads a Trivy or Grype json report
+func ParseVulnerabilityReport(data []byte) (docker.VulnerabilityReport, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return docker.VulnerabilityReport{}, fmt.Errorf("failed to parse vulnerability report: %v", err)
+	}
+
+	report := docker.VulnerabilityReport{Vulnerabilities: []docker.Vulnerability{}}
+	switch {
+	case keys["Results"] != nil || keys["SchemaVersion"] != nil:
+		var trivy trivyReport
+		if err := json.Unmarshal(data, &trivy); err != nil {
+			return report, fmt.Errorf("failed to parse trivy report: %v", err)
+		}
+		report.Scanner = "trivy"
+		for _, result := range trivy.Results {
+			for _, vuln := range result.Vulnerabilities {
+				report.Vulnerabilities = append(report.Vulnerabilities, docker.Vulnerability{
+					ID:       vuln.VulnerabilityID,
+					Package:  vuln.PkgName,
+					Severity: strings.ToUpper(vuln.Severity),
+				})
+			}
+		}
+	case keys["matches"] != nil:
+		var grype grypeReport
+		if err := json.Unmarshal(data, &grype); err != nil {
+			return report, fmt.Errorf("failed to parse grype report: %v", err)
+		}
+		report.Scanner = "grype"
+		for _, match := range grype.Matches {
+			report.Vulnerabilities = append(report.Vulnerabilities, docker.Vulnerability{
+				ID:       match.Vulnerability.ID,
+				Package:  match.Artifact.Name,
+				Severity: strings.ToUpper(match.Vulnerability.Severity),
+			})
+		}
+	default:
+		return report, fmt.Errorf("unknown vulnerability report format (trivy or grype json expected)")
+	}
+	return report, nil
+}
+
+// LoadVulnerabilityReport reads the report stored alongside the tag, the path
+// template can use {repo} (slashes replaced by '_') and {tag}
+func LoadVulnerabilityReport(pathTemplate string, repoPath string, tag string) (docker.VulnerabilityReport, error) {
+	path := strings.NewReplacer(
+		"{repo}", strings.ReplaceAll(repoPath, "/", "_"),
+		"{tag}", tag,
+	).Replace(pathTemplate)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return docker.VulnerabilityReport{}, fmt.Errorf("no vulnerability report: %v", err)
+	}
+	return ParseVulnerabilityReport(data)
+}
+
+// ParseVulnerabilityAttestation reads the scanner report, the subjects and the scan time
+// of a cosign vuln attestation (DSSE envelope with an in-toto statement)
+func ParseVulnerabilityAttestation(envelope []byte) (VulnerabilityAttestation, error) {
+	var dsse dsseEnvelope
+	if err := json.Unmarshal(envelope, &dsse); err != nil {
+		return VulnerabilityAttestation{}, fmt.Errorf("failed to parse attestation envelope: %v", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(dsse.Payload)
+	if err != nil {
+		return VulnerabilityAttestation{}, fmt.Errorf("failed to decode attestation payload: %v", err)
+	}
+
+	var statement vulnStatement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return VulnerabilityAttestation{}, fmt.Errorf("failed to parse attestation statement: %v", err)
+	}
+	if !strings.Contains(statement.PredicateType, "vuln") || len(statement.Predicate.Scanner.Result) == 0 {
+		return VulnerabilityAttestation{}, fmt.Errorf("attestation is not a vulnerability scan (%s)", statement.PredicateType)
+	}
+	report, err := ParseVulnerabilityReport(statement.Predicate.Scanner.Result)
+	if err != nil {
+		return VulnerabilityAttestation{}, err
+	}
+
+	attestation := VulnerabilityAttestation{Report: report}
+	for _, subject := range statement.Subject {
+		if digest := subject.Digest["sha256"]; digest != "" {
+			attestation.Subjects = append(attestation.Subjects, "sha256:"+digest)
+		}
+	}
+	for _, scanTime := range []string{statement.Predicate.Metadata.ScanFinishedOn, statement.Predicate.Metadata.ScanStartedOn} {
+		if t, err := time.Parse(time.RFC3339, scanTime); err == nil {
+			attestation.ScannedAt = t
+			break
+		}
+	}
+	return attestation, nil
+}
+
+// LoadAttestationKey reads the PEM public key (e.g. cosign.pub) verifying the attestations
+func LoadAttestationKey(keyPath string) (crypto.PublicKey, error) {
+	content, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attestation key: %v", err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("attestation key %s is not a PEM public key", keyPath)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse attestation key: %v", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported attestation key type %T", key)
+}
+
+// VerifyAttestation checks that at least a signature of the DSSE envelope is
+// made by the key, over the pre-authentication encoding of the payload
+func VerifyAttestation(envelope []byte, key crypto.PublicKey) error {
+	var dsse dsseEnvelope
+	if err := json.Unmarshal(envelope, &dsse); err != nil {
+		return fmt.Errorf("failed to parse attestation envelope: %v", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(dsse.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode attestation payload: %v", err)
+	}
+
+	// PAE(type, body) = "DSSEv1" SP LEN(type) SP type SP LEN(body) SP body
+	pae := []byte(fmt.Sprintf("DSSEv1 %d %s %d ", len(dsse.PayloadType), dsse.PayloadType, len(payload)))
+	pae = append(pae, payload...)
+	digest := sha256.Sum256(pae)
+
+	for _, signature := range dsse.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(k, digest[:], sig) {
+				return nil
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil || rsa.VerifyPSS(k, crypto.SHA256, digest[:], sig, nil) == nil {
+				return nil
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(k, pae, sig) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("attestation is not signed by the attestation key")
+}
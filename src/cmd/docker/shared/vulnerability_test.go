@@ -0,0 +1,108 @@
+package shared_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/shared"
+)
+
+const trivyJSON = `{"SchemaVersion":2,"Results":[{"Target":"app","Vulnerabilities":[
+	{"VulnerabilityID":"CVE-1","PkgName":"openssl","Severity":"CRITICAL"},
+	{"VulnerabilityID":"CVE-2","PkgName":"zlib","Severity":"MEDIUM"}]}]}`
+
+func TestParseVulnerabilityReport(t *testing.T) {
+	// Arrange: A Trivy and a Grype report
+	grypeJSON := `{"matches":[{"vulnerability":{"id":"GHSA-1","severity":"High"},"artifact":{"name":"lodash"}}]}`
+
+	// Act: Parse both and an unknown format
+	trivy, trivyErr := shared.ParseVulnerabilityReport([]byte(trivyJSON))
+	grype, grypeErr := shared.ParseVulnerabilityReport([]byte(grypeJSON))
+	_, unknownErr := shared.ParseVulnerabilityReport([]byte(`{"foo":1}`))
+
+	// Assert: Findings are normalized
+	assert.NoError(t, trivyErr, "Trivy report should be parsed")
+	assert.Equal(t, "trivy", trivy.Scanner, "Scanner should be detected")
+	assert.Len(t, shared.FindingsAtOrAbove(trivy, "high"), 1, "Only the critical finding is at or above HIGH")
+	assert.NoError(t, grypeErr, "Grype report should be parsed")
+	assert.Equal(t, "HIGH", grype.Vulnerabilities[0].Severity, "Severity should be upper case")
+	assert.Equal(t, "lodash", grype.Vulnerabilities[0].Package, "Package should match")
+	assert.Error(t, unknownErr, "Unknown format should return an error")
+}
+
+func TestLoadVulnerabilityReport(t *testing.T) {
+	// Arrange: A report stored alongside the tag
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "org_app-1.2.0.json"), []byte(trivyJSON), 0644))
+
+	// Act: Load the report of the tag and of a tag without report
+	report, err := shared.LoadVulnerabilityReport(filepath.Join(dir, "{repo}-{tag}.json"), "org/app", "1.2.0")
+	_, missingErr := shared.LoadVulnerabilityReport(filepath.Join(dir, "{repo}-{tag}.json"), "org/app", "1.1.0")
+
+	// Assert: The template is resolved
+	assert.NoError(t, err, "Report should be loaded")
+	assert.Len(t, report.Vulnerabilities, 2, "Findings should match")
+	assert.Error(t, missingErr, "Missing report should return an error")
+}
+
+func TestParseVulnerabilityAttestation(t *testing.T) {
+	// Arrange: A cosign vuln attestation wrapping the Trivy report
+	statement := `{"predicateType":"https://cosign.sigstore.dev/attestation/vuln/v1","subject":[{"name":"org/app","digest":{"sha256":"abc"}}],` +
+		`"predicate":{"scanner":{"result":` + trivyJSON + `},"metadata":{"scanFinishedOn":"2024-06-01T10:00:00Z"}}}`
+	envelope := `{"payloadType":"application/vnd.in-toto+json","payload":"` + base64.StdEncoding.EncodeToString([]byte(statement)) + `"}`
+
+	// Act: Parse the envelope
+	attestation, err := shared.ParseVulnerabilityAttestation([]byte(envelope))
+
+	// Assert: The scanner result is the report, with the subject and the scan time
+	assert.NoError(t, err, "Attestation should be parsed")
+	assert.Equal(t, "trivy", attestation.Report.Scanner, "Scanner should be detected")
+	assert.Len(t, attestation.Report.Vulnerabilities, 2, "Findings should match")
+	assert.True(t, attestation.Attests("sha256:abc"), "Subject digest should be attested")
+	assert.False(t, attestation.Attests("sha256:def"), "Other digest should not be attested")
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), attestation.ScannedAt, "Scan time should match")
+}
+
+func TestVerifyAttestation(t *testing.T) {
+	// Arrange: An envelope signed (DSSE PAE) with an ECDSA key, and the PEM public key
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	assert.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+
+	payload := `{"predicateType":"https://cosign.sigstore.dev/attestation/vuln/v1"}`
+	payloadType := "application/vnd.in-toto+json"
+	digest := sha256.Sum256([]byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+	envelope := func(payload string) []byte {
+		return []byte(fmt.Sprintf(`{"payloadType":"%s","payload":"%s","signatures":[{"sig":"%s"}]}`,
+			payloadType, base64.StdEncoding.EncodeToString([]byte(payload)), base64.StdEncoding.EncodeToString(sig)))
+	}
+
+	// Act: Load the key and verify the envelope, a tampered and an unsigned one
+	publicKey, loadErr := shared.LoadAttestationKey(keyPath)
+	signedErr := shared.VerifyAttestation(envelope(payload), publicKey)
+	tamperedErr := shared.VerifyAttestation(envelope(payload+" "), publicKey)
+	unsignedErr := shared.VerifyAttestation([]byte(`{"payloadType":"x","payload":""}`), publicKey)
+
+	// Assert: Only the signed payload is verified
+	assert.NoError(t, loadErr, "Key should be loaded")
+	assert.NoError(t, signedErr, "Signed envelope should be verified")
+	assert.Error(t, tamperedErr, "Tampered payload should be refused")
+	assert.Error(t, unsignedErr, "Unsigned envelope should be refused")
+}
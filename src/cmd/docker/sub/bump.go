@@ -0,0 +1,46 @@
+package sub
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var (
+	repoB         string
+	registryURLB  string
+	itemsForPageB = 100 // Default number of items per page
+	bumpLevel     = "patch"
+	bumpSourceTag string
+)
+
+var BumpDockerCmd = &cobra.Command{
+	Use:   "bump",
+	Short: "Compute the next semver tag of a Docker repository",
+	Long: `Compute the next semver tag of a repository incrementing the highest release tag (x.y.z or vx.y.z)
+at the given level. With --source the image of the source tag is also tagged with the next version.
+
+Example:
+  sinaloa docker bump --repo org/app --level minor --source latest`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.Bump(registryURLB, repoB, strconv.Itoa(itemsForPageB), bumpLevel, bumpSourceTag)
+	}),
+}
+
+func init() {
+	BumpDockerCmd.Flags().IntVarP(&itemsForPageB, "items", "i", itemsForPageB, "Number of items per page")
+	BumpDockerCmd.Flags().StringVarP(&repoB, "repo", "r", "", "Docker repository to bump")
+	if err := BumpDockerCmd.MarkFlagRequired("repo"); err != nil {
+		fmt.Println(err)
+	}
+	BumpDockerCmd.Flags().StringVar(&registryURLB, "registry", "", "Registry url, e.g. ghcr.io or harbor.example.com (default Docker Hub or DOCKER_REGISTRY_URL)")
+	BumpDockerCmd.Flags().StringVarP(&bumpLevel, "level", "l", bumpLevel, "Level to increment: major, minor or patch")
+	BumpDockerCmd.Flags().StringVarP(&bumpSourceTag, "source", "s", "", "Tag the image of this tag with the next version")
+	BumpDockerCmd.RegisterFlagCompletionFunc("repo", completeRepositories)
+	BumpDockerCmd.RegisterFlagCompletionFunc("source", completeTags)
+	BumpDockerCmd.RegisterFlagCompletionFunc("level", cobra.FixedCompletions([]string{"major", "minor", "patch"}, cobra.ShellCompDirectiveNoFileComp))
+}
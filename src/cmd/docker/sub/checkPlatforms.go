@@ -0,0 +1,49 @@
+package sub
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var (
+	repoP             string
+	registryURLP      string
+	itemsForPageP     = 100 // Default number of items per page
+	platformTags      []string
+	requiredPlatforms []string
+)
+
+var CheckPlatformsDockerCmd = &cobra.Command{
+	Use:   "check-platforms",
+	Short: "Check that Docker tags are built for the required platforms",
+	Long: `Check that the tags of a repository (all of them or the ones passed with --tags) are built for every
+required platform. The command exits with an error when a tag misses a platform.
+A platform without variant (linux/arm) matches any variant.
+
+Example:
+  sinaloa docker check-platforms -r org/repo --require linux/amd64,linux/arm64 --tags 1.2.0,latest`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.CheckPlatforms(registryURLP, repoP, strconv.Itoa(itemsForPageP), platformTags, requiredPlatforms)
+	}),
+}
+
+func init() {
+	CheckPlatformsDockerCmd.Flags().IntVarP(&itemsForPageP, "items", "i", itemsForPageP, "Number of items per page")
+	CheckPlatformsDockerCmd.Flags().StringVarP(&repoP, "repo", "r", "", "Docker repository to check")
+	if err := CheckPlatformsDockerCmd.MarkFlagRequired("repo"); err != nil {
+		fmt.Println(err)
+	}
+	CheckPlatformsDockerCmd.Flags().StringVar(&registryURLP, "registry", "", "Registry url, e.g. ghcr.io or harbor.example.com (default Docker Hub or DOCKER_REGISTRY_URL)")
+	CheckPlatformsDockerCmd.Flags().StringSliceVar(&requiredPlatforms, "require", nil, "Required platforms (comma-separated), e.g. linux/amd64,linux/arm64")
+	if err := CheckPlatformsDockerCmd.MarkFlagRequired("require"); err != nil {
+		fmt.Println(err)
+	}
+	CheckPlatformsDockerCmd.Flags().StringSliceVarP(&platformTags, "tags", "t", nil, "Tags to check (comma-separated, default all the tags)")
+	CheckPlatformsDockerCmd.RegisterFlagCompletionFunc("repo", completeRepositories)
+	CheckPlatformsDockerCmd.RegisterFlagCompletionFunc("tags", completeTagList)
+}
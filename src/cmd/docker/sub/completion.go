@@ -0,0 +1,44 @@
+package sub
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// Shell completion of the repositories of the --registry of the command
+var completeRepositories = helpers.CompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+	return controller.CompleteRepositories(flagValue(cmd, "registry"), toComplete)
+})
+
+// Shell completion of the tags of the --repo of the command
+var completeTags = helpers.CompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+	return controller.CompleteTags(flagValue(cmd, "registry"), flagValue(cmd, "repo"))
+})
+
+// Shell completion of a comma-separated list of tags of the --repo of the command
+var completeTagList = helpers.ListCompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+	return controller.CompleteTags(flagValue(cmd, "registry"), flagValue(cmd, "repo"))
+})
+
+// completeImageRefs is the shell completion of repo:tag, no space is added after the repository
+func completeImageRefs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	completions, directive := helpers.CompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+		return controller.CompleteImageRefs(flagValue(cmd, "registry"), toComplete)
+	})(cmd, args, toComplete)
+	if !strings.Contains(toComplete, ":") {
+		directive |= cobra.ShellCompDirectiveNoSpace
+	}
+	return completions, directive
+}
+
+// flagValue returns the value of a flag of the command, empty if it has no such flag
+func flagValue(cmd *cobra.Command, name string) string {
+	if flag := cmd.Flag(name); flag != nil {
+		return flag.Value.String()
+	}
+	return ""
+}
@@ -7,28 +7,102 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/shared"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/docker"
 )
 
 var (
-	repoD         string
-	itemsToTake   int
-	itemsForPageD int    = 100    // Default number of items per page
-	dryRunStr     string = "true" // Default to dry run
+	repoD                string
+	registryURLD         string
+	itemsToTake          int
+	itemsForPageD        int    = 100    // Default number of items per page
+	dryRunStr            string = "true" // Default to dry run
+	policyFile           string
+	keepRegex            []string
+	keepPulledWithinDays int
+	keepPerMajor         int
+	keepPerMinor         int
+	keepDeployedFile     string
+	nonSemverMaxAgeDays  int
+	protectArgoCD        bool
+	concurrency          int = 5 // Default parallel deletions
+	maxRetries           int = 3 // Default retries on 429/5xx
+	journalPath          string
 )
 
 var DeleteImagesDockerCmd = &cobra.Command{
 	Use:   "delete-images",
 	Short: "Delete Docker images from a repository",
-	Long:  "Delete Docker images from a specified repository on Docker Hub. You can specify the number of items per page.",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Delete Docker images from a specified repository on Docker Hub (or an OCI registry with --registry) applying retention rules.
+The rules can be passed with flags or with a policy file (--policy), flags override the file.
+The tags used by the ArgoCD applications are protected, ArgoCD must be configured unless --protect-argocd=false.
+With dry-run the output shows the tags to delete and which rule protected every other tag.
+
+Example:
+  sinaloa docker delete-images -r org/repo -t 5 --keep-regex "^release-" --keep-pulled-within-days 30 -d true`,
+	// The tags deployed in ArgoCD are protected with the ArgoCD credentials
+	Annotations: map[string]string{helpers.AnnotationCredentials: "argocd"},
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		dryRun, err := strconv.ParseBool(dryRunStr)
 		if err != nil {
-			fmt.Println("[Error] Invalid value for dry-run, must be true or false")
-			return
+			return nil, helpers.ValidationError("Invalid value for dry-run, must be true or false")
+		}
+		policy, err := buildRetentionPolicy(cmd)
+		if err != nil {
+			return nil, helpers.NewCommandError(helpers.ExitValidation, err)
 		}
-		result, _ := controller.GetImages(repoD, strconv.Itoa(itemsForPageD), strconv.Itoa(itemsToTake), "delete", dryRun)
-		fmt.Println(string(result))
-	},
+		opts := docker.DeleteOptions{
+			Concurrency: concurrency,
+			MaxRetries:  maxRetries,
+			JournalPath: journalPath,
+		}
+		return controller.DeleteImages(registryURLD, repoD, strconv.Itoa(itemsForPageD), policy, opts, dryRun)
+	}),
+}
+
+// buildRetentionPolicy loads the policy file (if any) and applies the flags on top of it
+func buildRetentionPolicy(cmd *cobra.Command) (docker.RetentionPolicy, error) {
+	policy := docker.RetentionPolicy{KeepArgoCDDeployed: true}
+	if policyFile != "" {
+		loaded, err := shared.LoadRetentionPolicy(policyFile)
+		if err != nil {
+			return policy, err
+		}
+		policy = loaded
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("items-to-take") {
+		policy.KeepLast = itemsToTake
+	}
+	if flags.Changed("keep-regex") {
+		policy.KeepRegex = keepRegex
+	}
+	if flags.Changed("keep-pulled-within-days") {
+		policy.KeepPulledWithinDays = keepPulledWithinDays
+	}
+	if flags.Changed("keep-per-major") {
+		policy.KeepPerMajor = keepPerMajor
+	}
+	if flags.Changed("keep-per-minor") {
+		policy.KeepPerMinor = keepPerMinor
+	}
+	if flags.Changed("keep-deployed-file") {
+		policy.KeepDeployedFile = keepDeployedFile
+	}
+	if flags.Changed("non-semver-max-age-days") {
+		policy.NonSemverMaxAgeDays = nonSemverMaxAgeDays
+	}
+	if flags.Changed("protect-argocd") {
+		policy.KeepArgoCDDeployed = protectArgoCD
+	}
+	if policy.KeepArgoCDDeployed && helpers.AppConfig.ARGOCD_URL == "" {
+		// The deployed tags are never left unprotected silently
+		return policy, fmt.Errorf("ArgoCD is not configured (ARGOCD_URL) to protect the deployed tags, pass --protect-argocd=false to delete without it")
+	}
+
+	return policy, shared.ValidateRetentionPolicy(policy)
 }
 
 func init() {
@@ -37,10 +111,26 @@ func init() {
 	if err := DeleteImagesDockerCmd.MarkFlagRequired("repo"); err != nil {
 		fmt.Println(err)
 	}
+	DeleteImagesDockerCmd.Flags().StringVar(&registryURLD, "registry", "", "Registry url, e.g. ghcr.io or harbor.example.com (default Docker Hub or DOCKER_REGISTRY_URL)")
 	DeleteImagesDockerCmd.Flags().IntVarP(&itemsToTake, "items-to-take", "t", 0, "Number of the first X tags to take from the repository.")
+	DeleteImagesDockerCmd.Flags().StringVarP(&policyFile, "policy", "p", "", "Retention policy file (yaml or json)")
+	DeleteImagesDockerCmd.Flags().StringSliceVar(&keepRegex, "keep-regex", nil, "Keep tags matching these regexes (comma-separated)")
+	DeleteImagesDockerCmd.Flags().IntVar(&keepPulledWithinDays, "keep-pulled-within-days", 0, "Keep tags pulled in the last N days")
+	DeleteImagesDockerCmd.Flags().IntVar(&keepPerMajor, "keep-per-major", 0, "Keep the last N semver tags of every major line")
+	DeleteImagesDockerCmd.Flags().IntVar(&keepPerMinor, "keep-per-minor", 0, "Keep the last N semver tags of every major.minor line")
+	DeleteImagesDockerCmd.Flags().StringVar(&keepDeployedFile, "keep-deployed-file", "", "File with the tags currently deployed, one per line")
+	DeleteImagesDockerCmd.Flags().IntVar(&nonSemverMaxAgeDays, "non-semver-max-age-days", 0, "Delete non-semver tags only when older than N days, the tags without a push date are kept (0 = always delete)")
+	DeleteImagesDockerCmd.Flags().BoolVar(&protectArgoCD, "protect-argocd", true, "Keep the tags used by the ArgoCD applications, requires ArgoCD to be configured (ARGOCD_URL, ARGOCD_USER, ARGOCD_PASSWORD)")
+	DeleteImagesDockerCmd.Flags().IntVar(&concurrency, "concurrency", concurrency, "Number of tags deleted in parallel")
+	DeleteImagesDockerCmd.Flags().IntVar(&maxRetries, "retries", maxRetries, "Retries for every tag on 429 and 5xx responses")
+	DeleteImagesDockerCmd.Flags().StringVar(&journalPath, "journal", "", "Journal file, re-run with the same file to resume an interrupted cleanup")
+	DeleteImagesDockerCmd.RegisterFlagCompletionFunc("repo", completeRepositories)
 	DeleteImagesDockerCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if policyFile != "" {
+			return nil
+		}
 		if !cmd.Flags().Changed("items-to-take") || itemsToTake <= 0 {
-			return fmt.Errorf("parameter '--items-to-take' is required and must be greater than 0")
+			return fmt.Errorf("parameter '--items-to-take' is required and must be greater than 0 (or use '--policy')")
 		}
 		return nil
 	}
@@ -0,0 +1,35 @@
+package sub
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// retentionCmd returns a command with the retention flags of delete-images, parsed from args
+func retentionCmd(t *testing.T, args ...string) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().IntVarP(&itemsToTake, "items-to-take", "t", 0, "")
+	cmd.Flags().BoolVar(&protectArgoCD, "protect-argocd", true, "")
+	assert.NoError(t, cmd.Flags().Parse(args))
+	return cmd
+}
+
+func TestBuildRetentionPolicy_ArgoCDNotConfigured(t *testing.T) {
+	// Arrange: No ArgoCD in the configuration
+	oldURL := helpers.AppConfig.ARGOCD_URL
+	defer func() { helpers.AppConfig.ARGOCD_URL = oldURL }()
+	helpers.AppConfig.ARGOCD_URL = ""
+
+	// Act: Build the policy with the default protection and with the protection disabled
+	_, defaultErr := buildRetentionPolicy(retentionCmd(t, "-t", "5"))
+	policy, disabledErr := buildRetentionPolicy(retentionCmd(t, "-t", "5", "--protect-argocd=false"))
+
+	// Assert: The deployed tags are unprotected only on request
+	assert.ErrorContains(t, defaultErr, "--protect-argocd=false", "Missing ArgoCD should be a validation error")
+	assert.NoError(t, disabledErr, "Disabled protection should not need ArgoCD")
+	assert.False(t, policy.KeepArgoCDDeployed, "Protection should be disabled")
+}
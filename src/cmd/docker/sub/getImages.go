@@ -7,21 +7,22 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 )
 
 var (
 	repo         string
+	registryURL  string
 	itemsForPage = 100 // Default number of items per page
 )
 
 var GetImagesDockerCmd = &cobra.Command{
 	Use:   "get-images",
 	Short: "Get Docker images from a repository",
-	Long:  "Get Docker images from a specified repository on Docker Hub. You can specify the number of items per page.",
-	Run: func(cmd *cobra.Command, args []string) {
-		result, _ := controller.GetImages(repo, strconv.Itoa(itemsForPage), "not-used", "get", true)
-		fmt.Println(string(result))
-	},
+	Long:  "Get Docker images from a specified repository on Docker Hub or on an OCI registry (--registry). You can specify the number of items per page.",
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.GetImages(registryURL, repo, strconv.Itoa(itemsForPage), "not-used", "get", true)
+	}),
 }
 
 func init() {
@@ -30,4 +31,6 @@ func init() {
 	if err := GetImagesDockerCmd.MarkFlagRequired("repo"); err != nil {
 		fmt.Println(err)
 	}
+	GetImagesDockerCmd.Flags().StringVar(&registryURL, "registry", "", "Registry url, e.g. ghcr.io or harbor.example.com (default Docker Hub or DOCKER_REGISTRY_URL)")
+	GetImagesDockerCmd.RegisterFlagCompletionFunc("repo", completeRepositories)
 }
@@ -0,0 +1,42 @@
+package sub
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var (
+	namespace     string
+	registryURLI  string
+	itemsForPageI = 100 // Default number of items per page
+	staleDays     = 90  // Default days without pulls to consider a tag stale
+)
+
+var InventoryDockerCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Inventory of the Docker repositories of a namespace",
+	Long: `List all the repositories of a namespace (Docker Hub organization or OCI registry prefix with --registry)
+with tag count, total size, oldest/newest push and stale tags (not pulled in the last --stale-days days),
+to find which repositories to clean up. The OCI registries give no pull dates, their tags are reported
+as unknown instead of stale. With --output table the table shows the totals and
+human readable sizes, --output csv has a line per repository.`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		// The csv and table outputs are rendered by the controller and printed as they are
+		return controller.Inventory(registryURLI, namespace, strconv.Itoa(itemsForPageI), staleDays, helpers.OutputFormat)
+	}),
+}
+
+func init() {
+	InventoryDockerCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Docker Hub namespace (organization or user) to scan")
+	if err := InventoryDockerCmd.MarkFlagRequired("namespace"); err != nil {
+		fmt.Println(err)
+	}
+	InventoryDockerCmd.Flags().StringVar(&registryURLI, "registry", "", "Registry url, e.g. ghcr.io or harbor.example.com (default Docker Hub or DOCKER_REGISTRY_URL)")
+	InventoryDockerCmd.Flags().IntVarP(&itemsForPageI, "items", "i", itemsForPageI, "Number of items per page")
+	InventoryDockerCmd.Flags().IntVar(&staleDays, "stale-days", staleDays, "Days without pulls after which a tag is stale")
+}
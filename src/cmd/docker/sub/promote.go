@@ -0,0 +1,44 @@
+package sub
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var (
+	promoteFrom        string
+	promoteTo          string
+	registryURLPromote string
+)
+
+var PromoteDockerCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Copy a Docker image to another repository or tag",
+	Long: `Copy an image between repositories of the same registry (or retag it) through the registry api,
+without pulling the layers locally. Multi-arch indexes are copied with all their platforms
+and the digest does not change.
+
+Example:
+  sinaloa docker promote --from org/app-staging:1.2.0-rc1 --to org/app:1.2.0`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.Promote(registryURLPromote, promoteFrom, promoteTo)
+	}),
+}
+
+func init() {
+	PromoteDockerCmd.Flags().StringVar(&promoteFrom, "from", "", "Source image, repo:tag or repo@sha256:<digest>")
+	if err := PromoteDockerCmd.MarkFlagRequired("from"); err != nil {
+		fmt.Println(err)
+	}
+	PromoteDockerCmd.Flags().StringVar(&promoteTo, "to", "", "Target image, repo:tag")
+	if err := PromoteDockerCmd.MarkFlagRequired("to"); err != nil {
+		fmt.Println(err)
+	}
+	PromoteDockerCmd.Flags().StringVar(&registryURLPromote, "registry", "", "Registry url, e.g. ghcr.io or harbor.example.com (default Docker Hub or DOCKER_REGISTRY_URL)")
+	PromoteDockerCmd.RegisterFlagCompletionFunc("from", completeImageRefs)
+	PromoteDockerCmd.RegisterFlagCompletionFunc("to", completeImageRefs)
+}
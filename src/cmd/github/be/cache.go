@@ -0,0 +1,99 @@
+package be
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	githubHelper "github.com/eltiocaballoloco/sinaloa-cli/src/helpers/github"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+)
+
+// ScanCache keeps the result of every repository scan keyed on the repository
+// and its LastCommitSHA: a repository whose default branch did not move is not
+// scanned again. The scan parameters are part of the key, changing folders,
+// manifest name, env filters or manifest schema invalidates the cache.
+type ScanCache struct {
+	dir       string
+	paramsKey string
+	hits      int64
+}
+
+// cachedScan is the cached result of a repository (RepoData is nil when
+// the repository has no deployments)
+type cachedScan struct {
+	RepoID        string           `json:"repo_id"`
+	LastCommitSHA string           `json:"last_commit_sha"`
+	ParamsKey     string           `json:"params_key"`
+	RepoData      *github.RepoData `json:"repo_data"`
+}
+
+// NewScanCache returns the scan cache in dir for the given scan parameters
+func NewScanCache(dir string, folders []string, manifestName string, envFilters []string, schema github.ManifestSchema) (*ScanCache, error) {
+	// The scans contain private repository data, readable by the owner only
+	dir = filepath.Join(dir, "scans")
+	if err := githubHelper.PrivateDir(dir); err != nil {
+		return nil, err
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	params := strings.Join(folders, ",") + "|" + manifestName + "|" + strings.Join(envFilters, ",") + "|" + string(schemaJSON)
+	sum := sha256.Sum256([]byte(params))
+	return &ScanCache{dir: dir, paramsKey: hex.EncodeToString(sum[:8])}, nil
+}
+
+// Get returns the cached result of the repository at commitSHA
+func (c *ScanCache) Get(repoID string, commitSHA string) (*github.RepoData, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(repoID))
+	if err != nil {
+		return nil, false
+	}
+	var cached cachedScan
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	if cached.RepoID != repoID || cached.LastCommitSHA != commitSHA || cached.ParamsKey != c.paramsKey {
+		return nil, false
+	}
+	atomic.AddInt64(&c.hits, 1)
+	return cached.RepoData, true
+}
+
+// Put saves the result of the repository at commitSHA, the cache is best effort
+func (c *ScanCache) Put(repoID string, commitSHA string, repoData *github.RepoData) {
+	if c == nil {
+		return
+	}
+	data, err := json.Marshal(cachedScan{
+		RepoID:        repoID,
+		LastCommitSHA: commitSHA,
+		ParamsKey:     c.paramsKey,
+		RepoData:      repoData,
+	})
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(c.path(repoID), data, 0600)
+}
+
+// Hits returns the repositories served by the cache
+func (c *ScanCache) Hits() int {
+	if c == nil {
+		return 0
+	}
+	return int(atomic.LoadInt64(&c.hits))
+}
+
+// path returns the file of the repository
+func (c *ScanCache) path(repoID string) string {
+	return filepath.Join(c.dir, strings.ReplaceAll(repoID, "/", "__")+".json")
+}
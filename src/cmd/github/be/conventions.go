@@ -0,0 +1,135 @@
+package be
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConventions is the default profile: area-gaming namespaces and the
+// Ambassador keys of the manifest.yaml files
+var defaultConventions = github.Conventions{
+	Namespace: `area-gaming-{{.Service}}-{{.Env}}`,
+	Cluster:   `{{.Cluster}}`,
+	HostKey:   `ambassador_{{.Exposure}}`,
+	PathKey:   `{{if eq .Exposure "internal"}}{{if contains .Prefix "ping"}}ping_prefix{{else}}main_prefix{{end}}{{end}}`,
+	URLKey:    `{{.Exposure}}_{{if contains .Prefix "ping"}}ping{{else}}main{{end}}`,
+	URL:       `https://{{.Hostname}}{{.Prefix}}`,
+}
+
+// Functions of the convention templates
+var conventionFuncs = template.FuncMap{
+	"contains":   strings.Contains,
+	"hasPrefix":  strings.HasPrefix,
+	"hasSuffix":  strings.HasSuffix,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replace":    strings.ReplaceAll,
+	"split":      strings.Split,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+}
+
+// Parsed convention templates, keyed on their text
+var conventionTemplates sync.Map
+
+// namingData is the data of the namespace and cluster templates
+type namingData struct {
+	Project   string
+	Service   string
+	Env       string
+	EnvPrefix string
+	Region    string
+	Cluster   string
+}
+
+// exposureData is the data of the exposure templates
+type exposureData struct {
+	Exposure string
+	Hostname string
+	Prefix   string
+	Rewrite  string
+	Env      string
+}
+
+// ApplyConventions sets the conventions of the schema: the default profile, overridden by the
+// conventions of the schema file and then by the conventions file at path ("" or "default"
+// for none). Every template is parsed so that a mistake fails before the scan.
+func ApplyConventions(schema *github.ManifestSchema, path string) error {
+	conventions := defaultConventions
+	mergeConventions(&conventions, schema.Conventions)
+
+	if path != "" && path != "default" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read conventions file: %w", err)
+		}
+		var fromFile github.Conventions
+		if err := yaml.Unmarshal(data, &fromFile); err != nil {
+			return fmt.Errorf("failed to parse conventions file %s: %w", path, err)
+		}
+		mergeConventions(&conventions, fromFile)
+	}
+
+	for _, text := range []string{conventions.Namespace, conventions.Cluster, conventions.HostKey, conventions.PathKey, conventions.URLKey, conventions.URL} {
+		if _, err := conventionTemplate(text); err != nil {
+			return err
+		}
+	}
+	schema.Conventions = conventions
+	return nil
+}
+
+// mergeConventions overrides the conventions with the templates set in override
+func mergeConventions(conventions *github.Conventions, override github.Conventions) {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&conventions.Namespace, override.Namespace},
+		{&conventions.Cluster, override.Cluster},
+		{&conventions.HostKey, override.HostKey},
+		{&conventions.PathKey, override.PathKey},
+		{&conventions.URLKey, override.URLKey},
+		{&conventions.URL, override.URL},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+}
+
+// renderConvention executes a convention template, the default one when text is empty
+func renderConvention(text string, fallback string, data interface{}) (string, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := conventionTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render convention %q: %w", text, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// conventionTemplate returns the parsed template of the text
+func conventionTemplate(text string) (*template.Template, error) {
+	if cached, ok := conventionTemplates.Load(text); ok {
+		return cached.(*template.Template), nil
+	}
+	tmpl, err := template.New("convention").Funcs(conventionFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid convention template %q: %w", text, err)
+	}
+	conventionTemplates.Store(text, tmpl)
+	return tmpl, nil
+}
@@ -0,0 +1,72 @@
+package be
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractExposureInfo_DefaultConventions(t *testing.T) {
+	// Arrange: Internal and external Ambassador mappings
+	outsideCluster := &github.OutsideCluster{
+		AmbassadorInternal: []github.AmbassadorMapping{
+			{Hostname: "api.internal", Prefix: "/gpi/"},
+			{Hostname: "api.internal", Prefix: "/gpi/ping"},
+		},
+		AmbassadorExternal: []github.AmbassadorMapping{{Hostname: "api.example.com", Prefix: "/gpi/"}},
+	}
+
+	// Act: Extract with the default profile
+	hosts, paths, urls, err := extractExposureInfo(github.Conventions{}, outsideCluster, "prod-eu")
+
+	// Assert: The keys of the area-gaming manifests
+	assert.NoError(t, err, "Extraction should not return an error")
+	assert.Equal(t, map[string]string{"ambassador_internal": "api.internal", "ambassador_external": "api.example.com"}, hosts, "Hosts should match")
+	assert.Equal(t, map[string]string{"main_prefix": "/gpi/", "ping_prefix": "/gpi/ping"}, paths, "Paths should match")
+	assert.Equal(t, map[string]string{
+		"internal_main": "https://api.internal/gpi/",
+		"internal_ping": "https://api.internal/gpi/ping",
+		"external_main": "https://api.example.com/gpi/",
+	}, urls, "URLs should match")
+}
+
+func TestApplyConventions_File(t *testing.T) {
+	// Arrange: A conventions file of another business area
+	path := filepath.Join(t.TempDir(), "conventions.yaml")
+	err := os.WriteFile(path, []byte(`
+namespace: "payments-{{.Project}}-{{.EnvPrefix}}{{.Region}}"
+cluster: "{{.Cluster}}-{{.Region}}"
+`), 0644)
+	assert.NoError(t, err, "Conventions file should be written")
+	schema, _ := LoadManifestSchema("manifest", "")
+
+	// Act: Apply the file and parse a manifest
+	applyErr := ApplyConventions(&schema, path)
+	subproject, parseErr := parseManifest(schemaRepo, "apps/card/manifest.yaml", []byte(`
+name: card
+environments:
+  prod-eu:
+    clusters: [aks]
+`), nil, schema)
+
+	// Assert: The file templates are used, the others keep the default profile
+	assert.NoError(t, applyErr, "Conventions should apply")
+	assert.NoError(t, parseErr, "Parse should not return an error")
+	assert.Equal(t, "payments-card-prod-eu", subproject.Deployments["prod-|eu"].Namespace, "Namespace should match")
+	assert.Equal(t, "aks-eu", subproject.Deployments["prod-|eu"].Cluster, "Cluster should match")
+	assert.Equal(t, defaultConventions.URL, schema.Conventions.URL, "Unset templates should keep the default")
+}
+
+func TestApplyConventions_InvalidTemplate(t *testing.T) {
+	// Arrange: A schema with a broken template
+	schema := github.ManifestSchema{Conventions: github.Conventions{Namespace: "{{.Project"}}
+
+	// Act: Apply the conventions
+	err := ApplyConventions(&schema, "default")
+
+	// Assert: The mistake fails before the scan
+	assert.Error(t, err, "Invalid template should return an error")
+}
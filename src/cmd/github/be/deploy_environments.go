@@ -2,14 +2,16 @@ package be
 
 import (
 	"fmt"
+	"log/slog"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	githubHelper "github.com/eltiocaballoloco/sinaloa-cli/src/helpers/github"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
-	"gopkg.in/yaml.v3"
 )
 
 // ParseEnvironmentName parses an environment name into prefix and region
@@ -51,12 +53,15 @@ func MatchesEnvFilter(envName string, envFilters []string) bool {
 	return false
 }
 
-// ProcessRepository processes a single repository and extracts deployment information
+// ProcessRepository processes a single repository and extracts deployment information.
+// With a cache, a repository whose default branch did not move is not scanned again.
 func ProcessRepository(
 	repo github.GitHubAPIRepository,
 	folders []string,
 	manifestName string,
 	envFilters []string,
+	schema github.ManifestSchema,
+	cache *ScanCache,
 ) (*github.RepoData, error) {
 
 	// Get the latest commit SHA
@@ -65,6 +70,29 @@ func ProcessRepository(
 		return nil, fmt.Errorf("failed to get commit SHA: %w", err)
 	}
 
+	// Unchanged repository, reuse the last scan
+	if repoData, ok := cache.Get(repo.FullName, commitSHA); ok {
+		return repoData, nil
+	}
+
+	repoData, err := scanRepository(repo, commitSHA, folders, manifestName, envFilters, schema)
+	if err != nil {
+		return nil, err
+	}
+	cache.Put(repo.FullName, commitSHA, repoData)
+	return repoData, nil
+}
+
+// scanRepository reads the tree and the manifests of the repository at commitSHA
+func scanRepository(
+	repo github.GitHubAPIRepository,
+	commitSHA string,
+	folders []string,
+	manifestName string,
+	envFilters []string,
+	schema github.ManifestSchema,
+) (*github.RepoData, error) {
+
 	// Get repository tree
 	tree, err := githubHelper.GetRepoTree(repo.FullName, commitSHA)
 	if err != nil {
@@ -82,22 +110,27 @@ func ProcessRepository(
 	subprojects := make(map[string]github.Subproject)
 
 	for _, manifestPath := range manifestPaths {
-		subproject, err := processManifest(repo, manifestPath, commitSHA, envFilters)
+		subproject, err := processManifest(repo, manifestPath, commitSHA, envFilters, schema)
 		if err != nil {
-			fmt.Printf("Warning: failed to process manifest %s: %v\n", manifestPath, err)
+			slog.Warn("Failed to process manifest", "repo", repo.FullName, "path", manifestPath, "error", err)
 			continue
 		}
 
 		if subproject != nil {
-			subprojects[subproject.ProjectID] = *subproject
+			addSubproject(subprojects, subproject, repo.FullName)
 		}
 	}
 
+	return newRepoData(repo, commitSHA, subprojects), nil
+}
+
+// newRepoData returns the data of a repository, nil when it has no subprojects
+func newRepoData(repo github.GitHubAPIRepository, commitSHA string, subprojects map[string]github.Subproject) *github.RepoData {
 	if len(subprojects) == 0 {
-		return nil, nil // No valid subprojects found
+		return nil // No valid subprojects found
 	}
 
-	repoData := &github.RepoData{
+	return &github.RepoData{
 		RepoID:        repo.FullName,
 		RepoName:      repo.Name,
 		RepoURL:       repo.HTMLURL,
@@ -105,8 +138,6 @@ func ProcessRepository(
 		LastCommitSHA: commitSHA,
 		Subprojects:   subprojects,
 	}
-
-	return repoData, nil
 }
 
 // findManifestPaths finds all manifest.yaml files in the specified folders
@@ -119,23 +150,31 @@ func findManifestPaths(tree *github.GitTree, folders []string, manifestName stri
 		}
 
 		// Check if the file is a manifest in one of the target folders
-		for _, folder := range folders {
-			if strings.HasPrefix(item.Path, folder+"/") && strings.HasSuffix(item.Path, "/"+manifestName) {
-				paths = append(paths, item.Path)
-				break
-			}
+		if isManifestPath(item.Path, folders, manifestName) {
+			paths = append(paths, item.Path)
 		}
 	}
 
 	return paths
 }
 
+// isManifestPath returns true if the file is a manifest in one of the target folders
+func isManifestPath(filePath string, folders []string, manifestName string) bool {
+	for _, folder := range folders {
+		if strings.HasPrefix(filePath, folder+"/") && strings.HasSuffix(filePath, "/"+manifestName) {
+			return true
+		}
+	}
+	return false
+}
+
 // processManifest processes a single manifest file
 func processManifest(
 	repo github.GitHubAPIRepository,
 	manifestPath string,
 	commitSHA string,
 	envFilters []string,
+	schema github.ManifestSchema,
 ) (*github.Subproject, error) {
 
 	// Fetch manifest content
@@ -144,15 +183,33 @@ func processManifest(
 		return nil, fmt.Errorf("failed to get manifest content: %w", err)
 	}
 
-	// Parse YAML
-	var manifest github.Manifest
-	if err := yaml.Unmarshal(content, &manifest); err != nil {
+	return parseManifest(repo, manifestPath, content, envFilters, schema)
+}
+
+// parseManifest extracts the deployments of a manifest file with the schema
+func parseManifest(
+	repo github.GitHubAPIRepository,
+	manifestPath string,
+	content []byte,
+	envFilters []string,
+	schema github.ManifestSchema,
+) (*github.Subproject, error) {
+
+	// Parse YAML (every document of the file)
+	documents, err := decodeDocuments(content)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
 	// Extract project info
-	folderPath := strings.TrimSuffix(manifestPath, "/manifest.yaml")
-	projectID := manifest.Name
+	folderPath := path.Dir(manifestPath)
+	displayName := ""
+	for _, document := range documents {
+		if displayName = lookupString(document, schema.Name, manifestPath); displayName != "" {
+			break
+		}
+	}
+	projectID := displayName
 	if projectID == "" {
 		// Use folder name as fallback
 		parts := strings.Split(folderPath, "/")
@@ -163,42 +220,69 @@ func processManifest(
 	deployments := make(map[string]github.DeploymentDetail)
 	summary := make(map[string][]string)
 
-	for envName, env := range manifest.Environments {
-		// Skip if doesn't match env filters
-		if !MatchesEnvFilter(envName, envFilters) {
-			continue
-		}
+	for _, document := range documents {
+		for _, env := range schemaEnvironments(schema, document, manifestPath) {
+			envName := env.name
 
-		// Skip if no clusters (Pattern 3: micro-frontends)
-		if len(env.Clusters) == 0 {
-			continue
-		}
+			// Skip if doesn't match env filters
+			if !MatchesEnvFilter(envName, envFilters) {
+				continue
+			}
 
-		// Parse environment name
-		prefix, region := ParseEnvironmentName(envName)
-		deployKey := BuildDeployKey(prefix, region)
+			// Skip if no clusters (Pattern 3: micro-frontends)
+			clusters := lookupStrings(env.node, schema.Clusters, manifestPath)
+			if schema.Clusters != "" && len(clusters) == 0 {
+				continue
+			}
 
-		// Build deployment detail
-		deployment := github.DeploymentDetail{
-			EnvPrefix: prefix,
-			Region:    region,
-			Cluster:   env.Clusters[0], // Take first cluster
-			Namespace: buildNamespace(projectID, envName, region),
-		}
+			// Parse environment name
+			prefix, region := ParseEnvironmentName(envName)
+			deployKey := BuildDeployKey(prefix, region)
 
-		// Extract hosts and URLs if available
-		if env.Expose != nil && env.Expose.OutsideCluster != nil {
-			deployment.Hosts, deployment.Paths, deployment.URLs = extractExposureInfo(env.Expose.OutsideCluster, envName)
-		}
+			// Build deployment detail
+			deployment := github.DeploymentDetail{
+				EnvPrefix: prefix,
+				Region:    region,
+				Namespace: lookupString(env.node, schema.Namespace, manifestPath),
+			}
+			if len(clusters) > 0 {
+				cluster := clusters[0] // Take first cluster
+				deployment.Cluster, err = renderConvention(schema.Conventions.Cluster, defaultConventions.Cluster, newNamingData(projectID, envName, region, cluster))
+				if err != nil {
+					return nil, err
+				}
+			}
+			if deployment.Namespace == "" {
+				deployment.Namespace, err = buildNamespace(schema.Conventions, projectID, envName, region, deployment.Cluster)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if replicas, err := strconv.Atoi(lookupString(env.node, schema.Replicas, manifestPath)); err == nil {
+				deployment.Replicas = replicas
+			}
+
+			// Extract hosts and URLs if available
+			if schema.Exposure != "" {
+				if outsideCluster := schemaExposure(env.node, schema.Exposure); outsideCluster != nil {
+					deployment.Hosts, deployment.Paths, deployment.URLs, err = extractExposureInfo(schema.Conventions, outsideCluster, envName)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+			addSchemaValues(&deployment.Hosts, env.node, schema.Hosts, manifestPath)
+			addSchemaValues(&deployment.URLs, env.node, schema.URLs, manifestPath)
 
-		deployments[deployKey] = deployment
+			deployments[deployKey] = deployment
 
-		// Add to summary
-		if summary[prefix] == nil {
-			summary[prefix] = []string{}
-		}
-		if !contains(summary[prefix], region) {
-			summary[prefix] = append(summary[prefix], region)
+			// Add to summary
+			if summary[prefix] == nil {
+				summary[prefix] = []string{}
+			}
+			if !contains(summary[prefix], region) {
+				summary[prefix] = append(summary[prefix], region)
+			}
 		}
 	}
 
@@ -208,89 +292,145 @@ func processManifest(
 
 	subproject := &github.Subproject{
 		ProjectID:    projectID,
-		DisplayName:  manifest.Name,
+		DisplayName:  displayName,
 		FolderPath:   folderPath,
 		ManifestPath: manifestPath,
 		Summary:      summary,
 		Deployments:  deployments,
-		SearchBlob:   buildSearchBlob(repo.FullName, projectID, manifest.Name, summary, deployments),
+		SearchBlob:   buildSearchBlob(repo.FullName, projectID, displayName, summary, deployments),
 	}
 
 	return subproject, nil
 }
 
+// addSchemaValues adds the values of the schema paths (hosts or urls) found in the environment
+func addSchemaValues(values *map[string]string, node interface{}, paths map[string]string, manifestPath string) {
+	for key, expr := range paths {
+		value := lookupString(node, expr, manifestPath)
+		if value == "" {
+			continue
+		}
+		if *values == nil {
+			*values = make(map[string]string)
+		}
+		(*values)[key] = value
+	}
+}
+
+// addSubproject adds the subproject of a manifest to the repository. Schemas with a file
+// per environment give the same project more than once, their deployments are merged.
+func addSubproject(subprojects map[string]github.Subproject, subproject *github.Subproject, repoID string) {
+	existing, ok := subprojects[subproject.ProjectID]
+	if !ok {
+		subprojects[subproject.ProjectID] = *subproject
+		return
+	}
+
+	for deployKey, deployment := range subproject.Deployments {
+		existing.Deployments[deployKey] = deployment
+	}
+	for prefix, regions := range subproject.Summary {
+		for _, region := range regions {
+			if !contains(existing.Summary[prefix], region) {
+				existing.Summary[prefix] = append(existing.Summary[prefix], region)
+			}
+		}
+	}
+	existing.SearchBlob = buildSearchBlob(repoID, existing.ProjectID, existing.DisplayName, existing.Summary, existing.Deployments)
+	subprojects[subproject.ProjectID] = existing
+}
+
 // buildNamespace constructs the namespace from project and environment info
-func buildNamespace(projectID, envName, region string) string {
-	// Pattern: area-gaming-<project>-<env>-<region>
-	// or area-gaming-<project>-<env> for default region
+// with the namespace convention (default area-gaming-<service>-<env>)
+func buildNamespace(conventions github.Conventions, projectID, envName, region, cluster string) (string, error) {
+	return renderConvention(conventions.Namespace, defaultConventions.Namespace, newNamingData(projectID, envName, region, cluster))
+}
 
+// newNamingData returns the data of the namespace and cluster templates
+func newNamingData(projectID, envName, region, cluster string) namingData {
 	// Extract service name from project ID (e.g., "gpi-pragmaticplay" -> "gpi")
 	parts := strings.Split(projectID, "-")
-	service := parts[0]
+	prefix, _ := ParseEnvironmentName(envName)
 
-	if region == "default" {
-		return fmt.Sprintf("area-gaming-%s-%s", service, envName)
+	return namingData{
+		Project:   projectID,
+		Service:   parts[0],
+		Env:       envName,
+		EnvPrefix: prefix,
+		Region:    region,
+		Cluster:   cluster,
 	}
-
-	return fmt.Sprintf("area-gaming-%s-%s", service, envName)
 }
 
 // extractExposureInfo extracts hosts, paths, and URLs from exposure configuration
-func extractExposureInfo(outsideCluster *github.OutsideCluster, envName string) (
+// with the key and URL conventions
+func extractExposureInfo(conventions github.Conventions, outsideCluster *github.OutsideCluster, envName string) (
 	hosts map[string]string,
 	paths map[string]string,
 	urls map[string]string,
+	err error,
 ) {
 	hosts = make(map[string]string)
 	paths = make(map[string]string)
 	urls = make(map[string]string)
 
-	// Extract from ambassadorInternal
-	if len(outsideCluster.AmbassadorInternal) > 0 {
-		for i, mapping := range outsideCluster.AmbassadorInternal {
-			if mapping.Hostname != "" {
-				if i == 0 {
-					hosts["ambassador_internal"] = mapping.Hostname
-				}
-
-				if mapping.Prefix != "" {
-					key := "main_prefix"
-					if strings.Contains(mapping.Prefix, "ping") {
-						key = "ping_prefix"
-					}
-					paths[key] = mapping.Prefix
+	for _, exposure := range []struct {
+		name     string
+		mappings []github.AmbassadorMapping
+	}{
+		{"internal", outsideCluster.AmbassadorInternal},
+		{"external", outsideCluster.AmbassadorExternal},
+	} {
+		for i, mapping := range exposure.mappings {
+			if mapping.Hostname == "" {
+				continue
+			}
+			data := exposureData{
+				Exposure: exposure.name,
+				Hostname: mapping.Hostname,
+				Prefix:   mapping.Prefix,
+				Rewrite:  mapping.Rewrite,
+				Env:      envName,
+			}
 
-					// Build URL
-					urlKey := "internal_main"
-					if strings.Contains(mapping.Prefix, "ping") {
-						urlKey = "internal_ping"
-					}
-					urls[urlKey] = fmt.Sprintf("https://%s%s", mapping.Hostname, mapping.Prefix)
+			// The host of the first mapping
+			if i == 0 {
+				if err := addConvention(hosts, conventions.HostKey, defaultConventions.HostKey, data, mapping.Hostname); err != nil {
+					return nil, nil, nil, err
 				}
 			}
-		}
-	}
 
-	// Extract from ambassadorExternal
-	if len(outsideCluster.AmbassadorExternal) > 0 {
-		for i, mapping := range outsideCluster.AmbassadorExternal {
-			if mapping.Hostname != "" {
-				if i == 0 {
-					hosts["ambassador_external"] = mapping.Hostname
-				}
+			if mapping.Prefix == "" {
+				continue
+			}
+			if err := addConvention(paths, conventions.PathKey, defaultConventions.PathKey, data, mapping.Prefix); err != nil {
+				return nil, nil, nil, err
+			}
 
-				if mapping.Prefix != "" {
-					key := "external_main"
-					if strings.Contains(mapping.Prefix, "ping") {
-						key = "external_ping"
-					}
-					urls[key] = fmt.Sprintf("https://%s%s", mapping.Hostname, mapping.Prefix)
-				}
+			// Build URL
+			url, err := renderConvention(conventions.URL, defaultConventions.URL, data)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if err := addConvention(urls, conventions.URLKey, defaultConventions.URLKey, data, url); err != nil {
+				return nil, nil, nil, err
 			}
 		}
 	}
 
-	return hosts, paths, urls
+	return hosts, paths, urls, nil
+}
+
+// addConvention sets the value under the key rendered by the template, an empty key is skipped
+func addConvention(values map[string]string, keyTemplate string, fallback string, data exposureData, value string) error {
+	key, err := renderConvention(keyTemplate, fallback, data)
+	if err != nil {
+		return err
+	}
+	if key != "" && value != "" {
+		values[key] = value
+	}
+	return nil
 }
 
 // buildSearchBlob creates a searchable string from all relevant fields
@@ -336,7 +476,9 @@ func ProcessRepositoriesConcurrently(
 	folders []string,
 	manifestName string,
 	envFilters []string,
+	schema github.ManifestSchema,
 	maxWorkers int,
+	cache *ScanCache,
 ) map[string]github.RepoData {
 
 	jobs := make(chan github.GitHubAPIRepository, len(repos))
@@ -350,10 +492,10 @@ func ProcessRepositoriesConcurrently(
 		go func() {
 			defer wg.Done()
 			for repo := range jobs {
-				fmt.Printf("Processing repository: %s\n", repo.FullName)
-				repoData, err := ProcessRepository(repo, folders, manifestName, envFilters)
+				slog.Info("Processing repository", "repo", repo.FullName)
+				repoData, err := ProcessRepository(repo, folders, manifestName, envFilters, schema, cache)
 				if err != nil {
-					fmt.Printf("Error processing %s: %v\n", repo.FullName, err)
+					slog.Error("Failed to process repository", "repo", repo.FullName, "error", err)
 					continue
 				}
 				if repoData != nil {
@@ -0,0 +1,164 @@
+package be
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+)
+
+// DiffMatrices compares two deployment matrices: added and removed repositories and
+// subprojects, added and removed deploy keys and changed fields of the deployments
+func DiffMatrices(oldMatrix *github.DeploymentMatrix, newMatrix *github.DeploymentMatrix) github.MatrixDiff {
+	diff := github.MatrixDiff{
+		OldGeneratedAt:  oldMatrix.Meta.GeneratedAt,
+		NewGeneratedAt:  newMatrix.Meta.GeneratedAt,
+		AddedRepos:      []string{},
+		RemovedRepos:    []string{},
+		AddedProjects:   []github.ProjectRef{},
+		RemovedProjects: []github.ProjectRef{},
+		Projects:        []github.ProjectDiff{},
+	}
+
+	for _, repoID := range unionKeys(oldMatrix.Repos, newMatrix.Repos) {
+		oldRepo, inOld := oldMatrix.Repos[repoID]
+		newRepo, inNew := newMatrix.Repos[repoID]
+		switch {
+		case !inOld:
+			diff.AddedRepos = append(diff.AddedRepos, repoID)
+		case !inNew:
+			diff.RemovedRepos = append(diff.RemovedRepos, repoID)
+		}
+
+		for _, projectID := range unionKeys(oldRepo.Subprojects, newRepo.Subprojects) {
+			oldProject, inOld := oldRepo.Subprojects[projectID]
+			newProject, inNew := newRepo.Subprojects[projectID]
+			switch {
+			case !inOld:
+				diff.AddedProjects = append(diff.AddedProjects, github.ProjectRef{RepoID: repoID, ProjectID: projectID, Deploys: sortedKeys(newProject.Deployments)})
+			case !inNew:
+				diff.RemovedProjects = append(diff.RemovedProjects, github.ProjectRef{RepoID: repoID, ProjectID: projectID, Deploys: sortedKeys(oldProject.Deployments)})
+			default:
+				if projectDiff, changed := diffProject(repoID, projectID, oldProject, newProject); changed {
+					diff.Projects = append(diff.Projects, projectDiff)
+				}
+			}
+		}
+	}
+
+	diff.Changed = len(diff.AddedRepos)+len(diff.RemovedRepos)+len(diff.AddedProjects)+len(diff.RemovedProjects)+len(diff.Projects) > 0
+	return diff
+}
+
+// diffProject compares the deployments of a subproject in both matrices
+func diffProject(repoID string, projectID string, oldProject github.Subproject, newProject github.Subproject) (github.ProjectDiff, bool) {
+	projectDiff := github.ProjectDiff{RepoID: repoID, ProjectID: projectID}
+
+	for _, deployKey := range unionKeys(oldProject.Deployments, newProject.Deployments) {
+		oldDeployment, inOld := oldProject.Deployments[deployKey]
+		newDeployment, inNew := newProject.Deployments[deployKey]
+		switch {
+		case !inOld:
+			projectDiff.AddedDeployKeys = append(projectDiff.AddedDeployKeys, deployKey)
+		case !inNew:
+			projectDiff.RemovedDeployKeys = append(projectDiff.RemovedDeployKeys, deployKey)
+		default:
+			projectDiff.Changes = append(projectDiff.Changes, diffDeployment(deployKey, oldDeployment, newDeployment)...)
+		}
+	}
+
+	changed := len(projectDiff.AddedDeployKeys)+len(projectDiff.RemovedDeployKeys)+len(projectDiff.Changes) > 0
+	return projectDiff, changed
+}
+
+// diffDeployment returns the changed fields of a deployment
+func diffDeployment(deployKey string, oldDeployment github.DeploymentDetail, newDeployment github.DeploymentDetail) []github.DeploymentChange {
+	var changes []github.DeploymentChange
+	add := func(field string, oldValue string, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, github.DeploymentChange{DeployKey: deployKey, Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("cluster", oldDeployment.Cluster, newDeployment.Cluster)
+	add("namespace", oldDeployment.Namespace, newDeployment.Namespace)
+	if oldDeployment.Replicas != newDeployment.Replicas {
+		add("replicas", fmt.Sprint(oldDeployment.Replicas), fmt.Sprint(newDeployment.Replicas))
+	}
+	for _, group := range []struct {
+		name     string
+		old, new map[string]string
+	}{
+		{"hosts", oldDeployment.Hosts, newDeployment.Hosts},
+		{"paths", oldDeployment.Paths, newDeployment.Paths},
+		{"urls", oldDeployment.URLs, newDeployment.URLs},
+	} {
+		for _, key := range unionKeys(group.old, group.new) {
+			add(group.name+"."+key, group.old[key], group.new[key])
+		}
+	}
+	return changes
+}
+
+// FormatMatrixDiff returns the human summary of the diff, markdown friendly for chat channels
+func FormatMatrixDiff(diff github.MatrixDiff) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Deployment matrix diff (%s -> %s)\n", diff.OldGeneratedAt, diff.NewGeneratedAt)
+	if !diff.Changed {
+		b.WriteString("No changes\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Repositories: +%d -%d | Projects: +%d -%d | Changed projects: %d\n",
+		len(diff.AddedRepos), len(diff.RemovedRepos), len(diff.AddedProjects), len(diff.RemovedProjects), len(diff.Projects))
+
+	for _, repoID := range diff.AddedRepos {
+		fmt.Fprintf(&b, "\n+ repo %s", repoID)
+	}
+	for _, repoID := range diff.RemovedRepos {
+		fmt.Fprintf(&b, "\n- repo %s", repoID)
+	}
+	for _, project := range diff.AddedProjects {
+		fmt.Fprintf(&b, "\n+ project %s/%s [%s]", project.RepoID, project.ProjectID, strings.Join(project.Deploys, ", "))
+	}
+	for _, project := range diff.RemovedProjects {
+		fmt.Fprintf(&b, "\n- project %s/%s [%s]", project.RepoID, project.ProjectID, strings.Join(project.Deploys, ", "))
+	}
+	for _, project := range diff.Projects {
+		fmt.Fprintf(&b, "\n~ project %s/%s", project.RepoID, project.ProjectID)
+		for _, deployKey := range project.AddedDeployKeys {
+			fmt.Fprintf(&b, "\n    + %s", deployKey)
+		}
+		for _, deployKey := range project.RemovedDeployKeys {
+			fmt.Fprintf(&b, "\n    - %s", deployKey)
+		}
+		for _, change := range project.Changes {
+			fmt.Fprintf(&b, "\n    ~ %s %s: %q -> %q", change.DeployKey, change.Field, change.Old, change.New)
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// unionKeys returns the sorted keys of both maps
+func unionKeys[V any](a map[string]V, b map[string]V) []string {
+	set := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		set[key] = true
+	}
+	for key := range b {
+		set[key] = true
+	}
+	return sortedKeys(set)
+}
+
+// sortedKeys returns the sorted keys of a map
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
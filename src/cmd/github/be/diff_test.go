@@ -0,0 +1,58 @@
+package be
+
+import (
+	"testing"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// diffMatrix returns a matrix with a project per repository
+func diffMatrix(generatedAt string, projects map[string]map[string]github.DeploymentDetail) *github.DeploymentMatrix {
+	matrix := &github.DeploymentMatrix{Meta: github.Meta{GeneratedAt: generatedAt}, Repos: map[string]github.RepoData{}}
+	for repoID, deployments := range projects {
+		matrix.Repos[repoID] = github.RepoData{
+			RepoID:      repoID,
+			Subprojects: map[string]github.Subproject{"api": {ProjectID: "api", Deployments: deployments}},
+		}
+	}
+	return matrix
+}
+
+func TestDiffMatrices(t *testing.T) {
+	// Arrange: A removed repository, an added one and a changed project
+	oldMatrix := diffMatrix("day-1", map[string]map[string]github.DeploymentDetail{
+		"org/old": {"prod-|eu": {Cluster: "aks-1"}},
+		"org/app": {
+			"prod-|eu": {Cluster: "aks-1", Namespace: "app", Hosts: map[string]string{"ambassador_internal": "a.internal"}},
+			"qa-|eu":   {Cluster: "aks-qa"},
+		},
+	})
+	newMatrix := diffMatrix("day-2", map[string]map[string]github.DeploymentDetail{
+		"org/new": {"prod-|us": {Cluster: "aks-2"}},
+		"org/app": {
+			"prod-|eu": {Cluster: "aks-2", Namespace: "app", Hosts: map[string]string{"ambassador_internal": "b.internal"}},
+			"prod-|us": {Cluster: "aks-us"},
+		},
+	})
+
+	// Act: Compare the matrices
+	diff := DiffMatrices(oldMatrix, newMatrix)
+	same := DiffMatrices(oldMatrix, oldMatrix)
+
+	// Assert: Every kind of change is reported
+	assert.True(t, diff.Changed, "Diff should report changes")
+	assert.Equal(t, []string{"org/new"}, diff.AddedRepos, "Added repositories should match")
+	assert.Equal(t, []string{"org/old"}, diff.RemovedRepos, "Removed repositories should match")
+	assert.Equal(t, []github.ProjectRef{{RepoID: "org/new", ProjectID: "api", Deploys: []string{"prod-|us"}}}, diff.AddedProjects, "Added projects should match")
+	assert.Len(t, diff.Projects, 1, "Changed projects should match")
+	assert.Equal(t, []string{"prod-|us"}, diff.Projects[0].AddedDeployKeys, "Added deploy keys should match")
+	assert.Equal(t, []string{"qa-|eu"}, diff.Projects[0].RemovedDeployKeys, "Removed deploy keys should match")
+	assert.Equal(t, []github.DeploymentChange{
+		{DeployKey: "prod-|eu", Field: "cluster", Old: "aks-1", New: "aks-2"},
+		{DeployKey: "prod-|eu", Field: "hosts.ambassador_internal", Old: "a.internal", New: "b.internal"},
+	}, diff.Projects[0].Changes, "Changed fields should match")
+	assert.Contains(t, FormatMatrixDiff(diff), `~ prod-|eu cluster: "aks-1" -> "aks-2"`, "Summary should list the changes")
+	assert.False(t, same.Changed, "Same matrix should have no changes")
+	assert.Contains(t, FormatMatrixDiff(same), "No changes", "Summary should report no changes")
+}
@@ -0,0 +1,117 @@
+package be
+
+import (
+	"log/slog"
+
+	githubHelper "github.com/eltiocaballoloco/sinaloa-cli/src/helpers/github"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+)
+
+// ProcessRepositoriesGraphQL processes multiple repositories with batched GraphQL queries:
+// one query reads the default-branch commit and the folder trees of 25 repositories and
+// one query reads 50 manifests, instead of three REST calls per repository. Repositories
+// (or manifests) that GraphQL cannot read, or whose folders are deeper than the trees
+// read by the query, are processed with the REST api.
+func ProcessRepositoriesGraphQL(
+	repos []github.GitHubAPIRepository,
+	folders []string,
+	manifestName string,
+	envFilters []string,
+	schema github.ManifestSchema,
+	maxWorkers int,
+	cache *ScanCache,
+) map[string]github.RepoData {
+
+	snapshots, err := githubHelper.GetRepoSnapshots(repos, folders, SchemaDepth(schema))
+	if err != nil {
+		slog.Warn("GraphQL scan failed, falling back to REST", "error", err)
+		return ProcessRepositoriesConcurrently(repos, folders, manifestName, envFilters, schema, maxWorkers, cache)
+	}
+
+	repoDataMap := make(map[string]github.RepoData)
+	var restRepos []github.GitHubAPIRepository
+	var pending []github.GitHubAPIRepository
+	var files []github.FileRef
+
+	for _, repo := range repos {
+		// Folders deeper than the snapshot are read with the recursive REST tree
+		snapshot, ok := snapshots[repo.FullName]
+		if !ok || snapshot.Truncated {
+			restRepos = append(restRepos, repo)
+			continue
+		}
+
+		// Unchanged repository, reuse the last scan
+		if repoData, ok := cache.Get(repo.FullName, snapshot.CommitSHA); ok {
+			if repoData != nil {
+				repoDataMap[repo.FullName] = *repoData
+			}
+			continue
+		}
+
+		manifestPaths := snapshotManifestPaths(snapshot, folders, manifestName)
+		if len(manifestPaths) == 0 {
+			cache.Put(repo.FullName, snapshot.CommitSHA, nil)
+			continue
+		}
+		pending = append(pending, repo)
+		for _, manifestPath := range manifestPaths {
+			files = append(files, github.FileRef{Repo: repo.FullName, Ref: snapshot.CommitSHA, Path: manifestPath})
+		}
+	}
+
+	contents, err := githubHelper.GetFileContents(files)
+	if err != nil {
+		slog.Warn("GraphQL manifest fetch failed, falling back to REST", "error", err)
+		contents = map[github.FileRef][]byte{}
+	}
+
+	for _, repo := range pending {
+		slog.Info("Processing repository", "repo", repo.FullName)
+		snapshot := snapshots[repo.FullName]
+		subprojects := make(map[string]github.Subproject)
+
+		for _, manifestPath := range snapshotManifestPaths(snapshot, folders, manifestName) {
+			var subproject *github.Subproject
+			var err error
+			if content, ok := contents[github.FileRef{Repo: repo.FullName, Ref: snapshot.CommitSHA, Path: manifestPath}]; ok {
+				subproject, err = parseManifest(repo, manifestPath, content, envFilters, schema)
+			} else {
+				subproject, err = processManifest(repo, manifestPath, snapshot.CommitSHA, envFilters, schema)
+			}
+			if err != nil {
+				slog.Warn("Failed to process manifest", "repo", repo.FullName, "path", manifestPath, "error", err)
+				continue
+			}
+			if subproject != nil {
+				addSubproject(subprojects, subproject, repo.FullName)
+			}
+		}
+
+		repoData := newRepoData(repo, snapshot.CommitSHA, subprojects)
+		cache.Put(repo.FullName, snapshot.CommitSHA, repoData)
+		if repoData != nil {
+			repoDataMap[repo.FullName] = *repoData
+		}
+	}
+
+	if len(restRepos) > 0 {
+		slog.Info("Repositories not readable with GraphQL, processing them with REST", "count", len(restRepos))
+		for repoID, repoData := range ProcessRepositoriesConcurrently(restRepos, folders, manifestName, envFilters, schema, maxWorkers, cache) {
+			repoDataMap[repoID] = repoData
+		}
+	}
+
+	return repoDataMap
+}
+
+// snapshotManifestPaths finds the manifest files in the target folders of a snapshot
+func snapshotManifestPaths(snapshot github.RepoSnapshot, folders []string, manifestName string) []string {
+	var paths []string
+	for _, filePath := range snapshot.Files {
+		if isManifestPath(filePath, folders, manifestName) {
+			paths = append(paths, filePath)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,35 @@
+package be
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+)
+
+// Dashboard of the deployment matrix, the matrix is embedded as JSON
+// and the page works offline as a single file
+//
+//go:embed templates/matrix.html
+var matrixHTML string
+
+var matrixTemplate = template.Must(template.New("matrix").Parse(matrixHTML))
+
+// RenderMatrixHTML returns the self-contained HTML dashboard of the matrix: search over the
+// search blobs, env prefix groups with a column per region and clickable hosts and URLs
+func RenderMatrixHTML(matrix *github.DeploymentMatrix) ([]byte, error) {
+	var buf bytes.Buffer
+	err := matrixTemplate.Execute(&buf, struct {
+		Organization string
+		Matrix       *github.DeploymentMatrix
+	}{
+		Organization: matrix.Meta.Source.Organization,
+		Matrix:       matrix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render HTML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,40 @@
+package be
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMatrixHTML(t *testing.T) {
+	// Arrange: A matrix with a value that tries to close the script
+	repoDataMap := map[string]github.RepoData{
+		"org/app": {
+			RepoID:   "org/app",
+			RepoName: "app",
+			Subprojects: map[string]github.Subproject{
+				"api": {
+					ProjectID:   "api",
+					DisplayName: "</script><script>alert(1)</script>",
+					Deployments: map[string]github.DeploymentDetail{
+						"prod-|eu": {EnvPrefix: "prod-", Region: "eu", Cluster: "aks-prod-eu"},
+					},
+					Summary: map[string][]string{"prod-": {"eu"}},
+				},
+			},
+		},
+	}
+	matrix := BuildDeploymentMatrix(repoDataMap, "Org", "", nil, nil)
+
+	// Act: Render the dashboard
+	html, err := RenderMatrixHTML(matrix)
+
+	// Assert: The page embeds the matrix as escaped JSON
+	assert.NoError(t, err, "Render should not return an error")
+	page := string(html)
+	assert.Contains(t, page, "<title>Deployment matrix - Org</title>", "Title should match")
+	assert.Contains(t, page, "aks-prod-eu", "Matrix should be embedded")
+	assert.Equal(t, 1, strings.Count(page, "</script>"), "Values should not close the script")
+}
@@ -0,0 +1,66 @@
+package be
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+)
+
+// FilterPushedSince returns the repositories pushed after since,
+// a repository without a valid pushed_at is always returned
+func FilterPushedSince(repos []github.GitHubAPIRepository, since time.Time) []github.GitHubAPIRepository {
+	var filtered []github.GitHubAPIRepository
+	for _, repo := range repos {
+		pushedAt, err := time.Parse(time.RFC3339, repo.PushedAt)
+		if err != nil || pushedAt.After(since) {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered
+}
+
+// LoadDeploymentMatrix reads a deployment matrix JSON generated by a previous run
+func LoadDeploymentMatrix(path string) (*github.DeploymentMatrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read matrix JSON: %w", err)
+	}
+	var matrix github.DeploymentMatrix
+	if err := json.Unmarshal(data, &matrix); err != nil {
+		return nil, fmt.Errorf("failed to parse matrix JSON %s: %w", path, err)
+	}
+	return &matrix, nil
+}
+
+// MergeRepoData merges the scan of the rescanned repositories into the repos of an
+// existing matrix: the rescanned repositories are replaced (or removed when they have
+// no deployments anymore) and the ones not listed anymore (deleted, renamed or
+// filtered out by the query) are dropped
+func MergeRepoData(
+	existing map[string]github.RepoData,
+	scanned map[string]github.RepoData,
+	rescanned []github.GitHubAPIRepository,
+	listed []github.GitHubAPIRepository,
+) map[string]github.RepoData {
+	listedSet := make(map[string]bool, len(listed))
+	for _, repo := range listed {
+		listedSet[repo.FullName] = true
+	}
+
+	merged := make(map[string]github.RepoData, len(existing))
+	for repoID, repoData := range existing {
+		if listedSet[repoID] {
+			merged[repoID] = repoData
+		}
+	}
+	for _, repo := range rescanned {
+		delete(merged, repo.FullName)
+	}
+	for repoID, repoData := range scanned {
+		merged[repoID] = repoData
+	}
+	return merged
+}
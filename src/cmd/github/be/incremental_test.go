@@ -0,0 +1,79 @@
+package be
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func apiRepo(fullName string, pushedAt string) github.GitHubAPIRepository {
+	return github.GitHubAPIRepository{FullName: fullName, PushedAt: pushedAt}
+}
+
+func TestFilterPushedSince(t *testing.T) {
+	// Arrange: Repositories pushed before and after since
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	repos := []github.GitHubAPIRepository{
+		apiRepo("org/old", "2024-04-01T10:00:00Z"),
+		apiRepo("org/new", "2024-05-02T10:00:00Z"),
+		apiRepo("org/unknown", ""),
+	}
+
+	// Act: Filter the repositories
+	filtered := FilterPushedSince(repos, since)
+
+	// Assert: Only the new one and the one without pushed_at are kept
+	assert.Equal(t, []github.GitHubAPIRepository{repos[1], repos[2]}, filtered, "Filtered repositories should match")
+}
+
+func TestMergeRepoData(t *testing.T) {
+	// Arrange: A previous matrix, a rescan of two repositories (one without deployments anymore)
+	existing := map[string]github.RepoData{
+		"org/a":       {RepoID: "org/a", LastCommitSHA: "a1"},
+		"org/b":       {RepoID: "org/b", LastCommitSHA: "b1"},
+		"org/c":       {RepoID: "org/c", LastCommitSHA: "c1"},
+		"org/deleted": {RepoID: "org/deleted", LastCommitSHA: "d1"},
+	}
+	scanned := map[string]github.RepoData{
+		"org/b": {RepoID: "org/b", LastCommitSHA: "b2"},
+	}
+	rescanned := []github.GitHubAPIRepository{apiRepo("org/b", ""), apiRepo("org/c", "")}
+	listed := []github.GitHubAPIRepository{apiRepo("org/a", ""), apiRepo("org/b", ""), apiRepo("org/c", "")}
+
+	// Act: Merge the rescan
+	merged := MergeRepoData(existing, scanned, rescanned, listed)
+
+	// Assert: Unchanged repositories are kept, rescanned ones replaced or removed
+	assert.Len(t, merged, 2, "Merged repositories should match")
+	assert.Equal(t, "a1", merged["org/a"].LastCommitSHA, "Unchanged repository should be kept")
+	assert.Equal(t, "b2", merged["org/b"].LastCommitSHA, "Rescanned repository should be replaced")
+	assert.NotContains(t, merged, "org/c", "Repository without deployments should be removed")
+	assert.NotContains(t, merged, "org/deleted", "Repository not listed anymore should be removed")
+}
+
+func TestScanCache(t *testing.T) {
+	// Arrange: A cache with a scanned repository
+	dir := t.TempDir()
+	cache, err := NewScanCache(dir, []string{"apps"}, "manifest.yaml", []string{"prod-"}, github.ManifestSchema{})
+	assert.NoError(t, err, "Cache should be created")
+	cache.Put("org/a", "sha1", &github.RepoData{RepoID: "org/a", LastCommitSHA: "sha1"})
+	cache.Put("org/empty", "sha1", nil)
+
+	// Act: Read it back at the same and at a new commit, and with other scan parameters
+	hit, ok := cache.Get("org/a", "sha1")
+	_, movedOk := cache.Get("org/a", "sha2")
+	empty, emptyOk := cache.Get("org/empty", "sha1")
+	other, _ := NewScanCache(dir, []string{"src"}, "manifest.yaml", []string{"prod-"}, github.ManifestSchema{})
+	_, otherOk := other.Get("org/a", "sha1")
+
+	// Assert: Only the same commit with the same parameters is a hit
+	assert.True(t, ok, "Same commit should be a hit")
+	assert.Equal(t, "org/a", hit.RepoID, "Cached repository should match")
+	assert.False(t, movedOk, "New commit should be a miss")
+	assert.True(t, emptyOk, "Repository without deployments should be cached too")
+	assert.Nil(t, empty, "Repository without deployments should have no data")
+	assert.False(t, otherOk, "Other scan parameters should be a miss")
+	assert.Equal(t, 2, cache.Hits(), "Hits should match")
+}
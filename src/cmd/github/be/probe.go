@@ -0,0 +1,135 @@
+package be
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	netBe "github.com/eltiocaballoloco/sinaloa-cli/src/cmd/net/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/net"
+)
+
+// Probes a URL, replaced in the tests
+var probeURL = netBe.Probe
+
+// probeTarget is a URL of a deployment of the matrix
+type probeTarget struct {
+	repoID    string
+	projectID string
+	deployKey string
+	urlKey    string
+	url       string
+}
+
+// ProbeMatrix probes the ping URLs of the matrix (every URL with all) with maxWorkers
+// concurrent requests, records the results in the deployments and returns the summary
+// of the broken endpoints per environment, also saved in the matrix meta
+func ProbeMatrix(matrix *github.DeploymentMatrix, all bool, maxWorkers int) github.ProbeSummary {
+	var targets []probeTarget
+	for repoID, repo := range matrix.Repos {
+		for projectID, project := range repo.Subprojects {
+			for deployKey, deployment := range project.Deployments {
+				for urlKey, url := range deployment.URLs {
+					if all || strings.Contains(urlKey, "ping") {
+						targets = append(targets, probeTarget{repoID, projectID, deployKey, urlKey, url})
+					}
+				}
+			}
+		}
+	}
+
+	// A URL shared by more deployments is probed once
+	var urls []string
+	urlIndex := map[string]int{}
+	for _, target := range targets {
+		if _, ok := urlIndex[target.url]; !ok {
+			urlIndex[target.url] = len(urls)
+			urls = append(urls, target.url)
+		}
+	}
+
+	// Probe concurrently, every worker writes its own results
+	results := make([]net.ProbeResult, len(urls))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < maxWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = probeURL(urls[i])
+			}
+		}()
+	}
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	summary := github.ProbeSummary{
+		CheckedAt:    time.Now().UTC().Format(time.RFC3339),
+		Total:        len(targets),
+		Environments: map[string]github.EnvironmentProbes{},
+	}
+	for _, target := range targets {
+		result := results[urlIndex[target.url]]
+
+		deployment := matrix.Repos[target.repoID].Subprojects[target.projectID].Deployments[target.deployKey]
+		if deployment.Probes == nil {
+			deployment.Probes = map[string]net.ProbeResult{}
+		}
+		deployment.Probes[target.urlKey] = result
+		matrix.Repos[target.repoID].Subprojects[target.projectID].Deployments[target.deployKey] = deployment
+
+		env := summary.Environments[target.deployKey]
+		env.Total++
+		if !result.OK {
+			summary.Broken++
+			env.Broken = append(env.Broken, github.BrokenProbe{
+				RepoID:    target.repoID,
+				ProjectID: target.projectID,
+				URLKey:    target.urlKey,
+				URL:       target.url,
+				Status:    result.StatusCode,
+				Error:     result.Error,
+			})
+		}
+		summary.Environments[target.deployKey] = env
+	}
+	for deployKey, env := range summary.Environments {
+		sort.Slice(env.Broken, func(i, j int) bool { return env.Broken[i].URL < env.Broken[j].URL })
+		if env.Broken == nil {
+			env.Broken = []github.BrokenProbe{}
+		}
+		summary.Environments[deployKey] = env
+	}
+
+	matrix.Meta.Probe = &summary
+	return summary
+}
+
+// FormatProbeSummary returns the human summary of the probes, broken endpoints per environment
+func FormatProbeSummary(summary github.ProbeSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Probed %d URLs, %d broken\n", summary.Total, summary.Broken)
+
+	deployKeys := sortedKeys(summary.Environments)
+	sort.SliceStable(deployKeys, func(i, j int) bool { return compareDeployKeys(deployKeys[i], deployKeys[j]) })
+	for _, deployKey := range deployKeys {
+		env := summary.Environments[deployKey]
+		fmt.Fprintf(&b, "\n%s: %d/%d OK", deployKey, env.Total-len(env.Broken), env.Total)
+		for _, broken := range env.Broken {
+			reason := broken.Error
+			if reason == "" {
+				reason = fmt.Sprintf("status %d", broken.Status)
+			}
+			fmt.Fprintf(&b, "\n  ✗ %s/%s %s %s (%s)", broken.RepoID, broken.ProjectID, broken.URLKey, broken.URL, reason)
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
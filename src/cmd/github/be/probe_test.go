@@ -0,0 +1,45 @@
+package be
+
+import (
+	"testing"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/net"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeMatrix(t *testing.T) {
+	// Arrange: Two projects sharing a broken ping URL and a main URL
+	oldProbe := probeURL
+	t.Cleanup(func() { probeURL = oldProbe })
+	probed := map[string]int{}
+	probeURL = func(url string) net.ProbeResult {
+		probed[url]++
+		if url == "https://broken/ping" {
+			return net.ProbeResult{URL: url, StatusCode: 503}
+		}
+		return net.ProbeResult{URL: url, OK: true, StatusCode: 200}
+	}
+	deployments := func(pingURL string) map[string]github.DeploymentDetail {
+		return map[string]github.DeploymentDetail{"prod-|eu": {URLs: map[string]string{
+			"internal_ping": pingURL,
+			"internal_main": "https://main/",
+		}}}
+	}
+	matrix := &github.DeploymentMatrix{Repos: map[string]github.RepoData{
+		"org/a": {Subprojects: map[string]github.Subproject{"api": {Deployments: deployments("https://broken/ping")}}},
+		"org/b": {Subprojects: map[string]github.Subproject{"web": {Deployments: deployments("https://broken/ping")}}},
+	}}
+
+	// Act: Probe the ping URLs with one worker
+	summary := ProbeMatrix(matrix, false, 1)
+
+	// Assert: Shared URLs are probed once and every deployment gets the result
+	assert.Equal(t, map[string]int{"https://broken/ping": 1}, probed, "Only ping URLs should be probed, once")
+	assert.Equal(t, 2, summary.Total, "Probed deployments should match")
+	assert.Equal(t, 2, summary.Broken, "Broken deployments should match")
+	assert.Len(t, summary.Environments["prod-|eu"].Broken, 2, "Broken endpoints per environment should match")
+	assert.Equal(t, 503, matrix.Repos["org/a"].Subprojects["api"].Deployments["prod-|eu"].Probes["internal_ping"].StatusCode, "Result should be recorded in the matrix")
+	assert.NotNil(t, matrix.Meta.Probe, "Summary should be saved in the matrix meta")
+	assert.Contains(t, FormatProbeSummary(summary), "prod-|eu: 0/2 OK", "Summary should list the environment")
+}
@@ -0,0 +1,327 @@
+package be
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	githubHelper "github.com/eltiocaballoloco/sinaloa-cli/src/helpers/github"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"gopkg.in/yaml.v3"
+)
+
+// $dir or $dir[N] in a schema expression
+var dirExprRe = regexp.MustCompile(`\$dir(?:\[(\d+)\])?`)
+
+// Built-in manifest schemas
+var manifestSchemas = map[string]github.ManifestSchema{
+	// manifest.yaml with environments.<name>.clusters and Ambassador exposure
+	"manifest": {
+		Name:         "name",
+		Environments: "environments",
+		Clusters:     "clusters",
+		Replicas:     "replicas",
+		Exposure:     "expose.outsideCluster",
+	},
+	// Helm values file per environment: <folder>/<project>/<env>/values.yaml
+	"helm": {
+		Name:            "fullnameOverride|nameOverride|$dir[1]",
+		EnvironmentName: "$dir[0]",
+		Clusters:        "global.cluster|cluster",
+		Replicas:        "replicaCount",
+		Namespace:       "namespaceOverride|global.namespace",
+		Hosts:           map[string]string{"ingress": "ingress.hosts[0].host"},
+	},
+	// Kustomize overlays: <folder>/<project>/overlays/<env>/kustomization.yaml
+	"kustomize": {
+		Name:            "$dir[2]",
+		EnvironmentName: "$dir[0]",
+		Namespace:       "namespace",
+	},
+	// ArgoCD ApplicationSet with list generators of env, cluster and namespace
+	"applicationset": {
+		Name:            "metadata.name",
+		Environments:    "spec.generators[*].list.elements",
+		EnvironmentName: "env|environment|name",
+		Clusters:        "cluster|server",
+		Namespace:       "namespace",
+	},
+}
+
+// manifestEnvironment is an environment found in a manifest file
+type manifestEnvironment struct {
+	name string
+	node interface{}
+}
+
+// ManifestSchemaNames returns the names of the built-in schemas
+func ManifestSchemaNames() []string {
+	var names []string
+	for name := range manifestSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadManifestSchema returns the built-in schema with the name or the schema in the YAML
+// file at the path. A non-empty section overrides the path of the environments.
+func LoadManifestSchema(nameOrPath string, section string) (github.ManifestSchema, error) {
+	if nameOrPath == "" {
+		nameOrPath = "manifest"
+	}
+
+	schema, ok := manifestSchemas[nameOrPath]
+	if !ok {
+		data, err := os.ReadFile(nameOrPath)
+		if err != nil {
+			return github.ManifestSchema{}, fmt.Errorf("unknown manifest schema %q (built-in: %s): %w",
+				nameOrPath, strings.Join(ManifestSchemaNames(), ", "), err)
+		}
+		if err := yaml.Unmarshal(data, &schema); err != nil {
+			return github.ManifestSchema{}, fmt.Errorf("failed to parse manifest schema %s: %w", nameOrPath, err)
+		}
+	}
+
+	if section != "" {
+		schema.Environments = section
+	}
+	if schema.Environments == "" && schema.EnvironmentName == "" {
+		return github.ManifestSchema{}, fmt.Errorf("manifest schema %s needs environments or environment_name", nameOrPath)
+	}
+	return schema, nil
+}
+
+// SchemaDepth returns the levels below the scanned folder needed to reach the manifests
+// of the schema: a manifest whose name uses $dir[N] is at least N+2 levels deep
+// (e.g. kustomize, <folder>/<project>/overlays/<env>/kustomization.yaml, is 4)
+func SchemaDepth(schema github.ManifestSchema) int {
+	depth := githubHelper.DefaultSnapshotDepth
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return depth
+	}
+	for _, match := range dirExprRe.FindAllStringSubmatch(string(data), -1) {
+		n := 0
+		if match[1] != "" {
+			n, _ = strconv.Atoi(match[1])
+		}
+		depth = max(depth, n+2)
+	}
+	return depth
+}
+
+// decodeDocuments parses every YAML document of a file
+func decodeDocuments(content []byte) ([]interface{}, error) {
+	var documents []interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if document != nil {
+			documents = append(documents, document)
+		}
+	}
+}
+
+// schemaEnvironments returns the environments of a document
+func schemaEnvironments(schema github.ManifestSchema, document interface{}, manifestPath string) []manifestEnvironment {
+	// A file per environment, named after the file or its content
+	if schema.Environments == "" {
+		name := lookupString(document, schema.EnvironmentName, manifestPath)
+		if name == "" {
+			return nil
+		}
+		return []manifestEnvironment{{name: name, node: document}}
+	}
+
+	var environments []manifestEnvironment
+	for _, value := range lookupPath(document, schema.Environments) {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			// Map of environments, the keys are the names (unless the map is a single item)
+			if schema.EnvironmentName != "" {
+				if name := lookupString(node, schema.EnvironmentName, manifestPath); name != "" {
+					environments = append(environments, manifestEnvironment{name: name, node: node})
+					continue
+				}
+			}
+			for name, env := range node {
+				environments = append(environments, manifestEnvironment{name: name, node: env})
+			}
+		case []interface{}:
+			// List of environments, named by environment_name
+			for _, item := range node {
+				if name := lookupString(item, schema.EnvironmentName, manifestPath); name != "" {
+					environments = append(environments, manifestEnvironment{name: name, node: item})
+				}
+			}
+		}
+	}
+	return environments
+}
+
+// lookupString returns the first alternative of the expression found as a scalar
+func lookupString(node interface{}, expr string, manifestPath string) string {
+	if expr == "" {
+		return ""
+	}
+	for _, alternative := range strings.Split(expr, "|") {
+		alternative = strings.TrimSpace(alternative)
+		if strings.HasPrefix(alternative, "$dir") {
+			if dir := pathDir(manifestPath, alternative); dir != "" {
+				return dir
+			}
+			continue
+		}
+		for _, value := range lookupPath(node, alternative) {
+			if s := scalarString(value); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// lookupStrings returns the scalars of the first alternative of the expression found,
+// a list is flattened (e.g. clusters: [a, b])
+func lookupStrings(node interface{}, expr string, manifestPath string) []string {
+	if expr == "" {
+		return nil
+	}
+	for _, alternative := range strings.Split(expr, "|") {
+		alternative = strings.TrimSpace(alternative)
+		if strings.HasPrefix(alternative, "$dir") {
+			if dir := pathDir(manifestPath, alternative); dir != "" {
+				return []string{dir}
+			}
+			continue
+		}
+		var values []string
+		for _, value := range lookupPath(node, alternative) {
+			if list, ok := value.([]interface{}); ok {
+				for _, item := range list {
+					if s := scalarString(item); s != "" {
+						values = append(values, s)
+					}
+				}
+			} else if s := scalarString(value); s != "" {
+				values = append(values, s)
+			}
+		}
+		if len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+// lookupPath evaluates a path expression (a.b[0].c, a[*].b) on a YAML document
+func lookupPath(node interface{}, expr string) []interface{} {
+	nodes := []interface{}{node}
+	if expr == "" || expr == "." {
+		return nodes
+	}
+
+	for _, segment := range strings.Split(expr, ".") {
+		key, indexes, _ := strings.Cut(segment, "[")
+		var next []interface{}
+		for _, n := range nodes {
+			if key != "" {
+				m, ok := n.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if n, ok = m[key]; !ok {
+					continue
+				}
+			}
+			next = append(next, lookupIndexes(n, indexes)...)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// lookupIndexes applies the list indexes of a segment ("0]", "*]", "0][1]")
+func lookupIndexes(node interface{}, indexes string) []interface{} {
+	if indexes == "" {
+		return []interface{}{node}
+	}
+	index, rest, _ := strings.Cut(indexes, "]")
+	rest = strings.TrimPrefix(rest, "[")
+
+	list, ok := node.([]interface{})
+	if !ok {
+		return nil
+	}
+	if index == "*" {
+		var nodes []interface{}
+		for _, item := range list {
+			nodes = append(nodes, lookupIndexes(item, rest)...)
+		}
+		return nodes
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= len(list) {
+		return nil
+	}
+	return lookupIndexes(list[i], rest)
+}
+
+// pathDir resolves $dir[N], the name of the N-th folder above the file
+func pathDir(manifestPath string, expr string) string {
+	n := 0
+	if index, ok := strings.CutPrefix(expr, "$dir["); ok {
+		var err error
+		if n, err = strconv.Atoi(strings.TrimSuffix(index, "]")); err != nil {
+			return ""
+		}
+	}
+	parts := strings.Split(path.Dir(manifestPath), "/")
+	if n < 0 || n >= len(parts) || parts[len(parts)-1-n] == "." {
+		return ""
+	}
+	return parts[len(parts)-1-n]
+}
+
+// scalarString returns a YAML scalar as a string, empty for maps and lists
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int, int64, float64, bool:
+		return fmt.Sprint(v)
+	default:
+		return ""
+	}
+}
+
+// schemaExposure decodes the Ambassador outsideCluster mappings of an environment
+func schemaExposure(node interface{}, expr string) *github.OutsideCluster {
+	for _, value := range lookupPath(node, expr) {
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			continue
+		}
+		var outsideCluster github.OutsideCluster
+		if err := yaml.Unmarshal(data, &outsideCluster); err == nil {
+			return &outsideCluster
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package be
+
+import (
+	"testing"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/stretchr/testify/assert"
+)
+
+var schemaRepo = github.GitHubAPIRepository{FullName: "org/app", Name: "app"}
+
+func TestParseManifest_Manifest(t *testing.T) {
+	// Arrange: A manifest.yaml with clusters, replicas and Ambassador exposure
+	content := []byte(`
+name: gpi-api
+environments:
+  prod-eu:
+    clusters: [aks-prod-eu]
+    replicas: 3
+    expose:
+      outsideCluster:
+        ambassadorInternal:
+          - hostname: api.internal
+            prefix: /gpi/
+  qa-eu:
+    clusters: [aks-qa-eu]
+  prod-assets: {}
+`)
+	schema, err := LoadManifestSchema("manifest", "")
+	assert.NoError(t, err, "Built-in schema should load")
+
+	// Act: Parse the manifest
+	subproject, err := parseManifest(schemaRepo, "apps/gpi/manifest.yaml", content, []string{"prod-"}, schema)
+
+	// Assert: Only the filtered environments with clusters are returned
+	assert.NoError(t, err, "Parse should not return an error")
+	assert.Equal(t, "gpi-api", subproject.ProjectID, "Project should match")
+	assert.Equal(t, "apps/gpi", subproject.FolderPath, "Folder should match")
+	assert.Len(t, subproject.Deployments, 1, "Deployments should match")
+	deployment := subproject.Deployments["prod-|eu"]
+	assert.Equal(t, "aks-prod-eu", deployment.Cluster, "Cluster should match")
+	assert.Equal(t, 3, deployment.Replicas, "Replicas should match")
+	assert.Equal(t, "https://api.internal/gpi/", deployment.URLs["internal_main"], "URL should match")
+}
+
+func TestParseManifest_ApplicationSet(t *testing.T) {
+	// Arrange: An ApplicationSet with a list generator
+	content := []byte(`
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: payments
+spec:
+  generators:
+    - list:
+        elements:
+          - env: prod-eu
+            cluster: aks-prod-eu
+            namespace: payments-prod
+          - env: qa-us
+            cluster: aks-qa-us
+`)
+	schema, _ := LoadManifestSchema("applicationset", "")
+
+	// Act: Parse the ApplicationSet
+	subproject, err := parseManifest(schemaRepo, "deploy/payments/appset.yaml", content, nil, schema)
+
+	// Assert: Every element is an environment
+	assert.NoError(t, err, "Parse should not return an error")
+	assert.Equal(t, "payments", subproject.ProjectID, "Project should match")
+	assert.Equal(t, "payments-prod", subproject.Deployments["prod-|eu"].Namespace, "Namespace should match")
+	assert.Equal(t, "aks-qa-us", subproject.Deployments["qa-|us"].Cluster, "Cluster should match")
+}
+
+func TestParseManifest_KustomizeOverlays(t *testing.T) {
+	// Arrange: Two overlays of the same project
+	schema, _ := LoadManifestSchema("kustomize", "")
+	subprojects := map[string]github.Subproject{}
+
+	// Act: Parse both overlays
+	for _, overlay := range []struct{ path, content string }{
+		{"apps/billing/overlays/prod-eu/kustomization.yaml", "namespace: billing-prod\n"},
+		{"apps/billing/overlays/qa-eu/kustomization.yaml", "namespace: billing-qa\n"},
+	} {
+		subproject, err := parseManifest(schemaRepo, overlay.path, []byte(overlay.content), nil, schema)
+		assert.NoError(t, err, "Parse should not return an error")
+		addSubproject(subprojects, subproject, schemaRepo.FullName)
+	}
+
+	// Assert: The environments come from the folders and are merged in one project
+	assert.Len(t, subprojects, 1, "Projects should match")
+	billing := subprojects["billing"]
+	assert.Equal(t, "billing-prod", billing.Deployments["prod-|eu"].Namespace, "Namespace should match")
+	assert.Equal(t, "billing-qa", billing.Deployments["qa-|eu"].Namespace, "Namespace should match")
+}
+
+func TestLoadManifestSchema_Section(t *testing.T) {
+	// Act: Override the environments path and load an unknown schema
+	schema, err := LoadManifestSchema("", "deploy.targets")
+	_, unknownErr := LoadManifestSchema("does-not-exist.yaml", "")
+
+	// Assert: The section replaces the environments path of the default schema
+	assert.NoError(t, err, "Default schema should load")
+	assert.Equal(t, "deploy.targets", schema.Environments, "Section should be honored")
+	assert.Equal(t, "clusters", schema.Clusters, "Other paths should be kept")
+	assert.Error(t, unknownErr, "Unknown schema should return an error")
+}
+
+func TestLookupPath(t *testing.T) {
+	// Arrange: A document with nested lists
+	documents, _ := decodeDocuments([]byte(`
+ingress:
+  hosts:
+    - host: a.example.com
+    - host: b.example.com
+`))
+
+	// Act & Assert: Indexes, wildcards, alternatives and folders
+	assert.Equal(t, "a.example.com", lookupString(documents[0], "ingress.hosts[0].host", ""), "Index should match")
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, lookupStrings(documents[0], "ingress.hosts[*].host", ""), "Wildcard should match")
+	assert.Equal(t, "b.example.com", lookupString(documents[0], "missing|ingress.hosts[1].host", ""), "Alternative should match")
+	assert.Equal(t, "api", lookupString(documents[0], "missing|$dir[1]", "apps/api/prod-eu/values.yaml"), "Folder should match")
+}
+
+func TestSchemaDepth(t *testing.T) {
+	// Act & Assert: The snapshot reaches the manifests named by $dir[N]
+	assert.Equal(t, 3, SchemaDepth(manifestSchemas["manifest"]), "Default depth should be kept")
+	assert.Equal(t, 3, SchemaDepth(manifestSchemas["helm"]), "helm is <project>/<env>/values.yaml")
+	assert.Equal(t, 4, SchemaDepth(manifestSchemas["kustomize"]), "kustomize is <project>/overlays/<env>/kustomization.yaml")
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	githubHelper "github.com/eltiocaballoloco/sinaloa-cli/src/helpers/github"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+)
+
+// Query terms completed whatever the repositories
+var queryFlagTerms = []string{"is:archived", "is:fork", "is:private", "is:public", "archived:false", "fork:false"}
+
+// CompleteOrganizations returns the organizations of the user for the shell completion
+func CompleteOrganizations() []string {
+	configureGitHub()
+	return helpers.CachedCompletions("github organizations "+helpers.AppConfig.GITHUB_API_URL, githubHelper.ListOrganizations)
+}
+
+// CompleteQuery returns the completions of the last term of a repository query: the names,
+// topics and languages of the repositories of the organization and the flag terms
+func CompleteQuery(organization string, toComplete string) []string {
+	if organization == "" {
+		return nil
+	}
+	configureGitHub()
+	terms := helpers.CachedCompletions("github query terms "+helpers.AppConfig.GITHUB_API_URL+" "+organization, func() ([]string, error) {
+		githubHelper.SetOrganization(organization)
+		repos, err := githubHelper.ListRepositories(organization)
+		if err != nil {
+			return nil, err
+		}
+		return queryTerms(repos), nil
+	})
+
+	// Only the last term is completed, keeping the previous ones and the negation
+	prefix := ""
+	if i := strings.LastIndex(toComplete, " "); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+	if strings.HasPrefix(toComplete[len(prefix):], "-") {
+		prefix += "-"
+	}
+
+	var completions []string
+	for _, term := range append(terms, queryFlagTerms...) {
+		completions = append(completions, prefix+term)
+	}
+	return completions
+}
+
+// queryTerms returns the names of the repositories and the topic: and language: terms
+func queryTerms(repos []github.GitHubAPIRepository) []string {
+	seen := map[string]bool{}
+	var terms []string
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	for _, repo := range repos {
+		add(repo.Name)
+	}
+	sort.Strings(terms)
+
+	var qualified []string
+	for _, repo := range repos {
+		for _, topic := range repo.Topics {
+			qualified = append(qualified, "topic:"+topic)
+		}
+		if repo.Language != "" {
+			qualified = append(qualified, "language:"+strings.ToLower(repo.Language))
+		}
+	}
+	sort.Strings(qualified)
+	for _, term := range qualified {
+		add(term)
+	}
+	return terms
+}
@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	githubHelper "github.com/eltiocaballoloco/sinaloa-cli/src/helpers/github"
+)
+
+// configureGitHub passes the GitHub settings of the loaded configuration to the api helpers
+func configureGitHub() {
+	githubHelper.Configure(githubHelper.Config{
+		Token:             helpers.AppConfig.GITHUB_TOKEN,
+		APIURL:            helpers.AppConfig.GITHUB_API_URL,
+		AppID:             helpers.AppConfig.GITHUB_APP_ID,
+		AppPrivateKey:     helpers.AppConfig.GITHUB_APP_PRIVATE_KEY,
+		AppPrivateKeyPath: helpers.AppConfig.GITHUB_APP_PRIVATE_KEY_PATH,
+		AppInstallationID: helpers.AppConfig.GITHUB_APP_INSTALLATION_ID,
+	})
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	githubHelper "github.com/eltiocaballoloco/sinaloa-cli/src/helpers/github"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
 )
 
 func ReposDeployEnvironments(
@@ -19,7 +22,15 @@ func ReposDeployEnvironments(
 	folders string,
 	saveJSON bool,
 	savePathJSON string,
+	format string,
+	outPath string,
+	options github.ScanOptions,
 ) ([]byte, error) {
+	configureGitHub()
+
+	if format != "json" && format != "html" {
+		return nil, fmt.Errorf("invalid format %q: expected json or html", format)
+	}
 
 	// Parse comma-separated values
 	envFilters := parseCommaSeparated(envs)
@@ -32,7 +43,53 @@ func ReposDeployEnvironments(
 	fmt.Printf("Folders to scan: %v\n", folderList)
 	fmt.Printf("Manifest name: %s\n", manifestName)
 
-	// Step 1: Fetch repositories
+	// Schema mapping the manifests to the matrix, --manifest-section overrides its environments
+	schema, err := be.LoadManifestSchema(options.Schema, manifestSection)
+	if err != nil {
+		return nil, err
+	}
+	if err := be.ApplyConventions(&schema, options.Conventions); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Manifest schema: %s (environments: %q)\n", schemaName(options.Schema), schema.Environments)
+
+	// Incremental mode, only the repositories pushed after since are scanned
+	// and merged into the matrix of a previous run
+	var since time.Time
+	var existing *github.DeploymentMatrix
+	if options.Since != "" {
+		since, err = ParseSince(options.Since, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		mergePath := options.MergeJSON
+		if mergePath == "" {
+			mergePath = savePathJSON
+		}
+		if mergePath == "" {
+			return nil, fmt.Errorf("--since requires an existing matrix JSON (--merge-json or --save-path-json)")
+		}
+		existing, err = be.LoadDeploymentMatrix(mergePath)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Since: %s (merging into %s)\n", since.Format(time.RFC3339), mergePath)
+	}
+
+	// Cache of the responses (ETags) and of the repository scans
+	var cache *be.ScanCache
+	if !options.NoCache && options.CacheDir != "" {
+		if err := githubHelper.SetCacheDir(options.CacheDir); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		cache, err = be.NewScanCache(options.CacheDir, folderList, manifestName, envFilters, schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		fmt.Printf("Cache directory: %s\n", options.CacheDir)
+	}
+
+	// Step 1: Fetch the repositories matching the query
 	fmt.Println("\n[1/4] Fetching repositories from GitHub...")
 
 	// Show authentication method after first API call
@@ -42,37 +99,57 @@ func ReposDeployEnvironments(
 			fmt.Printf("ℹ️  GitHub authentication: %s\n", authMethod)
 		}
 	}()
-	repos, err := githubHelper.ListRepositories(organization)
+	// Installation of the GitHub App, when used
+	githubHelper.SetOrganization(organization)
+	repos, err := githubHelper.QueryRepositories(organization, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch repositories: %w", err)
 	}
-	fmt.Printf("Found %d repositories\n", len(repos))
-
-	// Step 2: Filter repositories
 	if query != "" {
-		repos = githubHelper.FilterRepositories(repos, query)
-		fmt.Printf("Filtered to %d repositories matching query\n", len(repos))
+		fmt.Printf("Found %d repositories matching query\n", len(repos))
+	} else {
+		fmt.Printf("Found %d repositories\n", len(repos))
 	}
 
 	if len(repos) == 0 {
-		return nil, fmt.Errorf("no repositories found matching criteria")
+		return nil, helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("no repositories found matching criteria"))
+	}
+
+	listed := repos
+	if existing != nil {
+		repos = be.FilterPushedSince(repos, since)
+		fmt.Printf("%d repositories pushed since %s\n", len(repos), since.Format(time.RFC3339))
 	}
 
 	// Step 3: Process repositories concurrently
 	fmt.Println("\n[2/4] Processing repositories and parsing manifests...")
 	maxWorkers := 10 // Concurrent workers
-	repoDataMap := be.ProcessRepositoriesConcurrently(
+	process := be.ProcessRepositoriesConcurrently
+	if options.GraphQL {
+		process = be.ProcessRepositoriesGraphQL
+	}
+	repoDataMap := process(
 		repos,
 		folderList,
 		manifestName,
 		envFilters,
+		schema,
 		maxWorkers,
+		cache,
 	)
 
 	fmt.Printf("Successfully processed %d repositories with deployments\n", len(repoDataMap))
+	if cache != nil {
+		fmt.Printf("%d repositories unchanged, served from cache\n", cache.Hits())
+	}
+
+	if existing != nil {
+		repoDataMap = be.MergeRepoData(existing.Repos, repoDataMap, repos, listed)
+		fmt.Printf("Merged into %d repositories with deployments\n", len(repoDataMap))
+	}
 
 	if len(repoDataMap) == 0 {
-		return nil, fmt.Errorf("no repositories found with matching deployments")
+		return nil, helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("no repositories found with matching deployments"))
 	}
 
 	// Step 4: Build deployment matrix
@@ -90,20 +167,46 @@ func ReposDeployEnvironments(
 		matrix.Meta.Stats.TotalProjects,
 	)
 
+	// Reachability of the discovered URLs
+	if options.Probe || options.ProbeAll {
+		fmt.Println("\nProbing URLs...")
+		fmt.Print(be.FormatProbeSummary(be.ProbeMatrix(matrix, options.ProbeAll, maxWorkers)))
+	}
+
 	// Step 5: Marshal to JSON
-	fmt.Println("\n[4/4] Generating JSON output...")
+	fmt.Printf("\n[4/4] Generating %s output...\n", strings.ToUpper(format))
 	jsonData, err := json.MarshalIndent(matrix, "", "  ")
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
 	// Save to file if requested
+	var savedPaths []string
 	if saveJSON && savePathJSON != "" {
 		if err := os.WriteFile(savePathJSON, jsonData, 0644); err != nil {
 			return nil, fmt.Errorf("failed to write JSON file: %w", err)
 		}
 		fmt.Printf("\n✓ JSON saved to: %s\n", savePathJSON)
+		savedPaths = append(savedPaths, savePathJSON)
+	}
 
+	// Self-contained HTML dashboard
+	if format == "html" {
+		htmlData, err := be.RenderMatrixHTML(matrix)
+		if err != nil {
+			return nil, err
+		}
+		if outPath == "" {
+			return htmlData, nil
+		}
+		if err := os.WriteFile(outPath, htmlData, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write HTML file: %w", err)
+		}
+		fmt.Printf("\n✓ HTML saved to: %s\n", outPath)
+		savedPaths = append(savedPaths, outPath)
+	}
+
+	if len(savedPaths) > 0 {
 		// Return summary instead of full JSON
 		summary := fmt.Sprintf(`
 Deployment Matrix Analysis Complete!
@@ -123,7 +226,7 @@ Output saved to: %s
 			matrix.Meta.Stats.TotalProjects,
 			matrix.Meta.Stats.TotalDeployments,
 			matrix.Meta.Stats.TotalDeployKeys,
-			savePathJSON,
+			strings.Join(savedPaths, ", "),
 		)
 
 		return []byte(summary), nil
@@ -132,6 +235,24 @@ Output saved to: %s
 	return jsonData, nil
 }
 
+// schemaName returns the name of the manifest schema for the output
+func schemaName(schema string) string {
+	if schema == "" {
+		return "manifest"
+	}
+	return schema
+}
+
+// ParseSince parses the --since value: an RFC3339 time, a date (2006-01-02)
+// or a duration back from now (e.g. 24h, 30d)
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	t, err := githubHelper.ParseTime(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since: %w", err)
+	}
+	return t, nil
+}
+
 // parseCommaSeparated parses a comma-separated string into a slice
 func parseCommaSeparated(input string) []string {
 	if input == "" {
@@ -10,19 +10,22 @@ import (
 )
 
 func GetRepos(organization string, query string, saveJSON bool, savePathJSON string) ([]byte, error) {
+	// Installation of the GitHub App, when used
+	configureGitHub()
+	githubHelper.SetOrganization(organization)
+
 	// Fetch repositories from GitHub
 	fmt.Printf("Fetching repositories from organization: %s\n", organization)
-	repos, err := githubHelper.ListRepositories(organization)
+	// The query narrows the listing on the server side where possible
+	repos, err := githubHelper.QueryRepositories(organization, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch repositories: %w", err)
 	}
 
-	fmt.Printf("Found %d repositories\n", len(repos))
-
-	// Filter repositories if query is provided
 	if query != "" {
-		repos = githubHelper.FilterRepositories(repos, query)
-		fmt.Printf("Filtered to %d repositories matching query: %s\n", len(repos), query)
+		fmt.Printf("Found %d repositories matching query: %s\n", len(repos), query)
+	} else {
+		fmt.Printf("Found %d repositories\n", len(repos))
 	}
 
 	// Convert to simpler structure for output
@@ -43,6 +46,8 @@ func GetRepos(organization string, query string, saveJSON bool, savePathJSON str
 			PushedAt:        repo.PushedAt,
 			Size:            repo.Size,
 			Language:        repo.Language,
+			Topics:          repo.Topics,
+			Visibility:      repo.Visibility,
 			ForksCount:      repo.ForksCount,
 			StargazersCount: repo.StargazersCount,
 			WatchersCount:   repo.WatchersCount,
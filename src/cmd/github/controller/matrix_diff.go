@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/be"
+)
+
+// MatrixDiff compares two deployment matrix JSON files. The output is the human summary
+// (format text) or the JSON diff (format json), jsonOut saves the JSON diff too. With
+// failOnChanges the output is returned with an error when the matrices differ.
+func MatrixDiff(oldPath string, newPath string, format string, jsonOut string, failOnChanges bool) ([]byte, error) {
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("invalid format %q: expected text or json", format)
+	}
+
+	oldMatrix, err := be.LoadDeploymentMatrix(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newMatrix, err := be.LoadDeploymentMatrix(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := be.DiffMatrices(oldMatrix, newMatrix)
+
+	jsonData, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if jsonOut != "" {
+		if err := os.WriteFile(jsonOut, jsonData, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write JSON file: %w", err)
+		}
+	}
+
+	output := []byte(be.FormatMatrixDiff(diff))
+	if format == "json" {
+		output = jsonData
+	}
+
+	if failOnChanges && diff.Changed {
+		return output, fmt.Errorf("deployment matrix changed")
+	}
+	return output, nil
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/be"
+)
+
+// MatrixProbe probes the URLs of a deployment matrix JSON file (the ping ones unless all)
+// and returns the summary of the broken endpoints (format text) or its JSON (format json).
+// outPath saves the matrix with the probe results. With failOnBroken the output is
+// returned with an error when an endpoint is broken.
+func MatrixProbe(matrixPath string, all bool, workers int, format string, outPath string, failOnBroken bool) ([]byte, error) {
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("invalid format %q: expected text or json", format)
+	}
+	if workers < 1 {
+		return nil, fmt.Errorf("workers must be at least 1")
+	}
+
+	matrix, err := be.LoadDeploymentMatrix(matrixPath)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := be.ProbeMatrix(matrix, all, workers)
+
+	if outPath != "" {
+		jsonData, err := json.MarshalIndent(matrix, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		if err := os.WriteFile(outPath, jsonData, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write JSON file: %w", err)
+		}
+	}
+
+	output := []byte(be.FormatProbeSummary(summary))
+	if format == "json" {
+		output, err = json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+	}
+
+	if failOnBroken && summary.Broken > 0 {
+		return output, fmt.Errorf("%d broken endpoints", summary.Broken)
+	}
+	return output, nil
+}
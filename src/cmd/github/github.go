@@ -2,13 +2,15 @@ package github
 
 import (
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/sub"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/spf13/cobra"
 )
 
 var GithubCmd = &cobra.Command{
-	Use:   "github",
-	Short: "GitHub commands",
-	Long:  "GitHub commands to manage repositories, analyze deployments, and extract deployment information",
+	Use:         "github",
+	Short:       "GitHub commands",
+	Long:        "GitHub commands to manage repositories, analyze deployments, and extract deployment information",
+	Annotations: map[string]string{helpers.AnnotationCredentials: "github"},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -17,5 +19,7 @@ var GithubCmd = &cobra.Command{
 func init() {
 	GithubCmd.AddCommand(sub.GetReposCmd)
 	GithubCmd.AddCommand(sub.ReposDeployEnvironmentsCmd)
+	GithubCmd.AddCommand(sub.MatrixDiffCmd)
+	GithubCmd.AddCommand(sub.MatrixProbeCmd)
 }
 
@@ -0,0 +1,22 @@
+package sub
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+// Shell completion of the organizations of the user
+var completeOrganizations = helpers.CompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+	return controller.CompleteOrganizations()
+})
+
+// Shell completion of the repository query of the --organization of the command
+var completeQuery = helpers.CompletionFunc(func(cmd *cobra.Command, args []string, toComplete string) []string {
+	organization := ""
+	if flag := cmd.Flag("organization"); flag != nil {
+		organization = flag.Value.String()
+	}
+	return controller.CompleteQuery(organization, toComplete)
+})
@@ -1,9 +1,10 @@
 package sub
 
 import (
-	"fmt"
-
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	githubHelper "github.com/eltiocaballoloco/sinaloa-cli/src/helpers/github"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +17,17 @@ var (
 	deployEnvsFolders         string
 	deployEnvsSaveJSON        bool
 	deployEnvsSavePathJSON    string
+	deployEnvsCacheDir        string
+	deployEnvsNoCache         bool
+	deployEnvsSince           string
+	deployEnvsMergeJSON       string
+	deployEnvsGraphQL         bool
+	deployEnvsSchema          string
+	deployEnvsFormat          string
+	deployEnvsOut             string
+	deployEnvsConventions     string
+	deployEnvsProbe           bool
+	deployEnvsProbeAll        bool
 )
 
 var ReposDeployEnvironmentsCmd = &cobra.Command{
@@ -31,10 +43,53 @@ Example:
     --envs "prod-,qa-,test-" \
     --folders "apps,src" \
     --save-json true \
-    --save-path-json "/tmp/deployment-matrix.json"`,
-	Run: func(cmd *cobra.Command, args []string) {
+    --save-path-json "/tmp/deployment-matrix.json"
+
+Unchanged repositories (same last commit) are served from the local cache and the
+GitHub requests are conditional (ETags). To rescan only the repositories pushed
+after a given time and merge them into the matrix of a previous run:
+  sinaloa github repos-deploy-environments \
+    --organization "OrgName" \
+    --since 24h \
+    --save-json true \
+    --save-path-json "/tmp/deployment-matrix.json"
+
+To export a single-file HTML dashboard (search, env prefix groups, region columns and links):
+  sinaloa github repos-deploy-environments \
+    --organization "OrgName" \
+    --format html \
+    --out matrix.html
+
+Manifests are mapped to the matrix with a schema of YAML paths (--schema). Built-in:
+  manifest        environments.<name>.clusters, replicas and Ambassador exposure (default)
+  helm            <folder>/<project>/<env>/values.yaml (--manifest-name values.yaml)
+  kustomize       <folder>/<project>/overlays/<env>/kustomization.yaml (--manifest-name kustomization.yaml)
+  applicationset  spec.generators[*].list.elements with env, cluster and namespace
+A schema file sets the same fields, e.g.:
+  name: metadata.name
+  environments: spec.environments        # map (keys are the names) or list
+  environment_name: id                   # name of the list items ($dir[0] for a file per environment)
+  clusters: cluster|clusters             # alternatives separated by |
+  replicas: replicas
+  namespace: namespace
+  hosts: {ingress: "ingress.hosts[0].host"}
+
+Namespaces, cluster names and host/path/URL keys follow Go templates (--conventions),
+the default profile is the area-gaming one:
+  namespace: "area-gaming-{{.Service}}-{{.Env}}"   # .Project .Service .Env .EnvPrefix .Region .Cluster
+  cluster: "{{.Cluster}}"
+  host_key: "ambassador_{{.Exposure}}"             # .Exposure .Hostname .Prefix .Rewrite .Env
+  path_key: '{{if eq .Exposure "internal"}}{{if contains .Prefix "ping"}}ping_prefix{{else}}main_prefix{{end}}{{end}}'
+  url_key: '{{.Exposure}}_{{if contains .Prefix "ping"}}ping{{else}}main{{end}}'
+  url: "https://{{.Hostname}}{{.Prefix}}"
+A conventions file overrides only the templates it sets.
+
+For organizations with 1000+ repositories use --graphql: commits and manifests are
+fetched in batches (manifests up to 3 levels below the folders) and the requests
+wait for the rate limit reset instead of failing.`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		// Call the ReposDeployEnvironments controller
-		result, err := controller.ReposDeployEnvironments(
+		return controller.ReposDeployEnvironments(
 			deployEnvsOrganization,
 			deployEnvsQuery,
 			deployEnvsEnvs,
@@ -43,27 +98,47 @@ Example:
 			deployEnvsFolders,
 			deployEnvsSaveJSON,
 			deployEnvsSavePathJSON,
+			deployEnvsFormat,
+			deployEnvsOut,
+			github.ScanOptions{
+				CacheDir:    deployEnvsCacheDir,
+				NoCache:     deployEnvsNoCache,
+				Since:       deployEnvsSince,
+				MergeJSON:   deployEnvsMergeJSON,
+				GraphQL:     deployEnvsGraphQL,
+				Schema:      deployEnvsSchema,
+				Conventions: deployEnvsConventions,
+				Probe:       deployEnvsProbe,
+				ProbeAll:    deployEnvsProbeAll,
+			},
 		)
-
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			return
-		}
-
-		// Print the result
-		fmt.Println(string(result))
-	},
+	}),
 }
 
 func init() {
 	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsOrganization, "organization", "o", "", "Organization name (required)")
-	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsQuery, "query", "q", "", "Comma-separated repo patterns to search")
+	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsQuery, "query", "q", "", "Repository query, see github get-repos --help (e.g. \"svc-* -is:archived\")")
 	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsEnvs, "envs", "e", "prod-,qa-,test-", "Environment prefixes to search (comma-separated)")
-	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsManifestSection, "manifest-section", "s", "environments", "YAML section to search")
+	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsManifestSection, "manifest-section", "s", "", "YAML path of the environments (default from the schema, \"environments\" for manifest)")
+	ReposDeployEnvironmentsCmd.Flags().StringVar(&deployEnvsSchema, "schema", "manifest", "Manifest schema: manifest, helm, kustomize, applicationset or path of a schema YAML file")
 	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsManifestName, "manifest-name", "m", "manifest.yaml", "Manifest filename")
 	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsFolders, "folders", "f", "apps,src,micro-frontends", "Project folders to scan (comma-separated)")
 	ReposDeployEnvironmentsCmd.Flags().BoolVarP(&deployEnvsSaveJSON, "save-json", "z", false, "Save to file (true) or display in terminal (false)")
 	ReposDeployEnvironmentsCmd.Flags().StringVarP(&deployEnvsSavePathJSON, "save-path-json", "j", "", "Absolute path for JSON output file")
+	ReposDeployEnvironmentsCmd.Flags().StringVar(&deployEnvsCacheDir, "cache-dir", githubHelper.DefaultCacheDir(), "Directory of the scan and response cache")
+	ReposDeployEnvironmentsCmd.Flags().BoolVar(&deployEnvsNoCache, "no-cache", false, "Scan every repository without using the cache")
+	ReposDeployEnvironmentsCmd.Flags().StringVar(&deployEnvsSince, "since", "", "Rescan only repos pushed after this time (RFC3339, 2006-01-02 or duration like 24h)")
+	ReposDeployEnvironmentsCmd.Flags().StringVar(&deployEnvsMergeJSON, "merge-json", "", "Matrix JSON to merge the --since scan into (default --save-path-json)")
+	ReposDeployEnvironmentsCmd.Flags().BoolVar(&deployEnvsGraphQL, "graphql", false, "Fetch commits and manifests with batched GraphQL queries (large organizations)")
+	ReposDeployEnvironmentsCmd.Flags().StringVar(&deployEnvsConventions, "conventions", "default", "Conventions file with the namespace, cluster and URL templates")
+	ReposDeployEnvironmentsCmd.Flags().BoolVar(&deployEnvsProbe, "probe", false, "Probe the ping URLs and record status, latency and TLS expiry in the matrix")
+	ReposDeployEnvironmentsCmd.Flags().BoolVar(&deployEnvsProbeAll, "probe-all", false, "Probe every URL, not only the ping ones")
+	ReposDeployEnvironmentsCmd.Flags().StringVar(&deployEnvsFormat, "format", "json", "Output format: json or html (self-contained dashboard)")
+	ReposDeployEnvironmentsCmd.Flags().StringVar(&deployEnvsOut, "out", "", "Path of the HTML dashboard (default stdout)")
 
 	ReposDeployEnvironmentsCmd.MarkFlagRequired("organization")
+	ReposDeployEnvironmentsCmd.RegisterFlagCompletionFunc("organization", completeOrganizations)
+	ReposDeployEnvironmentsCmd.RegisterFlagCompletionFunc("query", completeQuery)
+	ReposDeployEnvironmentsCmd.RegisterFlagCompletionFunc("schema", cobra.FixedCompletions([]string{"manifest", "helm", "kustomize", "applicationset"}, cobra.ShellCompDirectiveDefault))
+	ReposDeployEnvironmentsCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"json", "html"}, cobra.ShellCompDirectiveNoFileComp))
 }
@@ -1,9 +1,8 @@
 package sub
 
 import (
-	"fmt"
-
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/spf13/cobra"
 )
 
@@ -24,31 +23,46 @@ Example:
     --organization "OrgName" \
     --query "-my-repo-,repo-" \
     --save-json true \
-    --save-path-json "/tmp/repos.json"`,
-	Run: func(cmd *cobra.Command, args []string) {
+    --save-path-json "/tmp/repos.json"
+
+The query is a list of space-separated terms that must all match, a term starting
+with - is negated and comma-separated values match any of them:
+  name:svc-* name:/^svc-/    name glob or regex (full name when the pattern has a /)
+  topic:payments             repository topic
+  language:go,python         primary language
+  archived:false fork:false  archived, fork and private flags (true/false)
+  is:private -is:archived    archived, fork, private or public
+  visibility:internal        public, private or internal
+  pushed:>2024-01-01         pushed after (>) or before (<) a date, time or duration (30d)
+  team:platform              repositories of the team
+
+A query made of a single term without key keeps the old format, a comma list of
+name substrings where a leading - is part of the substring: "-my-repo-,repo-"
+matches the names containing -my-repo- or repo-. To negate a single name term
+use -name: (e.g. "-name:legacy-").
+
+Example:
+  sinaloa github get-repos \
+    --organization "OrgName" \
+    --query "topic:payments language:go -is:archived pushed:>90d"`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		// Call the GetRepos controller
-		result, err := controller.GetRepos(
+		return controller.GetRepos(
 			getReposOrganization,
 			getReposQuery,
 			getReposSaveJSON,
 			getReposSavePathJSON,
 		)
-
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			return
-		}
-
-		// Print the result
-		fmt.Println(string(result))
-	},
+	}),
 }
 
 func init() {
 	GetReposCmd.Flags().StringVarP(&getReposOrganization, "organization", "o", "", "Organization name (required)")
-	GetReposCmd.Flags().StringVarP(&getReposQuery, "query", "q", "", "Comma-separated repo patterns to search")
+	GetReposCmd.Flags().StringVarP(&getReposQuery, "query", "q", "", "Repository query (e.g. \"svc-* topic:payments -is:archived\")")
 	GetReposCmd.Flags().BoolVarP(&getReposSaveJSON, "save-json", "z", false, "Save to file (true) or display in terminal (false)")
 	GetReposCmd.Flags().StringVarP(&getReposSavePathJSON, "save-path-json", "j", "", "Absolute path for JSON output file")
 
 	GetReposCmd.MarkFlagRequired("organization")
+	GetReposCmd.RegisterFlagCompletionFunc("organization", completeOrganizations)
+	GetReposCmd.RegisterFlagCompletionFunc("query", completeQuery)
 }
@@ -0,0 +1,42 @@
+package sub
+
+import (
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/spf13/cobra"
+)
+
+var (
+	matrixDiffFormat        string
+	matrixDiffJSONOut       string
+	matrixDiffFailOnChanges bool
+)
+
+var MatrixDiffCmd = &cobra.Command{
+	Use:   "matrix-diff <old.json> <new.json>",
+	Short: "Show what changed between two deployment matrix scans",
+	Long: `Show what changed between two deployment matrices generated by repos-deploy-environments:
+added and removed repositories and projects, new and removed deploy keys per project and
+changed clusters, namespaces, replicas, hosts, paths and URLs.
+
+Example:
+  sinaloa github matrix-diff old.json new.json
+  sinaloa github matrix-diff old.json new.json --json-out diff.json --fail-on-changes`,
+	Args: cobra.ExactArgs(2),
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		// Call the MatrixDiff controller
+		return controller.MatrixDiff(
+			args[0],
+			args[1],
+			matrixDiffFormat,
+			matrixDiffJSONOut,
+			matrixDiffFailOnChanges,
+		)
+	}),
+}
+
+func init() {
+	MatrixDiffCmd.Flags().StringVarP(&matrixDiffFormat, "format", "f", "text", "Output format: text (summary) or json")
+	MatrixDiffCmd.Flags().StringVar(&matrixDiffJSONOut, "json-out", "", "Also save the JSON diff to this file")
+	MatrixDiffCmd.Flags().BoolVar(&matrixDiffFailOnChanges, "fail-on-changes", false, "Exit with status 1 when the matrices differ (PR checks)")
+}
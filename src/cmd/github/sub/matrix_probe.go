@@ -0,0 +1,47 @@
+package sub
+
+import (
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/spf13/cobra"
+)
+
+var (
+	matrixProbeAll          bool
+	matrixProbeWorkers      int
+	matrixProbeFormat       string
+	matrixProbeOut          string
+	matrixProbeFailOnBroken bool
+)
+
+var MatrixProbeCmd = &cobra.Command{
+	Use:   "matrix-probe <matrix.json>",
+	Short: "Probe the URLs discovered in a deployment matrix",
+	Long: `Probe concurrently the ping URLs (every URL with --all) of a deployment matrix generated by
+repos-deploy-environments, record status code, latency and TLS expiry in the matrix and
+summarize the broken endpoints per environment.
+
+Example:
+  sinaloa github matrix-probe matrix.json
+  sinaloa github matrix-probe matrix.json --out matrix.json --fail-on-broken`,
+	Args: cobra.ExactArgs(1),
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		// Call the MatrixProbe controller
+		return controller.MatrixProbe(
+			args[0],
+			matrixProbeAll,
+			matrixProbeWorkers,
+			matrixProbeFormat,
+			matrixProbeOut,
+			matrixProbeFailOnBroken,
+		)
+	}),
+}
+
+func init() {
+	MatrixProbeCmd.Flags().BoolVar(&matrixProbeAll, "all", false, "Probe every URL, not only the ping ones")
+	MatrixProbeCmd.Flags().IntVarP(&matrixProbeWorkers, "workers", "w", 10, "Concurrent probes")
+	MatrixProbeCmd.Flags().StringVarP(&matrixProbeFormat, "format", "f", "text", "Output format: text (summary) or json")
+	MatrixProbeCmd.Flags().StringVar(&matrixProbeOut, "out", "", "Save the matrix with the probe results to this file")
+	MatrixProbeCmd.Flags().BoolVar(&matrixProbeFailOnBroken, "fail-on-broken", false, "Exit with status 1 when an endpoint is broken")
+}
@@ -0,0 +1,84 @@
+package be
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"time"
+
+	netModel "github.com/eltiocaballoloco/sinaloa-cli/src/models/net"
+)
+
+// Roots verifying the server certificates (nil = system roots), replaced in the tests
+var rootCAs *x509.CertPool
+
+// Probe sends a GET to the URL and returns status code, latency and,
+// for https, the expiry of the server certificate. The expiry is recorded
+// even when the certificate does not verify (expired, unknown authority).
+func Probe(url string) netModel.ProbeResult {
+	start := time.Now()
+	result := netModel.ProbeResult{URL: url, CheckedAt: start.UTC().Format(time.RFC3339)}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var cert *x509.Certificate
+	probeClient := newProbeClient(req.URL.Hostname(), &cert)
+	defer probeClient.CloseIdleConnections()
+
+	resp, err := probeClient.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if cert != nil {
+		result.TLSExpiry = cert.NotAfter.UTC().Format(time.RFC3339)
+		result.TLSDaysLeft = int(time.Until(cert.NotAfter).Hours() / 24)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.OK = resp.StatusCode < http.StatusBadRequest
+	return result
+}
+
+// newProbeClient returns a client recording the server certificate in cert before
+// verifying it: the verification of the chain is done in VerifyConnection, which
+// the handshake runs only when the default verification is skipped. host is
+// verified when the connection has no server name (an IP address).
+func newProbeClient(host string, cert **x509.Certificate) *http.Client {
+	return &http.Client{
+		Timeout: client.Timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+				VerifyConnection: func(cs tls.ConnectionState) error {
+					if len(cs.PeerCertificates) == 0 {
+						return errors.New("tls: server sent no certificate")
+					}
+					*cert = cs.PeerCertificates[0]
+					name := cs.ServerName
+					if name == "" {
+						name = host
+					}
+					opts := x509.VerifyOptions{
+						Roots:         rootCAs,
+						DNSName:       name,
+						Intermediates: x509.NewCertPool(),
+					}
+					for _, intermediate := range cs.PeerCertificates[1:] {
+						opts.Intermediates.AddCert(intermediate)
+					}
+					_, err := cs.PeerCertificates[0].Verify(opts)
+					return err
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package be_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/net/be"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbe(t *testing.T) {
+	// Arrange: A healthy and a failing endpoint
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method, "Expected GET method")
+		if r.URL.Path == "/down/ping" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Act: Probe both and an unreachable URL
+	up := be.Probe(server.URL + "/up/ping")
+	down := be.Probe(server.URL + "/down/ping")
+	unreachable := be.Probe("http://127.0.0.1:1/ping")
+
+	// Assert: Status codes and errors are recorded
+	assert.True(t, up.OK, "Healthy endpoint should be OK")
+	assert.Equal(t, http.StatusOK, up.StatusCode, "Status code should match")
+	assert.NotEmpty(t, up.CheckedAt, "Check time should be set")
+	assert.False(t, down.OK, "Failing endpoint should not be OK")
+	assert.Equal(t, http.StatusServiceUnavailable, down.StatusCode, "Status code should match")
+	assert.False(t, unreachable.OK, "Unreachable endpoint should not be OK")
+	assert.NotEmpty(t, unreachable.Error, "Unreachable endpoint should have an error")
+}
@@ -0,0 +1,35 @@
+package be
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbe_TLS(t *testing.T) {
+	// Arrange: An https endpoint with a certificate of a test authority
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	expiry := server.Certificate().NotAfter.UTC().Format(time.RFC3339)
+
+	// Act: Probe it without and with the test authority trusted
+	untrusted := Probe(server.URL + "/ping")
+	rootCAs = x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+	defer func() { rootCAs = nil }()
+	trusted := Probe(server.URL + "/ping")
+
+	// Assert: The expiry is recorded even when the verification fails
+	assert.False(t, untrusted.OK, "Unverified certificate should not be OK")
+	assert.Contains(t, untrusted.Error, "certificate", "Verification error should be reported")
+	assert.Equal(t, expiry, untrusted.TLSExpiry, "Expiry should be recorded on a failed verification")
+	assert.True(t, trusted.OK, "Verified endpoint should be OK")
+	assert.Equal(t, expiry, trusted.TLSExpiry, "Expiry should match the server certificate")
+	assert.Greater(t, trusted.TLSDaysLeft, 0, "Days left should be computed")
+}
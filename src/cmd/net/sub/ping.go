@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/net/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 )
 
 var urlPath string
@@ -19,12 +20,10 @@ var PingCmd = &cobra.Command{
 	Use:   "ping",
 	Short: "This command is used to ping a url or an ip address",
 	Long:  `This command is used to ping a url or an ip address. Return 200 if ping it is ok otherwise error. Example: sinaloa net ping -u google.com`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
 		// Call the Ping controller
-		result, _ := controller.Ping(urlPath)
-		// Print the success response
-		fmt.Println(string(result))
-	},
+		return controller.Ping(urlPath)
+	}),
 }
 
 func init() {
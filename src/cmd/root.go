@@ -1,30 +1,61 @@
 package cmd
 
 import (
+	"log/slog"
 	"os"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/argocd"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/auth"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/azure"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/config"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/docker"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/github"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/net"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/version"
+	versionController "github.com/eltiocaballoloco/sinaloa-cli/src/cmd/version/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 	"github.com/spf13/cobra"
 )
 
+var (
+	profile   string
+	logLevel  string
+	logFormat string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "sinaloa",
 	Short: "The sinaloa cli",
 	Long:  `The sinaloa cli`,
-	CompletionOptions: cobra.CompletionOptions{
-		DisableDefaultCmd: true, // Disable the "completion" command
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Load the configuration once (flags > env > profile), set up the logs
+		// on stderr and validate the output format before running the command.
+		// The shell completion runs without a terminal to ask the passphrase.
+		if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+			helpers.PromptsDisabled = true
+		}
+		if err := helpers.InitConfig(profile); err != nil && cmd.Annotations[helpers.AnnotationManagesProfiles] == "" {
+			return err
+		}
+		if err := helpers.SetupLogger(logLevel, logFormat); err != nil {
+			return err
+		}
+		// The binary replaced by the last self-update on Windows can be removed now
+		versionController.RemoveOldBinary()
+		// The stored credentials are read only for the services the command uses
+		if err := helpers.LoadCredentials(helpers.CommandCredentials(cmd)...); err != nil {
+			slog.Warn("Ignoring the stored credentials", "error", err)
+		}
+		return helpers.ValidateOutputFormat(helpers.OutputFormat)
 	},
 }
 
 func Execute() {
+	// The commands exit with their own code, the errors left are the
+	// ones of the flags, the arguments and the unknown commands
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(helpers.ExitValidation)
 	}
 }
 
@@ -34,10 +65,21 @@ func addSubcommandPalettes() {
 	rootCmd.AddCommand(github.GithubCmd)
 	rootCmd.AddCommand(azure.AzureCmd)
 	rootCmd.AddCommand(net.NetCmd)
+	rootCmd.AddCommand(config.ConfigCmd)
+	rootCmd.AddCommand(auth.AuthCmd)
 	rootCmd.AddCommand(version.VersionCmd)
+	rootCmd.AddCommand(version.SelfUpdateCmd)
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&helpers.OutputFormat, "output", helpers.OutputJSON, "Output format: json, yaml, table, csv or jsonpath=<expr> (e.g. jsonpath={.data[*].name})")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Profile of the config file (default SINALOA_PROFILE or the current profile)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Log level: debug, info, warn or error (default info, debug with SINALOA_DEBUG=true)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Log format: text or json")
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"json", "yaml", "table", "csv", "jsonpath="}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("profile", helpers.CompletionFunc(helpers.ProfileCompletions))
+	rootCmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions([]string{"debug", "info", "warn", "error"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	addSubcommandPalettes()
 }
@@ -0,0 +1,93 @@
+package be
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/version"
+)
+
+// Repository of the releases of sinaloa-cli
+const ReleaseRepository = "eltiocaballoloco/sinaloa-cli"
+
+// GitHubAPIURL is the base url of the GitHub API, replaced in the tests
+var GitHubAPIURL = "https://api.github.com"
+
+// Client of the release api and downloads, with the TLS verification
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// GetRelease returns the release of the tag, the latest release when tag is empty. The token
+// (GITHUB_TOKEN) is the one of the api at tokenURL (GITHUB_API_URL, empty for github.com).
+func GetRelease(tag string, token string, tokenURL string) (version.Release, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/releases/latest", GitHubAPIURL, ReleaseRepository)
+	if tag != "" {
+		endpoint = fmt.Sprintf("%s/repos/%s/releases/tags/%s", GitHubAPIURL, ReleaseRepository, url.PathEscape(tag))
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return version.Release{}, fmt.Errorf("failed to create the request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	// The token is optional, it raises the rate limit
+	if token := githubToken(token, tokenURL); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	body, err := do(req)
+	if err != nil {
+		return version.Release{}, err
+	}
+	var release version.Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return version.Release{}, fmt.Errorf("failed to parse the release: %w", err)
+	}
+	return release, nil
+}
+
+// githubToken returns the token when it is a token of github.com: the token of
+// GitHub Enterprise Server (tokenURL of another host) is not sent to github.com
+func githubToken(token string, tokenURL string) string {
+	if tokenURL != "" {
+		parsed, err := url.Parse(tokenURL)
+		if err != nil || parsed.Host != "api.github.com" {
+			return ""
+		}
+	}
+	return token
+}
+
+// Download returns the content of a release asset
+func Download(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %w", err)
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	return do(req)
+}
+
+// do sends the request and returns the body of a 200 response
+func do(req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response of %s: %w", req.URL.Path, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("%s not found (status 404)", req.URL.Path))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s failed (status %d): %s", req.URL.Path, resp.StatusCode, helpers.RedactBody(body))
+	}
+	return body, nil
+}
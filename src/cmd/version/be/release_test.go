@@ -0,0 +1,62 @@
+package be
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/version"
+)
+
+func TestGetRelease_Token(t *testing.T) {
+	// Arrange: A release server recording the authorization
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(version.Release{TagName: "v1.3.0"})
+	}))
+	defer server.Close()
+	originalURL := GitHubAPIURL
+	t.Cleanup(func() { GitHubAPIURL = originalURL })
+	GitHubAPIURL = server.URL
+
+	tests := map[string]string{
+		"":                                "Bearer token",
+		"https://api.github.com":          "Bearer token",
+		"https://api.github.com/":         "Bearer token",
+		"https://ghe.example.com/api/v3":  "",
+		"https://ghe.example.com":         "",
+		"https://api.github.com.evil.com": "",
+	}
+	for apiURL, expected := range tests {
+		// Act: Get the latest release with the token of the api
+		_, err := GetRelease("", "token", apiURL)
+
+		// Assert: The token is only sent when it is a token of github.com
+		assert.NoError(t, err, "GetRelease should not return an error")
+		assert.Equal(t, expected, authorization, "Authorization with GITHUB_API_URL %q should match", apiURL)
+	}
+}
+
+func TestGetRelease_EscapedTag(t *testing.T) {
+	// Arrange: A release server recording the requested path
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		json.NewEncoder(w).Encode(version.Release{TagName: "v1.3.0"})
+	}))
+	defer server.Close()
+	originalURL := GitHubAPIURL
+	t.Cleanup(func() { GitHubAPIURL = originalURL })
+	GitHubAPIURL = server.URL
+
+	// Act: Get a release with a tag leaving the tags endpoint
+	_, err := GetRelease("../../../user", "", "")
+
+	// Assert: The tag stays a single path segment
+	assert.NoError(t, err, "GetRelease should not return an error")
+	assert.Equal(t, "/repos/"+ReleaseRepository+"/releases/tags/..%2F..%2F..%2Fuser", path, "Tag should be escaped")
+}
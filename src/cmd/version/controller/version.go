@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/version/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/version"
+)
+
+// Name of the checksums file of a release (sha256sum format)
+const ChecksumsAsset = "checksums.txt"
+
+// Path of the running binary, replaced in the tests
+var executablePath = func() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+// The running binary can not be overwritten on Windows, it is moved aside to <path>.old
+// and replaced, replaced in the tests
+var moveAside = runtime.GOOS == "windows"
+
+// Version returns the build information of the binary, compared
+// to the latest release of sinaloa-cli with check
+func Version(check bool) ([]byte, error) {
+	info := buildInfo()
+	if !check {
+		return marshal(info)
+	}
+
+	release, err := be.GetRelease("", helpers.AppConfig.GITHUB_TOKEN, helpers.AppConfig.GITHUB_API_URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the latest release: %w", err)
+	}
+	result := version.VersionCheck{
+		BuildInfo:  info,
+		Latest:     release.TagName,
+		ReleaseURL: release.HTMLURL,
+	}
+	// A development build can not be compared
+	if newer, err := isNewer(release.TagName, info.Version); err == nil {
+		result.UpdateAvailable = newer
+	}
+	return marshal(result)
+}
+
+// SelfUpdate replaces the running binary with the asset of the release of the tag (the latest
+// by default) for this platform, after verifying its checksum. The latest release is only
+// installed when newer than the binary, unless force is set.
+func SelfUpdate(tag string, force bool) ([]byte, error) {
+	current := buildInfo().Version
+	release, err := be.GetRelease(tag, helpers.AppConfig.GITHUB_TOKEN, helpers.AppConfig.GITHUB_API_URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the release: %w", err)
+	}
+
+	result := version.UpdateResult{Previous: current, Version: release.TagName}
+	if tag == "" && !force {
+		newer, err := isNewer(release.TagName, current)
+		if err != nil {
+			return nil, helpers.ValidationError("can not compare the version %q to the release %s: pass --force to install it", current, release.TagName)
+		}
+		if !newer {
+			result.Message = fmt.Sprintf("sinaloa %s is up to date", current)
+			return marshal(result)
+		}
+	}
+
+	// Asset of the platform and its checksum
+	result.Asset = AssetName(runtime.GOOS, runtime.GOARCH)
+	asset, ok := findAsset(release, result.Asset)
+	if !ok {
+		return nil, helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("release %s has no asset %s", release.TagName, result.Asset))
+	}
+	checksums, ok := findAsset(release, ChecksumsAsset)
+	if !ok {
+		return nil, helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("release %s has no %s to verify the asset", release.TagName, ChecksumsAsset))
+	}
+	sums, err := be.Download(checksums.BrowserDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", ChecksumsAsset, err)
+	}
+	expected, err := checksumOf(sums, result.Asset)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Downloading %s %s...\n", result.Asset, release.TagName)
+	binary, err := be.Download(asset.BrowserDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", result.Asset, err)
+	}
+	sum := sha256.Sum256(binary)
+	result.SHA256 = hex.EncodeToString(sum[:])
+	if result.SHA256 != expected {
+		return nil, fmt.Errorf("checksum mismatch of %s: expected %s, got %s", result.Asset, expected, result.SHA256)
+	}
+
+	result.Path, err = executablePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the path of the binary: %w", err)
+	}
+	if err := replaceBinary(result.Path, binary); err != nil {
+		return nil, err
+	}
+	result.Updated = true
+	result.Message = fmt.Sprintf("sinaloa updated from %s to %s", current, release.TagName)
+	return marshal(result)
+}
+
+// AssetName returns the name of the release asset of the platform, e.g. sinaloa_linux_amd64
+func AssetName(goos string, goarch string) string {
+	name := fmt.Sprintf("sinaloa_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// buildInfo returns the build information of the binary
+func buildInfo() version.BuildInfo {
+	v, commit, buildDate := helpers.BuildInfo()
+	return version.BuildInfo{
+		Version:   v,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  helpers.Platform(),
+	}
+}
+
+// isNewer returns true if the release is newer than the current version
+func isNewer(release string, current string) (bool, error) {
+	releaseVersion, err := semver.NewVersion(release)
+	if err != nil {
+		return false, fmt.Errorf("invalid release version %q: %w", release, err)
+	}
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %w", current, err)
+	}
+	return releaseVersion.GreaterThan(currentVersion), nil
+}
+
+// findAsset returns the asset of the release with the name
+func findAsset(release version.Release, name string) (version.ReleaseAsset, bool) {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return version.ReleaseAsset{}, false
+}
+
+// checksumOf returns the sha256 of the file in a checksums file ("<sha256>  <name>" lines)
+func checksumOf(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", helpers.NewCommandError(helpers.ExitNotFound, fmt.Errorf("no checksum of %s in %s", name, ChecksumsAsset))
+}
+
+// replaceBinary atomically replaces the binary at path: the new one is written next
+// to it, with the same permissions, and renamed over it. On Windows the running binary
+// is renamed to <path>.old first, RemoveOldBinary removes it at the next start.
+func replaceBinary(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write next to %s (run it with the permissions of its folder): %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to set the permissions of the new binary: %w", err)
+	}
+	if moveAside {
+		old := path + ".old"
+		if err := os.Remove(old); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s of the last update: %w", old, err)
+		}
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", path, err)
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			// Put the running binary back
+			if restoreErr := os.Rename(old, path); restoreErr != nil {
+				return fmt.Errorf("failed to replace %s: %w (the previous binary is %s)", path, err, old)
+			}
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// RemoveOldBinary removes the binary moved aside by the last self-update on Windows,
+// it is not running anymore
+func RemoveOldBinary() {
+	if !moveAside {
+		return
+	}
+	path, err := executablePath()
+	if err != nil {
+		return
+	}
+	if err := os.Remove(path + ".old"); err != nil && !os.IsNotExist(err) {
+		slog.Debug("Failed to remove the binary of the last update", "path", path+".old", "error", err)
+	}
+}
+
+// marshal returns the indented JSON of the result
+func marshal(result interface{}) ([]byte, error) {
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return jsonData, nil
+}
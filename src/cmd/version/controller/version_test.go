@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/version/be"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/version"
+)
+
+// releaseServer serves the release v1.3.0 with the binary of this platform and the checksums
+func releaseServer(t *testing.T, binary string, checksums string) {
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + be.ReleaseRepository + "/releases/latest":
+			json.NewEncoder(w).Encode(version.Release{
+				TagName: "v1.3.0",
+				HTMLURL: "https://github.com/" + be.ReleaseRepository + "/releases/tag/v1.3.0",
+				Assets: []version.ReleaseAsset{
+					{Name: asset, BrowserDownloadURL: server.URL + "/download/" + asset},
+					{Name: ChecksumsAsset, BrowserDownloadURL: server.URL + "/download/" + ChecksumsAsset},
+				},
+			})
+		case "/download/" + asset:
+			fmt.Fprint(w, binary)
+		case "/download/" + ChecksumsAsset:
+			fmt.Fprint(w, checksums)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	originalURL, originalVersion := be.GitHubAPIURL, helpers.Version
+	t.Cleanup(func() { be.GitHubAPIURL, helpers.Version = originalURL, originalVersion })
+	be.GitHubAPIURL = server.URL
+}
+
+// fakeExecutable replaces the path of the running binary with a file of the test
+func fakeExecutable(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "sinaloa")
+	assert.NoError(t, os.WriteFile(path, []byte("old"), 0755))
+	original := executablePath
+	t.Cleanup(func() { executablePath = original })
+	executablePath = func() (string, error) { return path, nil }
+	return path
+}
+
+func TestVersion_Check(t *testing.T) {
+	// Arrange: The binary v1.2.0 and the latest release v1.3.0
+	releaseServer(t, "", "")
+	helpers.Version = "v1.2.0"
+
+	// Act: Check the version
+	output, err := Version(true)
+
+	// Assert: The update is available
+	assert.NoError(t, err)
+	var check version.VersionCheck
+	assert.NoError(t, json.Unmarshal(output, &check))
+	assert.Equal(t, "v1.2.0", check.Version)
+	assert.Equal(t, "v1.3.0", check.Latest)
+	assert.True(t, check.UpdateAvailable)
+}
+
+func TestSelfUpdate(t *testing.T) {
+	// Arrange: A release with the new binary and its checksum
+	sum := sha256.Sum256([]byte("new"))
+	releaseServer(t, "new", fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), AssetName(runtime.GOOS, runtime.GOARCH)))
+	helpers.Version = "v1.2.0"
+	path := fakeExecutable(t)
+
+	// Act: Update the binary
+	output, err := SelfUpdate("", false)
+
+	// Assert: The binary is replaced keeping its permissions
+	assert.NoError(t, err)
+	var result version.UpdateResult
+	assert.NoError(t, json.Unmarshal(output, &result))
+	assert.True(t, result.Updated)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "new", string(data))
+	info, _ := os.Stat(path)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// Up to date now
+	helpers.Version = "v1.3.0"
+	output, err = SelfUpdate("", false)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(output, &result))
+	assert.False(t, result.Updated)
+}
+
+func TestReplaceBinary_MoveAside(t *testing.T) {
+	// Arrange: The Windows replacement of a running binary
+	path := fakeExecutable(t)
+	original := moveAside
+	t.Cleanup(func() { moveAside = original })
+	moveAside = true
+
+	// Act: Replace the binary, then start again
+	err := replaceBinary(path, []byte("new"))
+	data, _ := os.ReadFile(path)
+	old, _ := os.ReadFile(path + ".old")
+	RemoveOldBinary()
+
+	// Assert: The running binary is moved aside and removed at the next start
+	assert.NoError(t, err, "replaceBinary should not return an error")
+	assert.Equal(t, "new", string(data), "New binary should be at the path")
+	assert.Equal(t, "old", string(old), "Running binary should be moved aside")
+	_, err = os.Stat(path + ".old")
+	assert.True(t, os.IsNotExist(err), "Old binary should be removed at the next start")
+}
+
+func TestSelfUpdate_ChecksumMismatch(t *testing.T) {
+	// Arrange: A release whose checksum does not match the binary
+	releaseServer(t, "tampered", fmt.Sprintf("%064d  %s\n", 0, AssetName(runtime.GOOS, runtime.GOARCH)))
+	helpers.Version = "v1.2.0"
+	path := fakeExecutable(t)
+
+	// Act: Update the binary
+	_, err := SelfUpdate("", false)
+
+	// Assert: The binary is left untouched
+	assert.ErrorContains(t, err, "checksum mismatch")
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "old", string(data))
+}
+
+func TestSelfUpdate_DevBuild(t *testing.T) {
+	// Arrange: A development build
+	releaseServer(t, "", "")
+	helpers.Version = "dev"
+
+	// Act: Update without --force
+	_, err := SelfUpdate("", false)
+
+	// Assert: A validation error asks for --force
+	assert.Error(t, err)
+	assert.Equal(t, helpers.ExitValidation, helpers.ExitCode(nil, err))
+}
@@ -0,0 +1,34 @@
+package version
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/version/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
+)
+
+var (
+	selfUpdateVersion string
+	selfUpdateForce   bool
+)
+
+// SelfUpdateCmd represents the self-update command
+var SelfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update sinaloa-cli to the latest release",
+	Long: `Download the binary of this platform (sinaloa_<os>_<arch>) from the latest release of sinaloa-cli,
+verify it against the checksums.txt of the release and atomically replace the running binary.
+The latest release is installed only when newer, --version installs a given release.
+
+Example:
+  sinaloa self-update
+  sinaloa self-update --version v1.3.0`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.SelfUpdate(selfUpdateVersion, selfUpdateForce)
+	}),
+}
+
+func init() {
+	SelfUpdateCmd.Flags().StringVar(&selfUpdateVersion, "version", "", "Release tag to install (default the latest release)")
+	SelfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false, "Install the latest release even when it is not newer")
+}
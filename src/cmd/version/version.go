@@ -1,19 +1,29 @@
 package version
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/cmd/version/controller"
+	"github.com/eltiocaballoloco/sinaloa-cli/src/helpers"
 )
 
+var versionCheck bool
+
 // VersionCmd represents the version command
 var VersionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Get the version of sinaloa-cli",
-	Long:  "Get the version of sinaloa-cli. Example: sinaloa version",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("v1.2.0")
-	},
+	Long: `Get the version, commit, build date, Go version and platform of sinaloa-cli.
+With --check the version is compared to the latest release of sinaloa-cli.
+
+Example:
+  sinaloa version
+  sinaloa version --check`,
+	Run: helpers.RunCommand(func(cmd *cobra.Command, args []string) ([]byte, error) {
+		return controller.Version(versionCheck)
+	}),
 }
 
-func init() {}
+func init() {
+	VersionCmd.Flags().BoolVar(&versionCheck, "check", false, "Compare the version to the latest release")
+}
@@ -3,11 +3,13 @@ package helpers
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -45,9 +47,17 @@ func NewApiClient(baseURL, authToken, authType string, timeout ...int) *ApiClien
 
 // request makes an HTTP request with the specified method, endpoint, and body.
 func (client *ApiClient) Request(method string, endpoint string, body interface{}) models.ApiResponse {
+	return client.RequestWithHeaders(method, endpoint, body, nil)
+}
+
+// RequestWithHeaders makes an HTTP request adding the given headers.
+// A []byte body is sent as it is (e.g. registry manifests), any other body is marshalled to JSON.
+func (client *ApiClient) RequestWithHeaders(method string, endpoint string, body interface{}, headers map[string]string) models.ApiResponse {
 	// Create a new buffer for the request body
 	var requestBody *bytes.Buffer
-	if body != nil && (method == "POST" || method == "PUT") {
+	if raw, ok := body.([]byte); ok && (method == "POST" || method == "PUT") {
+		requestBody = bytes.NewBuffer(raw)
+	} else if body != nil && (method == "POST" || method == "PUT") {
 		// Marshal the body to JSON
 		jsonData, err := json.Marshal(body)
 		if err != nil {
@@ -80,9 +90,27 @@ func (client *ApiClient) Request(method string, endpoint string, body interface{
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	// Set the custom headers (they can override the defaults)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	// Trace the request at debug level, the secrets are redacted
+	tracing := slog.Default().Enabled(context.Background(), slog.LevelDebug)
+	if tracing {
+		slog.Debug("http request",
+			"method", method,
+			"url", RedactURL(req.URL.String()),
+			"headers", RedactHeaders(req.Header),
+			"body", RedactBody(requestBody.Bytes()),
+		)
+	}
+
 	// Send the request
+	start := time.Now()
 	resp, err := client.HTTPClient.Do(req)
 	if err != nil {
+		slog.Debug("http request failed", "method", method, "url", RedactURL(req.URL.String()), "error", err)
 		errorMessage := fmt.Sprintf("Api request got an error sending the request: %v", err)
 		return models.NewApiResponse(false, 0, nil, errorMessage, nil)
 	}
@@ -95,6 +123,17 @@ func (client *ApiClient) Request(method string, endpoint string, body interface{
 		return models.NewApiResponse(false, resp.StatusCode, resp.Header, errorMessage, nil)
 	}
 
+	if tracing {
+		slog.Debug("http response",
+			"method", method,
+			"url", RedactURL(req.URL.String()),
+			"status", resp.StatusCode,
+			"duration", time.Since(start),
+			"headers", RedactHeaders(resp.Header),
+			"body", RedactBody(responseBody),
+		)
+	}
+
 	// Create a new ApiResponse object
 	success := resp.StatusCode >= 200 && resp.StatusCode < 300
 	message := "ok"
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// CompletionCacheTTL is how long the dynamic completions are cached, so that
+// pressing tab again does not call the remote services
+var CompletionCacheTTL = 2 * time.Minute
+
+// PromptsDisabled is set when nothing can be asked on the terminal (shell completion)
+var PromptsDisabled bool
+
+// CompletionFunc returns a completion function (ValidArgsFunction or flag completion) with the
+// values of load starting with the word being completed, without the file completion. The config
+// is loaded with the --profile of the command line being completed.
+func CompletionFunc(load func(cmd *cobra.Command, args []string, toComplete string) []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		prepareCompletion(cmd)
+		return FilterCompletions(load(cmd, args, toComplete), toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// ListCompletionFunc is CompletionFunc for the comma-separated flags: the values
+// already in the list are left out and the shell does not add a space
+func ListCompletionFunc(load func(cmd *cobra.Command, args []string, toComplete string) []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		prepareCompletion(cmd)
+		listed := ""
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			listed = toComplete[:i+1]
+		}
+		done := map[string]bool{}
+		for _, value := range strings.Split(listed, ",") {
+			done[value] = true
+		}
+
+		var values []string
+		for _, value := range load(cmd, args, toComplete) {
+			name, _, _ := strings.Cut(value, "\t")
+			if !done[name] {
+				values = append(values, listed+value)
+			}
+		}
+		return FilterCompletions(values, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+// FilterCompletions returns the completions starting with toComplete, a
+// completion can have a description after a tab
+func FilterCompletions(values []string, toComplete string) []string {
+	var filtered []string
+	for _, value := range values {
+		if strings.HasPrefix(value, toComplete) {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
+
+// CachedCompletions returns the completions of the key (of the active profile) from the cache
+// when they are fresh, otherwise from load and caches them. A failure gives no completions,
+// it is only logged at debug level since the shell hides the errors.
+func CachedCompletions(key string, load func() ([]string, error)) []string {
+	sum := sha256.Sum256([]byte(ActiveProfile + "\x00" + key))
+	path := filepath.Join(completionCacheDir(), hex.EncodeToString(sum[:12])+".json")
+
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < CompletionCacheTTL {
+		var values []string
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &values) == nil {
+			return values
+		}
+	}
+
+	values, err := load()
+	if err != nil {
+		slog.Debug("Completion failed", "key", key, "error", err)
+		return nil
+	}
+	if data, err := json.Marshal(values); err == nil && os.MkdirAll(filepath.Dir(path), 0700) == nil {
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			slog.Debug("Failed to cache the completions", "key", key, "error", err)
+		}
+	}
+	return values
+}
+
+// completionCacheDir returns the directory of the completion cache
+func completionCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "sinaloa", "completion")
+	}
+	return filepath.Join(dir, "sinaloa", "completion")
+}
+
+// prepareCompletion loads the config of the profile of the command line being completed
+func prepareCompletion(cmd *cobra.Command) {
+	PromptsDisabled = true
+	profile := ProfileFlag(cmd)
+	err := InitConfig(profile)
+	if err == nil && profile != "" && profile != ActiveProfile {
+		err = LoadConfigFile(ConfigPath(), profile)
+	}
+	if err == nil {
+		err = LoadCredentials(CommandCredentials(cmd)...)
+	}
+	if err != nil {
+		slog.Debug("Failed to load the config for the completion", "error", err)
+	}
+}
+
+// ProfileCompletions returns the profiles of the config file
+func ProfileCompletions(cmd *cobra.Command, args []string, toComplete string) []string {
+	file, err := ReadConfigFile(ConfigPath())
+	if err != nil {
+		return nil
+	}
+	var profiles []string
+	for name := range file.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	return profiles
+}
@@ -1,46 +1,264 @@
 package helpers
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
+// Config holds the settings of the commands. Each field is read from the environment
+// variable with its name and from the key with its yaml name in a config file profile.
 type Config struct {
-	SINALOA_DEBUG       bool
-	ARGOCD_URL          string
-	ARGOCD_USER         string
-	ARGOCD_PASSWORD     string
-	AZURE_TENANT_ID     string
-	AZURE_CLIENT_ID     string
-	AZURE_CLIENT_SECRET string
-	AZURE_DRIVE_ID      string
-	DOCKER_HUB_USER_RWD string
-	DOCKER_HUB_PWD_RWD  string
-	GITHUB_TOKEN        string
+	SINALOA_DEBUG               bool   `yaml:"sinaloa_debug,omitempty"`
+	ARGOCD_URL                  string `yaml:"argocd_url,omitempty"`
+	ARGOCD_USER                 string `yaml:"argocd_user,omitempty"`
+	ARGOCD_PASSWORD             string `yaml:"argocd_password,omitempty"`
+	AZURE_TENANT_ID             string `yaml:"azure_tenant_id,omitempty"`
+	AZURE_CLIENT_ID             string `yaml:"azure_client_id,omitempty"`
+	AZURE_CLIENT_SECRET         string `yaml:"azure_client_secret,omitempty"`
+	AZURE_DRIVE_ID              string `yaml:"azure_drive_id,omitempty"`
+	DOCKER_HUB_USER_RWD         string `yaml:"docker_hub_user_rwd,omitempty"`
+	DOCKER_HUB_PWD_RWD          string `yaml:"docker_hub_pwd_rwd,omitempty"`
+	DOCKER_REGISTRY_URL         string `yaml:"docker_registry_url,omitempty"`
+	DOCKER_REGISTRY_USR         string `yaml:"docker_registry_usr,omitempty"`
+	DOCKER_REGISTRY_PWD         string `yaml:"docker_registry_pwd,omitempty"`
+	GITHUB_TOKEN                string `yaml:"github_token,omitempty"`
+	GITHUB_API_URL              string `yaml:"github_api_url,omitempty"`
+	GITHUB_APP_ID               string `yaml:"github_app_id,omitempty"`
+	GITHUB_APP_INSTALLATION_ID  string `yaml:"github_app_installation_id,omitempty"`
+	GITHUB_APP_PRIVATE_KEY      string `yaml:"github_app_private_key,omitempty"`
+	GITHUB_APP_PRIVATE_KEY_PATH string `yaml:"github_app_private_key_path,omitempty"`
+}
+
+// ConfigFile is the config file (~/.config/sinaloa/config.yaml) with the named profiles
+type ConfigFile struct {
+	CurrentProfile string            `yaml:"current_profile,omitempty"`
+	Profiles       map[string]Config `yaml:"profiles,omitempty"`
 }
 
+// DefaultProfile is the profile used when none is selected
+const DefaultProfile = "default"
+
+// AnnotationManagesProfiles marks the commands that manage the profiles (config set,
+// config use-profile), they run even when the selected profile does not exist yet
+const AnnotationManagesProfiles = "sinaloa/manages-profiles"
+
+// AnnotationCredentials lists the services of auth login (comma-separated) whose stored
+// credentials the command, or the commands under it, uses
+const AnnotationCredentials = "sinaloa/credentials"
+
 var (
 	AppConfig Config
+
+	// ActiveProfile is the profile of the config file AppConfig was loaded from
+	ActiveProfile string
+
+	// activeConfigPath is the config file AppConfig was loaded from, the credential store is next to it
+	activeConfigPath string
+
+	configOnce sync.Once
 )
 
-func LoadConfig() {
-	// Set debug mode
-	debug, err := strconv.ParseBool(os.Getenv("SINALOA_DEBUG"))
+// ConfigPath returns the path of the config file: SINALOA_CONFIG or
+// sinaloa/config.yaml in XDG_CONFIG_HOME (~/.config by default)
+func ConfigPath() string {
+	if path := os.Getenv("SINALOA_CONFIG"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "sinaloa", "config.yaml")
+}
+
+// ReadConfigFile reads the config file, a missing file is an empty one
+func ReadConfigFile(path string) (*ConfigFile, error) {
+	file := &ConfigFile{Profiles: map[string]Config{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = map[string]Config{}
+	}
+	return file, nil
+}
+
+// WriteConfigFile writes the config file readable only by the user (it holds credentials)
+func WriteConfigFile(path string, file *ConfigFile) error {
+	data, err := yaml.Marshal(file)
 	if err != nil {
-		debug = false // default to false if parsing fails or not set
-	}
-	// Set values to AppConfig
-	AppConfig = Config{
-		SINALOA_DEBUG:       debug,
-		ARGOCD_URL:          os.Getenv("ARGOCD_URL"),
-		ARGOCD_USER:         os.Getenv("ARGOCD_USER"),
-		ARGOCD_PASSWORD:     os.Getenv("ARGOCD_PASSWORD"),
-		AZURE_TENANT_ID:     os.Getenv("AZURE_TENANT_ID"),
-		AZURE_CLIENT_ID:     os.Getenv("AZURE_CLIENT_ID"),
-		AZURE_CLIENT_SECRET: os.Getenv("AZURE_CLIENT_SECRET"),
-		AZURE_DRIVE_ID:      os.Getenv("AZURE_DRIVE_ID"),
-		DOCKER_HUB_USER_RWD: os.Getenv("DOCKER_HUB_USER_RWD"),
-		DOCKER_HUB_PWD_RWD:  os.Getenv("DOCKER_HUB_PWD_RWD"),
-		GITHUB_TOKEN:        os.Getenv("GITHUB_TOKEN"),
+		return fmt.Errorf("failed to marshal config file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// SelectProfile returns the profile to use: the given one (--profile), SINALOA_PROFILE,
+// the current profile of the file or the default one
+func SelectProfile(file *ConfigFile, profile string) string {
+	for _, name := range []string{profile, os.Getenv("SINALOA_PROFILE"), file.CurrentProfile} {
+		if name != "" {
+			return name
+		}
+	}
+	return DefaultProfile
+}
+
+// ProfileFlag returns the value of the global --profile flag of the command
+func ProfileFlag(cmd *cobra.Command) string {
+	if flag := cmd.Flag("profile"); flag != nil {
+		return flag.Value.String()
+	}
+	return ""
+}
+
+// InitConfig loads AppConfig once for the run: the values of the profile of the config
+// file, overridden by the environment variables that are set. The credentials stored
+// with auth login are loaded only for the services of the command (LoadCredentials).
+// The flags of the commands override them all. A profile selected by name must exist
+// in the config file.
+func InitConfig(profile string) error {
+	var err error
+	configOnce.Do(func() {
+		err = LoadConfigFile(ConfigPath(), profile)
+	})
+	return err
+}
+
+// LoadConfigFile sets AppConfig from the profile of the config file at path, the credential store and the environment
+func LoadConfigFile(path string, profile string) error {
+	file, err := ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	name := SelectProfile(file, profile)
+	config, ok := file.Profiles[name]
+	if !ok && name != DefaultProfile && name != file.CurrentProfile {
+		return NewCommandError(ExitValidation, fmt.Errorf("profile %q not found in %s", name, path))
+	}
+
+	// The environment variables override the profile, and the stored credentials
+	// loaded later for the services of the command (see LoadCredentials)
+	for _, field := range configFields() {
+		if value, set := os.LookupEnv(field.env); set && value != "" {
+			if err := field.set(&config, value); err != nil {
+				slog.Warn("Ignoring invalid environment variable", "name", field.env, "error", err)
+			}
+		}
+	}
+
+	AppConfig = config
+	ActiveProfile = name
+	activeConfigPath = path
+	return nil
+}
+
+// configField is a setting of Config
+type configField struct {
+	index int
+	env   string // Environment variable, the field name
+	key   string // Config file key, the yaml name
+}
+
+// configFields returns the settings of Config
+func configFields() []configField {
+	var fields []configField
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		fields = append(fields, configField{index: i, env: field.Name, key: key})
+	}
+	return fields
+}
+
+// ConfigKeys returns the keys of the config file
+func ConfigKeys() []string {
+	var keys []string
+	for _, field := range configFields() {
+		keys = append(keys, field.key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConfigEnvName returns the environment variable of a config file key
+func ConfigEnvName(key string) (string, bool) {
+	for _, field := range configFields() {
+		if field.key == key {
+			return field.env, true
+		}
+	}
+	return "", false
+}
+
+// set parses the value into the field of the config
+func (f configField) set(config *Config, value string) error {
+	field := reflect.ValueOf(config).Elem().Field(f.index)
+	if field.Kind() == reflect.Bool {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return ValidationError("invalid value %q for %s: expected true or false", value, f.key)
+		}
+		field.SetBool(parsed)
+		return nil
+	}
+	field.SetString(value)
+	return nil
+}
+
+// SetConfigValue sets the value of a key (config file key or environment variable name)
+func SetConfigValue(config *Config, key string, value string) error {
+	for _, field := range configFields() {
+		if strings.EqualFold(key, field.key) || key == field.env {
+			return field.set(config, value)
+		}
+	}
+	return ValidationError("unknown config key %q (keys: %s)", key, strings.Join(ConfigKeys(), ", "))
+}
+
+// ConfigValues returns the non-empty values of the config by key,
+// the secret ones redacted unless showSecrets is set
+func ConfigValues(config Config, showSecrets bool) map[string]string {
+	values := map[string]string{}
+	value := reflect.ValueOf(config)
+	for _, field := range configFields() {
+		v := value.Field(field.index)
+		if v.IsZero() {
+			continue
+		}
+		if !showSecrets && IsSecretKey(field.key) {
+			values[field.key] = Redacted
+			continue
+		}
+		values[field.key] = fmt.Sprint(v.Interface())
 	}
+	return values
 }
@@ -2,7 +2,7 @@ package helpers
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/messages/errors"
 	"github.com/eltiocaballoloco/sinaloa-cli/src/models/messages/response"
@@ -15,7 +15,7 @@ func HandleControllerApi(result bool, statusCode string, message string, control
 		var jsonData interface{}
 		err := json.Unmarshal(byteData, &jsonData)
 		if err != nil {
-			fmt.Printf("[Error] Failed to unmarshal data: %v\n", err)
+			slog.Error("Failed to unmarshal data", "error", err)
 		} else {
 			data = jsonData // Update data to hold parsed JSON
 		}
@@ -28,16 +28,16 @@ func HandleControllerApi(result bool, statusCode string, message string, control
 		// Marshal the response to JSON
 		jsonResponse, jsonErr := json.MarshalIndent(successResponse, "", "  ")
 		if jsonErr != nil {
-			fmt.Println("[Error] Controller", controllerFunction, ", error marshaling JSON (new response):", jsonErr)
+			slog.Error("Failed to marshal the response", "controller", controllerFunction, "error", jsonErr)
 		}
 		return jsonResponse, err
 	} else {
 		// Print an error message if the controller function failed
-		fmt.Printf("[Error] An error occurred in the controller %s: %v\n", controllerFunction, err)
+		slog.Error("Controller failed", "controller", controllerFunction, "error", err)
 		errorResponse := errors.NewErrorResponse(result, statusCode, message)
 		errorJsonResponse, jsonErr := json.MarshalIndent(errorResponse, "", "  ")
 		if jsonErr != nil {
-			fmt.Println("[Error] Controller", controllerFunction, ", error marshaling JSON (error response):", jsonErr)
+			slog.Error("Failed to marshal the error response", "controller", controllerFunction, "error", jsonErr)
 		}
 		return errorJsonResponse, err
 	}
@@ -52,7 +52,7 @@ func HandleControllerGeneric(message string, controllerFunction string, data int
 		var jsonData interface{}
 		err := json.Unmarshal(byteData, &jsonData)
 		if err != nil {
-			fmt.Printf("[Error] Failed to unmarshal data: %v\n", err)
+			slog.Error("Failed to unmarshal data", "error", err)
 		} else {
 			data = jsonData // Update data to hold parsed JSON
 		}
@@ -65,16 +65,16 @@ func HandleControllerGeneric(message string, controllerFunction string, data int
 		// Marshal the response to JSON
 		jsonResponse, jsonErr := json.MarshalIndent(successResponse, "", "  ")
 		if jsonErr != nil {
-			fmt.Println("[Error] Controller", controllerFunction, ", error marshaling JSON (new response):", jsonErr)
+			slog.Error("Failed to marshal the response", "controller", controllerFunction, "error", jsonErr)
 		}
 		return jsonResponse, err
 	} else {
 		// Print an error message if the controller function failed
-		fmt.Printf("[Error] An error occurred in the controller %s: %v\n", controllerFunction, err)
+		slog.Error("Controller failed", "controller", controllerFunction, "error", err)
 		errorResponse := errors.NewErrorResponse(false, "500", "Error executing the command")
 		errorJsonResponse, jsonErr := json.MarshalIndent(errorResponse, "", "  ")
 		if jsonErr != nil {
-			fmt.Println("[Error] Controller", controllerFunction, ", error marshaling JSON (error response):", jsonErr)
+			slog.Error("Failed to marshal the error response", "controller", controllerFunction, "error", jsonErr)
 		}
 		return errorJsonResponse, err
 	}
@@ -0,0 +1,506 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/scrypt"
+)
+
+// CredentialServices are the services of auth login with the config keys of their credentials
+var CredentialServices = map[string][]string{
+	"argocd":          {"argocd_url", "argocd_user", "argocd_password"},
+	"azure":           {"azure_tenant_id", "azure_client_id", "azure_client_secret", "azure_drive_id"},
+	"docker-hub":      {"docker_hub_user_rwd", "docker_hub_pwd_rwd"},
+	"docker-registry": {"docker_registry_url", "docker_registry_usr", "docker_registry_pwd"},
+	"github":          {"github_token"},
+}
+
+// Credentials are the stored values of a profile by service and config key
+type Credentials map[string]map[string]string
+
+// CredentialStore keeps the credentials of auth login by profile
+type CredentialStore interface {
+	// Name describes the store for the output
+	Name() string
+	// Services returns the services with stored credentials, without reading the secrets when possible
+	Services(profile string) ([]string, error)
+	// Load returns the credentials of the profile
+	Load(profile string) (Credentials, error)
+	// Save replaces the credentials of the profile, empty ones remove it
+	Save(profile string, credentials Credentials) error
+}
+
+// CredentialServiceNames returns the names of the services of auth login
+func CredentialServiceNames() []string {
+	var names []string
+	for name := range CredentialServices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CredentialService returns the service of auth login storing the config key
+func CredentialService(key string) (string, bool) {
+	for service, keys := range CredentialServices {
+		for _, serviceKey := range keys {
+			if serviceKey == key {
+				return service, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Credential stores opened in the run by config path, their passphrase is asked once
+var (
+	openStores   = map[string]CredentialStore{}
+	openStoresMu sync.Mutex
+)
+
+// OpenCredentialStore returns the credential store selected by SINALOA_CREDENTIAL_STORE (keyring or file):
+// by default the Secret Service keyring when secret-tool and a session bus are available, otherwise
+// the passphrase-encrypted credentials.enc next to the config file. The store is opened once per run.
+func OpenCredentialStore(configPath string) (CredentialStore, error) {
+	key := configPath + "|" + os.Getenv("SINALOA_CREDENTIAL_STORE")
+	openStoresMu.Lock()
+	defer openStoresMu.Unlock()
+	if store, ok := openStores[key]; ok {
+		return store, nil
+	}
+
+	var store CredentialStore
+	file := &fileStore{path: filepath.Join(filepath.Dir(configPath), "credentials.enc")}
+	switch os.Getenv("SINALOA_CREDENTIAL_STORE") {
+	case "file":
+		store = file
+	case "keyring":
+		store = &keyringStore{}
+	case "":
+		store = file
+		if keyringAvailable() {
+			store = &keyringStore{}
+		}
+	default:
+		return nil, ValidationError("invalid SINALOA_CREDENTIAL_STORE %q: expected keyring or file", os.Getenv("SINALOA_CREDENTIAL_STORE"))
+	}
+	openStores[key] = store
+	return store, nil
+}
+
+// CommandCredentials returns the services of AnnotationCredentials of the command and its parents
+func CommandCredentials(cmd *cobra.Command) []string {
+	var services []string
+	for c := cmd; c != nil; c = c.Parent() {
+		for _, service := range strings.Split(c.Annotations[AnnotationCredentials], ",") {
+			if service = strings.TrimSpace(service); service != "" && !contains(services, service) {
+				services = append(services, service)
+			}
+		}
+	}
+	return services
+}
+
+// LoadCredentials sets the credentials stored with auth login for the services into AppConfig,
+// the environment variables that are set keep precedence. The store is only read (and the
+// passphrase asked) when a stored service has values that the environment does not set.
+func LoadCredentials(services ...string) error {
+	if len(services) == 0 {
+		return nil
+	}
+	path := activeConfigPath
+	if path == "" {
+		path = ConfigPath()
+	}
+	store, err := OpenCredentialStore(path)
+	if err != nil {
+		return err
+	}
+	return applyStoredCredentials(&AppConfig, store, ActiveProfile, services)
+}
+
+// applyStoredCredentials sets the stored credentials of the services of the profile into the
+// config, except the values set by the environment
+func applyStoredCredentials(config *Config, store CredentialStore, profile string, services []string) error {
+	stored, err := store.Services(profile)
+	if err != nil {
+		return err
+	}
+	var needed []string
+	for _, service := range services {
+		if !contains(stored, service) {
+			continue
+		}
+		for _, key := range CredentialServices[service] {
+			if !setByEnv(key) {
+				needed = append(needed, service)
+				break
+			}
+		}
+	}
+	if len(needed) == 0 {
+		return nil
+	}
+
+	credentials, err := store.Load(profile)
+	if err != nil {
+		return err
+	}
+	for _, service := range needed {
+		for key, value := range credentials[service] {
+			if value == "" || setByEnv(key) {
+				continue
+			}
+			if err := SetConfigValue(config, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// contains returns true if the list has the value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// setByEnv returns true if the environment variable of the config key is set
+func setByEnv(key string) bool {
+	env, ok := ConfigEnvName(key)
+	return ok && os.Getenv(env) != ""
+}
+
+// KEYRING
+
+// errSecretNotFound is returned by secret-tool lookup when nothing is stored
+var errSecretNotFound = errors.New("secret not found")
+
+// Runs secret-tool (Secret Service keyring), replaced in the tests
+var secretTool = func(stdin string, args ...string) ([]byte, error) {
+	cmd := exec.Command("secret-tool", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// A lookup of a missing secret exits with 1 and no message
+		var exitErr *exec.ExitError
+		if args[0] == "lookup" && errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0 && len(out) == 0 {
+			return nil, errSecretNotFound
+		}
+		return nil, fmt.Errorf("secret-tool %s failed: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// keyringAvailable returns true if the Secret Service keyring can be used
+func keyringAvailable() bool {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return false
+	}
+	return os.Getenv("DBUS_SESSION_BUS_ADDRESS") != ""
+}
+
+// keyringStore keeps the credentials of each profile as a JSON secret of the Secret Service keyring
+type keyringStore struct{}
+
+func (s *keyringStore) Name() string {
+	return "keyring (Secret Service)"
+}
+
+func (s *keyringStore) attributes(profile string) []string {
+	return []string{"application", "sinaloa", "profile", profile}
+}
+
+func (s *keyringStore) Services(profile string) ([]string, error) {
+	credentials, err := s.Load(profile)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.services(), nil
+}
+
+func (s *keyringStore) Load(profile string) (Credentials, error) {
+	out, err := secretTool("", append([]string{"lookup"}, s.attributes(profile)...)...)
+	if errors.Is(err, errSecretNotFound) || (err == nil && len(bytes.TrimSpace(out)) == 0) {
+		return Credentials{}, nil
+	}
+	if err != nil {
+		// A locked or unreachable keyring is not an empty one: saving would replace the stored credentials
+		return nil, NewCommandError(ExitAuth, fmt.Errorf("failed to read the credentials of profile %s from the keyring: %w", profile, err))
+	}
+	credentials := Credentials{}
+	if err := json.Unmarshal(out, &credentials); err != nil {
+		return nil, fmt.Errorf("invalid credentials of profile %s in the keyring: %w", profile, err)
+	}
+	return credentials, nil
+}
+
+func (s *keyringStore) Save(profile string, credentials Credentials) error {
+	if len(credentials) == 0 {
+		_, err := secretTool("", append([]string{"clear"}, s.attributes(profile)...)...)
+		return err
+	}
+	data, err := json.Marshal(credentials)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	label := fmt.Sprintf("--label=sinaloa credentials (%s)", profile)
+	_, err = secretTool(string(data), append([]string{"store", label}, s.attributes(profile)...)...)
+	return err
+}
+
+// ENCRYPTED FILE
+
+// Parameters of the scrypt key derivation of the passphrase
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32 // AES-256
+)
+
+// credentialFile is the credentials.enc file: the credentials of all the profiles encrypted
+// with AES-GCM and the key derived from the passphrase, with the index of the stored services
+// in clear (authenticated) to read them only when needed
+type credentialFile struct {
+	Version int                 `json:"version"`
+	Salt    string              `json:"salt"`
+	Nonce   string              `json:"nonce"`
+	Index   map[string][]string `json:"index"`
+	Data    string              `json:"data"`
+}
+
+// fileStore keeps the credentials in a passphrase-encrypted file
+type fileStore struct {
+	path       string
+	passphrase string
+}
+
+func (s *fileStore) Name() string {
+	return "encrypted file " + s.path
+}
+
+func (s *fileStore) Services(profile string) ([]string, error) {
+	file, err := s.read()
+	if err != nil || file == nil {
+		return nil, err
+	}
+	return file.Index[profile], nil
+}
+
+func (s *fileStore) Load(profile string) (Credentials, error) {
+	file, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if file == nil || len(file.Index[profile]) == 0 {
+		return Credentials{}, nil
+	}
+	profiles, err := s.decrypt(file)
+	if err != nil {
+		return nil, err
+	}
+	if credentials, ok := profiles[profile]; ok {
+		return credentials, nil
+	}
+	return Credentials{}, nil
+}
+
+func (s *fileStore) Save(profile string, credentials Credentials) error {
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+	profiles := map[string]Credentials{}
+	if file != nil {
+		if profiles, err = s.decrypt(file); err != nil {
+			return err
+		}
+	}
+
+	if len(credentials) == 0 {
+		delete(profiles, profile)
+	} else {
+		profiles[profile] = credentials
+	}
+	if len(profiles) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove credentials file: %w", err)
+		}
+		return nil
+	}
+	// A new file asks the passphrase twice
+	return s.write(profiles, file == nil)
+}
+
+// read reads the credentials file, nil when it does not exist
+func (s *fileStore) read() (*credentialFile, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	file := &credentialFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", s.path, err)
+	}
+	if file.Version != 1 {
+		return nil, fmt.Errorf("unsupported version %d of credentials file %s", file.Version, s.path)
+	}
+	return file, nil
+}
+
+// decrypt returns the credentials of all the profiles of the file
+func (s *fileStore) decrypt(file *credentialFile) (map[string]Credentials, error) {
+	salt, errSalt := base64.StdEncoding.DecodeString(file.Salt)
+	nonce, errNonce := base64.StdEncoding.DecodeString(file.Nonce)
+	data, errData := base64.StdEncoding.DecodeString(file.Data)
+	if err := errors.Join(errSalt, errNonce, errData); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", s.path, err)
+	}
+
+	passphrase, err := s.getPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newCredentialCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	index, _ := json.Marshal(file.Index)
+	plain, err := aead.Open(nil, nonce, data, index)
+	if err != nil {
+		return nil, NewCommandError(ExitAuth, fmt.Errorf("failed to decrypt %s: wrong passphrase or corrupted file", s.path))
+	}
+
+	profiles := map[string]Credentials{}
+	if err := json.Unmarshal(plain, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", s.path, err)
+	}
+	return profiles, nil
+}
+
+// write encrypts the credentials of all the profiles into the file, with a new salt and nonce
+func (s *fileStore) write(profiles map[string]Credentials, newFile bool) error {
+	passphrase, err := s.getPassphrase(newFile)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := newCredentialCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	file := credentialFile{Version: 1, Index: map[string][]string{}}
+	for profile, credentials := range profiles {
+		file.Index[profile] = credentials.services()
+	}
+	plain, err := json.Marshal(profiles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	index, _ := json.Marshal(file.Index)
+	file.Salt = base64.StdEncoding.EncodeToString(salt)
+	file.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	file.Data = base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plain, index))
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// getPassphrase returns the passphrase of the file: SINALOA_PASSPHRASE or asked on the terminal
+func (s *fileStore) getPassphrase(confirm bool) (string, error) {
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+	if passphrase := os.Getenv("SINALOA_PASSPHRASE"); passphrase != "" {
+		s.passphrase = passphrase
+		return passphrase, nil
+	}
+	if PromptsDisabled || !IsTerminal(os.Stdin) {
+		return "", NewCommandError(ExitAuth, fmt.Errorf("the passphrase of %s is required: set SINALOA_PASSPHRASE", s.path))
+	}
+
+	passphrase, err := ReadSecret("Passphrase of the credential store: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", ValidationError("the passphrase can not be empty")
+	}
+	if confirm {
+		again, err := ReadSecret("Repeat the passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", ValidationError("the passphrases do not match")
+		}
+	}
+	s.passphrase = passphrase
+	return passphrase, nil
+}
+
+// newCredentialCipher returns the AES-GCM cipher with the key derived from the passphrase
+func newCredentialCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive the key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// services returns the services with credentials, sorted
+func (c Credentials) services() []string {
+	var services []string
+	for service, values := range c {
+		if len(values) > 0 {
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
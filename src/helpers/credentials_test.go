@@ -0,0 +1,173 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore_RoundTrip(t *testing.T) {
+	// Arrange: A file store with the passphrase from the environment
+	t.Setenv("SINALOA_PASSPHRASE", "passphrase")
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store := &fileStore{path: path}
+
+	// Act: Store the credentials of two profiles
+	err := store.Save("default", Credentials{"argocd": {"argocd_user": "admin", "argocd_password": "secret"}})
+	assert.NoError(t, err)
+	err = store.Save("prod", Credentials{"github": {"github_token": "ghp_token"}})
+	assert.NoError(t, err)
+
+	// Assert: The secrets are encrypted, the services are listed without the passphrase
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "ghp_token")
+
+	services, err := (&fileStore{path: path, passphrase: "wrong"}).Services("default")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"argocd"}, services)
+
+	credentials, err := (&fileStore{path: path}).Load("default")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", credentials["argocd"]["argocd_password"])
+
+	// A wrong passphrase is an authentication error
+	_, err = (&fileStore{path: path, passphrase: "wrong"}).Load("prod")
+	assert.Error(t, err)
+	assert.Equal(t, ExitAuth, ExitCode(nil, err))
+
+	// Removing all the credentials removes the file
+	assert.NoError(t, store.Save("default", Credentials{}))
+	assert.NoError(t, store.Save("prod", Credentials{}))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestKeyringStore(t *testing.T) {
+	// Arrange: A fake secret-tool keeping the secrets in memory
+	secrets := map[string]string{}
+	original := secretTool
+	defer func() { secretTool = original }()
+	secretTool = func(stdin string, args ...string) ([]byte, error) {
+		attributes := strings.Join(args[len(args)-4:], " ")
+		switch args[0] {
+		case "store":
+			secrets[attributes] = stdin
+		case "lookup":
+			if secret, ok := secrets[attributes]; ok {
+				return []byte(secret), nil
+			}
+			return nil, errSecretNotFound
+		case "clear":
+			delete(secrets, attributes)
+		}
+		return nil, nil
+	}
+	store := &keyringStore{}
+
+	// Act & Assert: Store, read and clear the credentials of a profile
+	assert.NoError(t, store.Save("dev", Credentials{"docker-hub": {"docker_hub_user_rwd": "user"}}))
+	assert.Contains(t, secrets, "application sinaloa profile dev")
+
+	services, err := store.Services("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docker-hub"}, services)
+
+	assert.NoError(t, store.Save("dev", Credentials{}))
+	credentials, err := store.Load("dev")
+	assert.NoError(t, err)
+	assert.Empty(t, credentials)
+}
+
+func TestKeyringStore_LookupError(t *testing.T) {
+	// Arrange: A locked keyring
+	original := secretTool
+	defer func() { secretTool = original }()
+	secretTool = func(stdin string, args ...string) ([]byte, error) {
+		return nil, errors.New("secret-tool lookup failed: exit status 1 Cannot unlock the collection")
+	}
+	store := &keyringStore{}
+
+	// Act: Read the credentials
+	_, loadErr := store.Load("dev")
+	_, servicesErr := store.Services("dev")
+
+	// Assert: The error is returned instead of an empty store
+	assert.Error(t, loadErr, "Load should fail when the keyring can not be read")
+	assert.Equal(t, ExitAuth, ExitCode(nil, loadErr), "Exit code should be the auth one")
+	assert.Error(t, servicesErr, "Services should fail when the keyring can not be read")
+}
+
+func TestLoadConfigFile_StoredCredentials(t *testing.T) {
+	// Arrange: A profile and the stored credentials overriding it, the environment overriding both
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	t.Setenv("SINALOA_CREDENTIAL_STORE", "file")
+	t.Setenv("SINALOA_PASSPHRASE", "passphrase")
+	t.Setenv("SINALOA_PROFILE", "")
+	t.Setenv("ARGOCD_URL", "")
+	t.Setenv("ARGOCD_USER", "")
+	t.Setenv("ARGOCD_PASSWORD", "env")
+	assert.NoError(t, WriteConfigFile(path, &ConfigFile{Profiles: map[string]Config{
+		DefaultProfile: {ARGOCD_URL: "argocd.example.com", ARGOCD_USER: "profile"},
+	}}))
+	store := &fileStore{path: filepath.Join(dir, "credentials.enc")}
+	assert.NoError(t, store.Save(DefaultProfile, Credentials{"argocd": {"argocd_user": "stored", "argocd_password": "stored"}}))
+
+	// Act: Load the config and the stored credentials of the command
+	err := LoadConfigFile(path, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "profile", AppConfig.ARGOCD_USER, "Stored credentials should not be read with the config")
+	err = LoadCredentials("argocd")
+
+	// Assert: env > credential store > profile
+	assert.NoError(t, err)
+	assert.Equal(t, "argocd.example.com", AppConfig.ARGOCD_URL)
+	assert.Equal(t, "stored", AppConfig.ARGOCD_USER)
+	assert.Equal(t, "env", AppConfig.ARGOCD_PASSWORD)
+}
+
+func TestLoadCredentials_OtherServices(t *testing.T) {
+	// Arrange: Stored argocd credentials and no passphrase to decrypt them
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	t.Setenv("SINALOA_CREDENTIAL_STORE", "file")
+	t.Setenv("SINALOA_PASSPHRASE", "passphrase")
+	t.Setenv("SINALOA_PROFILE", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	store := &fileStore{path: filepath.Join(dir, "credentials.enc")}
+	assert.NoError(t, store.Save(DefaultProfile, Credentials{"argocd": {"argocd_password": "stored"}}))
+	t.Setenv("SINALOA_PASSPHRASE", "")
+	PromptsDisabled = true
+	defer func() { PromptsDisabled = false }()
+	assert.NoError(t, LoadConfigFile(path, ""))
+
+	// Act: Load the credentials of a command without services and of a github command
+	noneErr := LoadCredentials()
+	githubErr := LoadCredentials("github")
+	argocdErr := LoadCredentials("argocd")
+
+	// Assert: Only the argocd credentials need the passphrase
+	assert.NoError(t, noneErr, "Command without services should not read the store")
+	assert.NoError(t, githubErr, "Credentials of another service should not be decrypted")
+	assert.Error(t, argocdErr, "Stored credentials of the service should need the passphrase")
+}
+
+func TestCommandCredentials(t *testing.T) {
+	// Arrange: A command under a parent, both with credentials
+	parent := &cobra.Command{Use: "docker", Annotations: map[string]string{AnnotationCredentials: "docker-hub,docker-registry"}}
+	child := &cobra.Command{Use: "delete-images", Annotations: map[string]string{AnnotationCredentials: "argocd, docker-hub"}}
+	parent.AddCommand(child)
+
+	// Act: Get the services of the command
+	services := CommandCredentials(child)
+
+	// Assert: The services of the command and its parents are merged
+	assert.Equal(t, []string{"argocd", "docker-hub", "docker-registry"}, services)
+}
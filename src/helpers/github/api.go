@@ -1,10 +1,12 @@
 package github
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os/exec"
 	"strings"
@@ -19,49 +21,112 @@ var (
 		Timeout: 30 * time.Second,
 	}
 
+	// Base URL of the GitHub API, GITHUB_API_URL overrides it (GitHub Enterprise Server)
+	apiBaseURL = "https://api.github.com"
+
 	// Track which auth method was used (for logging)
 	authMethodUsed string
+
+	// GitHub settings of the run, set by the controllers with Configure
+	config Config
 )
 
+// Config are the GitHub settings of the configuration: the token, the api
+// of GitHub Enterprise Server and the credentials of the GitHub App
+type Config struct {
+	Token             string // GITHUB_TOKEN
+	APIURL            string // GITHUB_API_URL
+	AppID             string // GITHUB_APP_ID
+	AppPrivateKey     string // GITHUB_APP_PRIVATE_KEY
+	AppPrivateKeyPath string // GITHUB_APP_PRIVATE_KEY_PATH
+	AppInstallationID string // GITHUB_APP_INSTALLATION_ID
+}
+
+// Configure sets the GitHub settings used by the api calls
+func Configure(c Config) {
+	appTokensMu.Lock()
+	defer appTokensMu.Unlock()
+	config = c
+}
+
 // GetGitHubToken retrieves the GitHub token from config or gh CLI automatically
 // Priority:
-// 1. GITHUB_TOKEN environment variable (via helpers.AppConfig)
-// 2. gh CLI credentials (automatic fallback)
+// 1. GitHub App installation token (GITHUB_APP_ID and GITHUB_APP_PRIVATE_KEY[_PATH])
+// 2. GITHUB_TOKEN (Config.Token)
+// 3. gh CLI credentials (automatic fallback)
 func GetGitHubToken() (string, error) {
+	// GitHub App, used in CI
+	if appConfigured() {
+		return appInstallationToken()
+	}
+
 	// First, try to get token from config (GITHUB_TOKEN env var)
-	if helpers.AppConfig.GITHUB_TOKEN != "" {
+	if config.Token != "" {
 		if authMethodUsed == "" {
 			authMethodUsed = "GITHUB_TOKEN environment variable"
-			if helpers.AppConfig.SINALOA_DEBUG {
-				fmt.Printf("[DEBUG] Using GitHub authentication from: %s\n", authMethodUsed)
-			}
+			slog.Debug("Using GitHub authentication", "method", authMethodUsed)
 		}
-		return helpers.AppConfig.GITHUB_TOKEN, nil
+		return config.Token, nil
 	}
 
 	// Automatic fallback to gh CLI (for local development)
 	// This allows seamless usage without manual configuration
-	cmd := exec.Command("gh", "auth", "token")
+	args := []string{"auth", "token"}
+	if host := enterpriseHost(); host != "" {
+		args = append(args, "--hostname", host)
+	}
+	cmd := exec.Command("gh", args...)
 	output, err := cmd.Output()
 	if err != nil {
-		return "", fmt.Errorf("GitHub authentication failed. Please either:\n  1. Set GITHUB_TOKEN environment variable, or\n  2. Authenticate with 'gh auth login'\nError: %w", err)
+		return "", helpers.NewCommandError(helpers.ExitAuth, fmt.Errorf("GitHub authentication failed. Please either:\n  1. Set GITHUB_TOKEN environment variable, or\n  2. Authenticate with 'gh auth login'\nError: %w", err))
 	}
 
 	token := strings.TrimSpace(string(output))
 	if token == "" {
-		return "", fmt.Errorf("GitHub token is empty. Please authenticate with 'gh auth login' or set GITHUB_TOKEN env var")
+		return "", helpers.NewCommandError(helpers.ExitAuth, fmt.Errorf("GitHub token is empty. Please authenticate with 'gh auth login' or set GITHUB_TOKEN env var"))
 	}
 
 	if authMethodUsed == "" {
 		authMethodUsed = "gh CLI (automatic fallback)"
-		if helpers.AppConfig.SINALOA_DEBUG {
-			fmt.Printf("[DEBUG] Using GitHub authentication from: %s\n", authMethodUsed)
-		}
+		slog.Debug("Using GitHub authentication", "method", authMethodUsed)
 	}
 
 	return token, nil
 }
 
+// baseURL returns the base URL of the REST api: GITHUB_API_URL for GitHub Enterprise
+// Server (https://<host>/api/v3, the bare host is accepted too) or api.github.com
+func baseURL() string {
+	url := strings.TrimSuffix(config.APIURL, "/")
+	if url == "" {
+		return apiBaseURL
+	}
+	if rest, ok := strings.CutPrefix(url, "https://"); ok && !strings.Contains(rest, "/") && rest != "api.github.com" {
+		url += "/api/v3"
+	}
+	return url
+}
+
+// endpointURL returns the URL of an endpoint, the GraphQL api of GitHub Enterprise
+// Server is at /api/graphql instead of under the REST base URL
+func endpointURL(endpoint string) string {
+	base := baseURL()
+	if endpoint == "/graphql" && strings.HasSuffix(base, "/api/v3") {
+		return strings.TrimSuffix(base, "/v3") + endpoint
+	}
+	return base + endpoint
+}
+
+// enterpriseHost returns the host of GitHub Enterprise Server, empty for github.com
+func enterpriseHost() string {
+	host := strings.TrimPrefix(strings.TrimPrefix(baseURL(), "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	if host == "api.github.com" {
+		return ""
+	}
+	return host
+}
+
 // GetAuthMethod returns the authentication method that was used
 func GetAuthMethod() string {
 	if authMethodUsed == "" {
@@ -70,74 +135,179 @@ func GetAuthMethod() string {
 	return authMethodUsed
 }
 
-// GitHubAPICall makes a generic GitHub API call
+// GitHubAPICall makes a generic GitHub API call.
+// The responses are cached with their ETag and requested again with If-None-Match:
+// a 304 reuses the cached body and does not count against the rate limit.
 func GitHubAPICall(endpoint string, result interface{}) error {
-	token, err := GetGitHubToken()
+	body, _, err := apiGet(endpoint)
 	if err != nil {
 		return err
 	}
 
-	url := fmt.Sprintf("https://api.github.com%s", endpoint)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	return nil
+}
 
-	resp, err := client.Do(req)
+// GetAllPages fetches every page of a list endpoint following the Link header
+func GetAllPages[T any](endpoint string) ([]T, error) {
+	var items []T
+	err := EachPage(endpoint, func(page []T) bool {
+		items = append(items, page...)
+		return true
+	})
 	if err != nil {
-		return fmt.Errorf("failed to make request: %w", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// EachPage fetches the pages of a list endpoint following the Link header
+// until the last one or until fn returns false
+func EachPage[T any](endpoint string, fn func(page []T) bool) error {
+	for endpoint != "" {
+		body, link, err := apiGet(endpoint)
+		if err != nil {
+			return err
+		}
+
+		var page []T
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		if !fn(page) {
+			return nil
+		}
+
+		endpoint = nextPage(link)
 	}
-	defer resp.Body.Close()
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
+// apiGet makes a conditional GET and returns the body and the Link header of the response
+func apiGet(endpoint string) ([]byte, string, error) {
+	token, err := GetGitHubToken()
+	if err != nil {
+		return nil, "", err
+	}
+	key := cacheKey(endpoint, token)
+	cached, hasCached := responses.get(key)
+	headers := map[string]string{}
+	if hasCached {
+		headers["If-None-Match"] = cached.ETag
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	resp, body, err := sendRequest(token, "GET", endpoint, nil, headers)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", err
 	}
 
-	if err := json.Unmarshal(body, result); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %w", err)
+	switch {
+	case resp.StatusCode == http.StatusNotModified && hasCached:
+		return cached.Body, cached.Link, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
-	return nil
+	link := resp.Header.Get("Link")
+	responses.put(key, resp.Header.Get("ETag"), link, body)
+	return body, link, nil
 }
 
-// ListRepositories fetches repositories from an organization
-func ListRepositories(org string) ([]github.GitHubAPIRepository, error) {
-	var repos []github.GitHubAPIRepository
-	page := 1
-	perPage := 100
+// apiRequest sends a request to the GitHub API and reads the response. Before the
+// request it waits when the rate limit is exhausted, and on a primary or secondary
+// rate limit answer (403/429) it waits as told by GitHub and tries again.
+func apiRequest(method string, endpoint string, payload []byte, headers map[string]string) (*http.Response, []byte, error) {
+	token, err := GetGitHubToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sendRequest(token, method, endpoint, payload, headers)
+}
 
-	for {
-		endpoint := fmt.Sprintf("/orgs/%s/repos?page=%d&per_page=%d&type=all", org, page, perPage)
-		var pageRepos []github.GitHubAPIRepository
+// sendRequest is apiRequest with the token already resolved
+func sendRequest(token string, method string, endpoint string, payload []byte, headers map[string]string) (*http.Response, []byte, error) {
+	resource := rateLimitResource(endpoint)
+	for attempt := 0; ; attempt++ {
+		limiter.wait(resource)
 
-		if err := GitHubAPICall(endpoint, &pageRepos); err != nil {
-			return nil, err
+		var reqBody io.Reader
+		if payload != nil {
+			reqBody = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequest(method, endpointURL(endpoint), reqBody)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
-		if len(pageRepos) == 0 {
-			break
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		for key, value := range headers {
+			req.Header.Set(key, value)
 		}
 
-		repos = append(repos, pageRepos...)
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to make request: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		slog.Debug("GitHub API request", "method", method, "endpoint", endpoint, "status", resp.StatusCode,
+			"duration", time.Since(start), "rate_limit_remaining", resp.Header.Get("X-RateLimit-Remaining"))
 
-		if len(pageRepos) < perPage {
-			break
+		limiter.update(resource, resp.Header)
+
+		delay, limited := rateLimitDelay(resp, body)
+		if !limited {
+			return resp, body, nil
+		}
+		if attempt >= maxRateLimitRetries || delay > maxRateLimitWait {
+			return nil, nil, fmt.Errorf("GitHub API rate limit exceeded (status %d, retry in %s): %s", resp.StatusCode, delay.Round(time.Second), string(body))
 		}
+		slog.Warn("GitHub API rate limit reached, waiting", "delay", delay.Round(time.Second), "endpoint", endpoint)
+		sleep(delay)
+	}
+}
 
-		page++
+// nextPage returns the endpoint of the next page from a Link header
+func nextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, rel, ok := strings.Cut(part, ";")
+		if !ok || !strings.Contains(rel, `rel="next"`) {
+			continue
+		}
+		target = strings.Trim(strings.TrimSpace(target), "<>")
+		return strings.TrimPrefix(target, baseURL())
 	}
+	return ""
+}
 
-	return repos, nil
+// ListRepositories fetches repositories from an organization
+func ListRepositories(org string) ([]github.GitHubAPIRepository, error) {
+	endpoint := fmt.Sprintf("/orgs/%s/repos?per_page=100&type=all", org)
+	return GetAllPages[github.GitHubAPIRepository](endpoint)
+}
+
+// ListOrganizations fetches the logins of the organizations of the authenticated user
+func ListOrganizations() ([]string, error) {
+	orgs, err := GetAllPages[struct {
+		Login string `json:"login"`
+	}]("/user/orgs?per_page=100")
+	if err != nil {
+		return nil, err
+	}
+	var logins []string
+	for _, org := range orgs {
+		logins = append(logins, org.Login)
+	}
+	return logins, nil
 }
 
 // GetRepoTree fetches the repository tree structure
@@ -185,25 +355,3 @@ func GetDefaultBranchCommit(repoFullName string, branch string) (string, error)
 
 	return commit.SHA, nil
 }
-
-// FilterRepositories filters repositories based on query patterns
-func FilterRepositories(repos []github.GitHubAPIRepository, query string) []github.GitHubAPIRepository {
-	if query == "" {
-		return repos
-	}
-
-	patterns := strings.Split(query, ",")
-	var filtered []github.GitHubAPIRepository
-
-	for _, repo := range repos {
-		for _, pattern := range patterns {
-			pattern = strings.TrimSpace(pattern)
-			if strings.Contains(repo.Name, pattern) {
-				filtered = append(filtered, repo)
-				break
-			}
-		}
-	}
-
-	return filtered
-}
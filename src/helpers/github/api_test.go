@@ -0,0 +1,236 @@
+package github
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestAPI points the helpers to a test server with empty caches and no real sleeps
+func newTestAPI(t *testing.T, handler http.HandlerFunc) *[]time.Duration {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	oldBaseURL, oldResponses, oldLimiter, oldSleep, oldConfig := apiBaseURL, responses, limiter, sleep, config
+	t.Cleanup(func() {
+		apiBaseURL, responses, limiter, sleep, config = oldBaseURL, oldResponses, oldLimiter, oldSleep, oldConfig
+	})
+
+	var slept []time.Duration
+	apiBaseURL = server.URL
+	responses = &etagCache{entries: map[string]cachedResponse{}}
+	limiter = &rateLimiter{limits: map[string]rateLimit{}}
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	Configure(Config{Token: "test-token"})
+	return &slept
+}
+
+func TestGitHubAPICall_ETag(t *testing.T) {
+	// Arrange: A server answering 304 to the known ETag
+	requests := 0
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"sha":"abc"}`)
+	})
+
+	// Act: Call twice
+	var first, second github.GitHubCommit
+	err1 := GitHubAPICall("/repos/org/app/commits/main", &first)
+	err2 := GitHubAPICall("/repos/org/app/commits/main", &second)
+
+	// Assert: The second call reuses the cached body
+	assert.NoError(t, err1, "First call should not return an error")
+	assert.NoError(t, err2, "Conditional call should not return an error")
+	assert.Equal(t, 2, requests, "Requests should match")
+	assert.Equal(t, "abc", second.SHA, "Cached body should be returned on 304")
+}
+
+func TestGitHubAPICall_SecondaryRateLimit(t *testing.T) {
+	// Arrange: A server answering a secondary rate limit, then the response
+	requests := 0
+	slept := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit"}`)
+			return
+		}
+		fmt.Fprint(w, `{"sha":"abc"}`)
+	})
+
+	// Act: Call the API
+	var commit github.GitHubCommit
+	err := GitHubAPICall("/repos/org/app/commits/main", &commit)
+
+	// Assert: The call waits Retry-After and tries again
+	assert.NoError(t, err, "Call should succeed after the wait")
+	assert.Equal(t, []time.Duration{7 * time.Second}, *slept, "Waits should match")
+	assert.Equal(t, "abc", commit.SHA, "Commit should match")
+}
+
+func TestGitHubAPICall_ExhaustedRateLimit(t *testing.T) {
+	// Arrange: A response exhausting the core rate limit for the next 30 seconds
+	reset := time.Now().Add(30 * time.Second).Unix()
+	slept := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset))
+		w.Header().Set("X-RateLimit-Resource", "core")
+		fmt.Fprint(w, `{"sha":"abc"}`)
+	})
+
+	// Act: Call twice
+	var commit github.GitHubCommit
+	_ = GitHubAPICall("/repos/org/app/commits/a", &commit)
+	_ = GitHubAPICall("/repos/org/app/commits/b", &commit)
+
+	// Assert: The second call waits for the reset before the request
+	assert.Len(t, *slept, 1, "Second call should wait")
+	assert.InDelta(t, 30, (*slept)[0].Seconds(), 2, "Wait should last until the reset")
+}
+
+func TestGitHubAPICall_ExhaustedSearchRateLimit(t *testing.T) {
+	// Arrange: A response exhausting the code search rate limit, named by GitHub code_search
+	reset := time.Now().Add(30 * time.Second).Unix()
+	slept := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset))
+		w.Header().Set("X-RateLimit-Resource", "code_search")
+		fmt.Fprint(w, `{"total_count":0,"items":[]}`)
+	})
+
+	// Act: Search twice, then call a core endpoint
+	var result map[string]interface{}
+	_ = GitHubAPICall("/search/code?q=a", &result)
+	_ = GitHubAPICall("/search/code?q=b", &result)
+	_ = GitHubAPICall("/repos/org/app", &result)
+
+	// Assert: Only the second search waits for the reset
+	assert.Len(t, *slept, 1, "Second search should wait, the core endpoint should not")
+}
+
+func TestGetAllPages(t *testing.T) {
+	// Arrange: Three pages linked by the Link header
+	var baseURL string
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		switch page {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/org/repos?page=2>; rel="next", <%s/orgs/org/repos?page=3>; rel="last"`, baseURL, baseURL))
+			fmt.Fprint(w, `[{"full_name":"org/a"},{"full_name":"org/b"}]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/org/repos?page=3>; rel="next"`, baseURL))
+			fmt.Fprint(w, `[{"full_name":"org/c"}]`)
+		default:
+			fmt.Fprint(w, `[{"full_name":"org/d"}]`)
+		}
+	})
+	baseURL = apiBaseURL
+
+	// Act: Fetch every page
+	repos, err := GetAllPages[github.GitHubAPIRepository]("/orgs/org/repos")
+
+	// Assert: The items of all the pages are returned in order
+	assert.NoError(t, err, "Pagination should not return an error")
+	var names []string
+	for _, repo := range repos {
+		names = append(names, repo.FullName)
+	}
+	assert.Equal(t, []string{"org/a", "org/b", "org/c", "org/d"}, names, "Repositories should match")
+}
+
+func TestGetRepoSnapshots(t *testing.T) {
+	// Arrange: A GraphQL answer with a readable and a missing repository
+	var query string
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query = string(body)
+		fmt.Fprint(w, `{"data":{
+			"r0":{"defaultBranchRef":{"target":{"oid":"sha-a"}},
+				"d0":{"entries":[{"name":"api","type":"tree","object":{"entries":[{"name":"manifest.yaml","type":"blob"}]}},{"name":"README.md","type":"blob"}]},
+				"d1":null},
+			"r1":null},
+			"errors":[{"message":"Could not resolve to a Repository with the name 'org/gone'."}]}`)
+	})
+	repos := []github.GitHubAPIRepository{{FullName: "org/app"}, {FullName: "org/gone"}}
+
+	// Act: Fetch the snapshots
+	snapshots, err := GetRepoSnapshots(repos, []string{"apps", "src"}, 0)
+
+	// Assert: The readable repository is returned with its files, the missing one is left out
+	assert.NoError(t, err, "Partial errors should not fail the batch")
+	assert.True(t, strings.Contains(query, `HEAD:apps`), "Query should read the folders")
+	assert.Equal(t, map[string]github.RepoSnapshot{
+		"org/app": {CommitSHA: "sha-a", Files: []string{"apps/api/manifest.yaml", "apps/README.md"}},
+	}, snapshots, "Snapshots should match")
+}
+
+func TestGetRepoSnapshots_Truncated(t *testing.T) {
+	// Arrange: A folder with a subtree below the depth of the query
+	var query string
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query = string(body)
+		fmt.Fprint(w, `{"data":{"r0":{"defaultBranchRef":{"target":{"oid":"sha-a"}},
+			"d0":{"entries":[{"name":"api","type":"tree","object":{"entries":[{"name":"overlays","type":"tree"}]}}]}}}}`)
+	})
+
+	// Act: Fetch the snapshot 4 levels deep
+	snapshots, err := GetRepoSnapshots([]github.GitHubAPIRepository{{FullName: "org/app"}}, []string{"apps"}, 4)
+
+	// Assert: The query reads 4 levels and the snapshot is marked truncated
+	assert.NoError(t, err)
+	assert.Contains(t, query, "...tree4", "Query should read the requested depth")
+	assert.True(t, snapshots["org/app"].Truncated, "Unread subtree should mark the snapshot truncated")
+}
+
+func TestGitHubAPICall_CachePerIdentity(t *testing.T) {
+	// Arrange: An on-disk cache and a server answering 304 to any known ETag
+	requests := map[string]int{}
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests[r.Header.Get("Authorization")]++
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"sha":"`+strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")+`"}`)
+	})
+	dir := filepath.Join(t.TempDir(), "github")
+	assert.NoError(t, SetCacheDir(dir))
+	t.Cleanup(func() { responses.dir = "" })
+
+	// Act: Call with two accounts
+	var first, second github.GitHubCommit
+	err1 := GitHubAPICall("/repos/org/app/commits/main", &first)
+	Configure(Config{Token: "other-token"})
+	err2 := GitHubAPICall("/repos/org/app/commits/main", &second)
+
+	// Assert: The second account does not get the response of the first one
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.Equal(t, "test-token", first.SHA)
+	assert.Equal(t, "other-token", second.SHA, "Cached response of another token should not be used")
+
+	info, err := os.Stat(filepath.Join(dir, "responses"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm(), "Cache directory should be private")
+	files, _ := os.ReadDir(filepath.Join(dir, "responses"))
+	assert.Len(t, files, 2, "Every identity should have its own entry")
+	fileInfo, _ := files[0].Info()
+	assert.Equal(t, os.FileMode(0600), fileInfo.Mode().Perm(), "Cache files should be private")
+}
@@ -0,0 +1,187 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An installation token is renewed this long before it expires
+const installationTokenMargin = 5 * time.Minute
+
+// installationToken is an access token of a GitHub App installation
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var (
+	appTokensMu sync.Mutex
+	// Installation tokens of the run, keyed on installation id or organization
+	appTokens = map[string]installationToken{}
+
+	// Organization the installation token is requested for
+	organization string
+)
+
+// SetOrganization sets the organization of the GitHub App installation
+// (not needed when GITHUB_APP_INSTALLATION_ID is set)
+func SetOrganization(org string) {
+	appTokensMu.Lock()
+	defer appTokensMu.Unlock()
+	organization = org
+}
+
+// appConfigured returns true if the GitHub App credentials are set
+func appConfigured() bool {
+	return config.AppID != "" && (config.AppPrivateKey != "" || config.AppPrivateKeyPath != "")
+}
+
+// appInstallationToken returns the installation token of the GitHub App, exchanging a JWT
+// signed with the app private key. The token is cached until a few minutes before expiry.
+func appInstallationToken() (string, error) {
+	appTokensMu.Lock()
+	defer appTokensMu.Unlock()
+
+	installationID := config.AppInstallationID
+	key := installationID
+	if key == "" {
+		if organization == "" {
+			return "", fmt.Errorf("GitHub App authentication requires GITHUB_APP_INSTALLATION_ID or an organization")
+		}
+		key = "org:" + organization
+	}
+	if cached, ok := appTokens[key]; ok && now().Add(installationTokenMargin).Before(cached.ExpiresAt) {
+		return cached.Token, nil
+	}
+
+	privateKey, err := appPrivateKey()
+	if err != nil {
+		return "", err
+	}
+	jwt, err := appJWT(config.AppID, privateKey, now())
+	if err != nil {
+		return "", err
+	}
+
+	// Installation of the app in the organization
+	if installationID == "" {
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		if err := appRequest("GET", fmt.Sprintf("/orgs/%s/installation", organization), jwt, &installation); err != nil {
+			return "", fmt.Errorf("GitHub App %s is not installed in %s: %w", config.AppID, organization, err)
+		}
+		installationID = fmt.Sprint(installation.ID)
+	}
+
+	var token installationToken
+	if err := appRequest("POST", fmt.Sprintf("/app/installations/%s/access_tokens", installationID), jwt, &token); err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+	appTokens[key] = token
+
+	if authMethodUsed == "" {
+		authMethodUsed = fmt.Sprintf("GitHub App %s (installation %s)", config.AppID, installationID)
+		slog.Debug("Using GitHub authentication", "method", authMethodUsed)
+	}
+	return token.Token, nil
+}
+
+// appRequest makes a request authenticated as the GitHub App (JWT)
+func appRequest(method string, endpoint string, jwt string, result interface{}) error {
+	req, err := http.NewRequest(method, baseURL()+endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// appJWT returns the JWT of the app, valid for 9 minutes (GitHub allows 10)
+// and issued one minute in the past to allow for clock drift
+func appJWT(appID string, privateKey *rsa.PrivateKey, issuedAt time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": issuedAt.Add(-time.Minute).Unix(),
+		"exp": issuedAt.Add(9 * time.Minute).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// appPrivateKey reads the app private key from GITHUB_APP_PRIVATE_KEY (PEM, escaped
+// newlines allowed) or from the file at GITHUB_APP_PRIVATE_KEY_PATH of the Config
+func appPrivateKey() (*rsa.PrivateKey, error) {
+	data := []byte(strings.ReplaceAll(config.AppPrivateKey, `\n`, "\n"))
+	if len(data) == 0 {
+		var err error
+		data, err = os.ReadFile(config.AppPrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+		}
+	}
+	return parsePrivateKey(data)
+}
+
+// parsePrivateKey parses a PEM RSA private key, PKCS#1 (the format of GitHub) or PKCS#8
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid GitHub App private key: no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid GitHub App private key: not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,91 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGitHubToken_App(t *testing.T) {
+	// Arrange: A GitHub App installed in the organization
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err, "Key should be generated")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	exchanges := 0
+	var issuer interface{}
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var parsed map[string]interface{}
+		_ = json.Unmarshal(claims, &parsed)
+		issuer = parsed["iss"]
+
+		switch r.URL.Path {
+		case "/orgs/org/installation":
+			fmt.Fprint(w, `{"id":42}`)
+		case "/app/installations/42/access_tokens":
+			exchanges++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token":"ghs_installation","expires_at":%q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	oldConfig, oldTokens, oldOrg, oldMethod := config, appTokens, organization, authMethodUsed
+	t.Cleanup(func() {
+		config, appTokens, organization, authMethodUsed = oldConfig, oldTokens, oldOrg, oldMethod
+	})
+	Configure(Config{AppID: "1234", AppPrivateKey: strings.ReplaceAll(string(keyPEM), "\n", `\n`)})
+	appTokens = map[string]installationToken{}
+	SetOrganization("org")
+
+	// Act: Ask the token twice
+	first, err := GetGitHubToken()
+	second, _ := GetGitHubToken()
+
+	// Assert: The installation token is exchanged once and cached
+	assert.NoError(t, err, "App token should not return an error")
+	assert.Equal(t, "ghs_installation", first, "Token should match")
+	assert.Equal(t, first, second, "Cached token should be returned")
+	assert.Equal(t, 1, exchanges, "Token should be exchanged once")
+	assert.Equal(t, "1234", issuer, "JWT issuer should be the app id")
+}
+
+func TestBaseURL_Enterprise(t *testing.T) {
+	// Arrange: Restore the configuration at the end
+	oldConfig := config
+	t.Cleanup(func() { config = oldConfig })
+
+	// Act & Assert: github.com, GitHub Enterprise Server with and without the api path
+	Configure(Config{APIURL: ""})
+	assert.Equal(t, "https://api.github.com/graphql", endpointURL("/graphql"), "github.com GraphQL should match")
+	assert.Equal(t, "", enterpriseHost(), "github.com should not be an enterprise host")
+
+	Configure(Config{APIURL: "https://ghe.example.com"})
+	assert.Equal(t, "https://ghe.example.com/api/v3/orgs/org/repos", endpointURL("/orgs/org/repos"), "Bare host should get the api path")
+	assert.Equal(t, "https://ghe.example.com/api/graphql", endpointURL("/graphql"), "GHES GraphQL should match")
+	assert.Equal(t, "ghe.example.com", enterpriseHost(), "Enterprise host should match")
+
+	Configure(Config{APIURL: "https://ghe.example.com/api/v3/"})
+	assert.Equal(t, "https://ghe.example.com/api/v3/user", endpointURL("/user"), "Full api URL should be kept")
+}
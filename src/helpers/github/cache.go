@@ -0,0 +1,117 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cachedResponse is a response saved with its ETag, a 304 answer reuses the body
+type cachedResponse struct {
+	Key  string          `json:"key"`
+	ETag string          `json:"etag"`
+	Link string          `json:"link,omitempty"` // Pagination of list endpoints
+	Body json.RawMessage `json:"body"`
+}
+
+// etagCache keeps the responses in memory for the run and, when a directory
+// is set, on disk so that the next runs can send conditional requests too
+type etagCache struct {
+	mu      sync.Mutex
+	dir     string
+	entries map[string]cachedResponse
+}
+
+var responses = &etagCache{entries: map[string]cachedResponse{}}
+
+// SetCacheDir enables the on-disk cache of the responses in dir ("" keeps the cache in memory only).
+// The responses contain private repository data, the directory is readable by the owner only.
+func SetCacheDir(dir string) error {
+	if dir != "" {
+		if err := PrivateDir(filepath.Join(dir, "responses")); err != nil {
+			return err
+		}
+	}
+	responses.mu.Lock()
+	defer responses.mu.Unlock()
+	responses.dir = dir
+	return nil
+}
+
+// DefaultCacheDir returns the cache directory of the github commands
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "sinaloa", "github")
+	}
+	return filepath.Join(dir, "sinaloa", "github")
+}
+
+// PrivateDir creates the directory (and its parents) readable by the owner only,
+// an existing directory is restricted too
+func PrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return os.Chmod(dir, 0700)
+}
+
+// cacheKey returns the key of the response of the endpoint: the API host and the
+// identity of the token are part of it, so that the profiles and the accounts
+// never get each other's responses
+func cacheKey(endpoint string, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return baseURL() + " " + hex.EncodeToString(sum[:8]) + " " + endpoint
+}
+
+// get returns the cached response of the key
+func (c *etagCache) get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		return entry, true
+	}
+	if c.dir == "" {
+		return cachedResponse{}, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return cachedResponse{}, false
+	}
+	var entry cachedResponse
+	// A broken file or a hash collision is a cache miss
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key || entry.ETag == "" {
+		return cachedResponse{}, false
+	}
+	c.entries[key] = entry
+	return entry, true
+}
+
+// put saves the response of the key, the on-disk cache is best effort
+func (c *etagCache) put(key string, etag string, link string, body []byte) {
+	if etag == "" || !json.Valid(body) {
+		return
+	}
+	entry := cachedResponse{Key: key, ETag: etag, Link: link, Body: body}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	if c.dir == "" {
+		return
+	}
+	if data, err := json.Marshal(entry); err == nil {
+		_ = os.WriteFile(c.path(key), data, 0600)
+	}
+}
+
+// path returns the file of the key in the cache directory
+func (c *etagCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, "responses", hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,220 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+)
+
+const (
+	// Repositories per snapshot query
+	snapshotBatchSize = 25
+	// Files per content query
+	contentBatchSize = 50
+	// Default levels of the folder trees read by the snapshot query (folder/project/manifest.yaml is 2)
+	DefaultSnapshotDepth = 3
+)
+
+// graphQLResponse is the envelope of a GraphQL answer
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// treeEntry is an entry of a tree read with GraphQL (object is set for subtrees)
+type treeEntry struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Object *struct {
+		Entries []treeEntry `json:"entries"`
+	} `json:"object"`
+}
+
+// GraphQLCall makes a GitHub GraphQL query. Errors of single fields (e.g. a missing
+// repository in a batch) leave those fields null and do not fail the whole query.
+func GraphQLCall(query string, variables map[string]interface{}, result interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("failed to marshal GraphQL query: %w", err)
+	}
+
+	resp, body, err := apiRequest("POST", "/graphql", payload, map[string]string{"Content-Type": "application/json"})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub GraphQL error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var response graphQLResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to unmarshal GraphQL response: %w", err)
+	}
+	if len(response.Data) == 0 || string(response.Data) == "null" {
+		if len(response.Errors) > 0 {
+			return fmt.Errorf("GitHub GraphQL error: %s", response.Errors[0].Message)
+		}
+		return fmt.Errorf("GitHub GraphQL error: empty response")
+	}
+	for _, e := range response.Errors {
+		slog.Debug("GraphQL partial error", "message", e.Message)
+	}
+
+	if err := json.Unmarshal(response.Data, result); err != nil {
+		return fmt.Errorf("failed to unmarshal GraphQL data: %w", err)
+	}
+	return nil
+}
+
+// GetRepoSnapshots fetches the default-branch commit and the files under the folders of
+// many repositories, 25 per query, down to depth levels (DefaultSnapshotDepth when 0).
+// Repositories that cannot be read are left out of the result, the callers scan them with
+// the REST api, as well as the snapshots marked truncated (folders deeper than depth).
+func GetRepoSnapshots(repos []github.GitHubAPIRepository, folders []string, depth int) (map[string]github.RepoSnapshot, error) {
+	if depth <= 0 {
+		depth = DefaultSnapshotDepth
+	}
+	snapshots := make(map[string]github.RepoSnapshot, len(repos))
+	for start := 0; start < len(repos); start += snapshotBatchSize {
+		end := min(start+snapshotBatchSize, len(repos))
+		batch := repos[start:end]
+
+		var data map[string]json.RawMessage
+		if err := GraphQLCall(snapshotQuery(batch, folders, depth), nil, &data); err != nil {
+			return nil, err
+		}
+
+		for i, repo := range batch {
+			if snapshot, ok := parseSnapshot(data[fmt.Sprintf("r%d", i)], folders); ok {
+				snapshots[repo.FullName] = snapshot
+			}
+		}
+	}
+	return snapshots, nil
+}
+
+// GetFileContents fetches the content of many files, 50 per query. Files that cannot
+// be read with GraphQL (missing, binary or truncated) are left out of the result.
+func GetFileContents(files []github.FileRef) (map[github.FileRef][]byte, error) {
+	contents := make(map[github.FileRef][]byte, len(files))
+	for start := 0; start < len(files); start += contentBatchSize {
+		end := min(start+contentBatchSize, len(files))
+		batch := files[start:end]
+
+		var query strings.Builder
+		query.WriteString("query {\n")
+		for i, file := range batch {
+			owner, name, _ := strings.Cut(file.Repo, "/")
+			fmt.Fprintf(&query, "  f%d: repository(owner: %s, name: %s) { object(expression: %s) { ... on Blob { text isBinary isTruncated } } }\n",
+				i, graphQLString(owner), graphQLString(name), graphQLString(file.Ref+":"+file.Path))
+		}
+		query.WriteString("}")
+
+		var data map[string]*struct {
+			Object *struct {
+				Text        *string `json:"text"`
+				IsBinary    bool    `json:"isBinary"`
+				IsTruncated bool    `json:"isTruncated"`
+			} `json:"object"`
+		}
+		if err := GraphQLCall(query.String(), nil, &data); err != nil {
+			return nil, err
+		}
+
+		for i, file := range batch {
+			repo := data[fmt.Sprintf("f%d", i)]
+			if repo == nil || repo.Object == nil || repo.Object.Text == nil || repo.Object.IsBinary || repo.Object.IsTruncated {
+				continue
+			}
+			contents[file] = []byte(*repo.Object.Text)
+		}
+	}
+	return contents, nil
+}
+
+// snapshotQuery builds the query of a batch of repositories: the commit of the
+// default branch and, for every folder, its tree down to depth levels
+func snapshotQuery(repos []github.GitHubAPIRepository, folders []string, depth int) string {
+	var query strings.Builder
+	query.WriteString("query {\n")
+	for i, repo := range repos {
+		owner, name, _ := strings.Cut(repo.FullName, "/")
+		fmt.Fprintf(&query, "  r%d: repository(owner: %s, name: %s) {\n", i, graphQLString(owner), graphQLString(name))
+		query.WriteString("    defaultBranchRef { target { oid } }\n")
+		for j, folder := range folders {
+			fmt.Fprintf(&query, "    d%d: object(expression: %s) { ...tree%d }\n", j, graphQLString("HEAD:"+folder), depth)
+		}
+		query.WriteString("  }\n")
+	}
+	query.WriteString("}\n")
+
+	// A fragment per level, GraphQL fragments cannot be recursive
+	query.WriteString("fragment tree1 on Tree { entries { name type } }\n")
+	for level := 2; level <= depth; level++ {
+		fmt.Fprintf(&query, "fragment tree%d on Tree { entries { name type object { ...tree%d } } }\n", level, level-1)
+	}
+	return query.String()
+}
+
+// parseSnapshot reads the snapshot of a repository from its part of the answer
+func parseSnapshot(raw json.RawMessage, folders []string) (github.RepoSnapshot, bool) {
+	var repo map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &repo); err != nil || repo == nil {
+		return github.RepoSnapshot{}, false
+	}
+
+	var ref *struct {
+		Target struct {
+			Oid string `json:"oid"`
+		} `json:"target"`
+	}
+	if err := json.Unmarshal(repo["defaultBranchRef"], &ref); err != nil || ref == nil || ref.Target.Oid == "" {
+		return github.RepoSnapshot{}, false
+	}
+
+	snapshot := github.RepoSnapshot{CommitSHA: ref.Target.Oid}
+	for j, folder := range folders {
+		var tree *struct {
+			Entries []treeEntry `json:"entries"`
+		}
+		if err := json.Unmarshal(repo[fmt.Sprintf("d%d", j)], &tree); err != nil || tree == nil {
+			continue // Folder missing in the repository
+		}
+		files, truncated := blobPaths(folder, tree.Entries)
+		snapshot.Files = append(snapshot.Files, files...)
+		snapshot.Truncated = snapshot.Truncated || truncated
+	}
+	return snapshot, true
+}
+
+// blobPaths returns the paths of the files in the entries of the tree at dir, truncated
+// is true when a subtree was not read (it is below the depth of the query)
+func blobPaths(dir string, entries []treeEntry) (paths []string, truncated bool) {
+	for _, entry := range entries {
+		entryPath := path.Join(dir, entry.Name)
+		switch {
+		case entry.Type == "blob":
+			paths = append(paths, entryPath)
+		case entry.Type == "tree" && entry.Object != nil:
+			files, subTruncated := blobPaths(entryPath, entry.Object.Entries)
+			paths = append(paths, files...)
+			truncated = truncated || subTruncated
+		case entry.Type == "tree":
+			truncated = true
+		}
+	}
+	return paths, truncated
+}
+
+// graphQLString quotes a value as a GraphQL string literal
+func graphQLString(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
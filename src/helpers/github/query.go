@@ -0,0 +1,439 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+)
+
+// RepoQuery is a parsed repository query. Terms are separated by spaces and must all
+// match, a term starting with - is negated and comma-separated values match any of them:
+//
+//	name:svc-*  name:/^svc-/  name glob or regex (full name when the pattern has a /)
+//	topic:payments            the repository has the topic
+//	language:go,python        primary language
+//	archived:false fork:false private:true visibility:internal
+//	is:archived is:fork is:private is:public
+//	pushed:>2024-01-01 pushed:<30d  pushed after/before a date, RFC3339 time or duration
+//	team:platform             the repository belongs to the team of the organization
+//
+// A query made of a single term without key keeps the old format: a comma list of
+// name substrings where a leading - is part of the substring ("-api-,web-" matches
+// names containing -api- or web-). Use -name: to negate a single name term.
+type RepoQuery struct {
+	terms []queryTerm
+}
+
+// queryTerm is a term of the query
+type queryTerm struct {
+	key    string
+	values []string
+	negate bool
+	match  func(repo github.GitHubAPIRepository) bool
+}
+
+// Keys of the query and their aliases
+var queryKeys = map[string]string{
+	"name": "name", "repo": "name",
+	"topic": "topic", "topics": "topic",
+	"language": "language", "lang": "language",
+	"archived": "archived", "fork": "fork", "private": "private",
+	"visibility": "visibility", "is": "is",
+	"pushed": "pushed", "team": "team",
+}
+
+// ParseRepoQuery parses a repository query, an empty query matches every repository
+func ParseRepoQuery(query string) (*RepoQuery, error) {
+	// "a, b" is a single comma list as in the old query format
+	query = regexp.MustCompile(`\s*,\s*`).ReplaceAllString(strings.TrimSpace(query), ",")
+
+	q := &RepoQuery{}
+	if isLegacyQuery(query) {
+		values := strings.Split(query, ",")
+		match, err := termMatcher("name", values)
+		if err != nil {
+			return nil, err
+		}
+		q.terms = append(q.terms, queryTerm{key: "name", values: values, match: match})
+		return q, nil
+	}
+
+	for _, field := range strings.Fields(query) {
+		term := queryTerm{key: "name"}
+		if strings.HasPrefix(field, "-") && len(field) > 1 {
+			term.negate = true
+			field = field[1:]
+		}
+
+		value := field
+		if key, rest, ok := strings.Cut(field, ":"); ok && !strings.HasPrefix(field, "/") {
+			canonical, known := queryKeys[strings.ToLower(key)]
+			if !known {
+				return nil, fmt.Errorf("unknown query key %q in %q", key, field)
+			}
+			term.key, value = canonical, rest
+		}
+		if value == "" {
+			return nil, fmt.Errorf("empty value in query term %q", field)
+		}
+		term.values = splitValues(term.key, value)
+
+		match, err := termMatcher(term.key, term.values)
+		if err != nil {
+			return nil, err
+		}
+		term.match = match
+		q.terms = append(q.terms, term)
+	}
+	return q, nil
+}
+
+// isLegacyQuery returns true if the query is in the old format: a single comma list
+// of name substrings, with no key and no regex
+func isLegacyQuery(query string) bool {
+	if query == "" || strings.ContainsAny(query, " \t:") {
+		return false
+	}
+	return !strings.HasPrefix(strings.TrimPrefix(query, "-"), "/")
+}
+
+// Matches returns true if the repository matches every term of the query.
+// Team terms need the team repositories, see ResolveTeams.
+func (q *RepoQuery) Matches(repo github.GitHubAPIRepository) bool {
+	for _, term := range q.terms {
+		if term.match(repo) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Teams returns the team slugs of the query
+func (q *RepoQuery) Teams() []string {
+	var teams []string
+	for _, term := range q.terms {
+		if term.key == "team" {
+			teams = append(teams, term.values...)
+		}
+	}
+	return teams
+}
+
+// ResolveTeams sets the repositories of the teams (full names by team slug) used by the team terms
+func (q *RepoQuery) ResolveTeams(teamRepos map[string]map[string]bool) {
+	for i, term := range q.terms {
+		if term.key != "team" {
+			continue
+		}
+		values := term.values
+		q.terms[i].match = func(repo github.GitHubAPIRepository) bool {
+			for _, team := range values {
+				if teamRepos[team][repo.FullName] {
+					return true
+				}
+			}
+			return false
+		}
+	}
+}
+
+// listType returns the type parameter of the organization listing that narrows the
+// repositories on the server side (all when the query cannot be narrowed)
+func (q *RepoQuery) listType() string {
+	for _, term := range q.terms {
+		if len(term.values) != 1 {
+			continue
+		}
+		value := strings.ToLower(term.values[0])
+		positive := !term.negate
+		switch {
+		case term.key == "fork" && value == "true", term.key == "is" && value == "fork":
+			if positive {
+				return "forks"
+			}
+			return "sources"
+		case term.key == "fork" && value == "false":
+			if positive {
+				return "sources"
+			}
+			return "forks"
+		case term.key == "private" && value == "true", term.key == "is" && value == "private", term.key == "visibility" && value == "private":
+			if positive {
+				return "private"
+			}
+		case term.key == "private" && value == "false", term.key == "is" && value == "public", term.key == "visibility" && value == "public":
+			if positive {
+				return "public"
+			}
+		}
+	}
+	return "all"
+}
+
+// pushedAfter returns the newest pushed-after bound of the positive terms
+func (q *RepoQuery) pushedAfter() (time.Time, bool) {
+	var after time.Time
+	found := false
+	for _, term := range q.terms {
+		if term.key != "pushed" || term.negate || len(term.values) != 1 || !strings.HasPrefix(term.values[0], ">") {
+			continue
+		}
+		t, err := ParseTime(strings.TrimLeft(term.values[0], ">="), now())
+		if err == nil && (!found || t.After(after)) {
+			after, found = t, true
+		}
+	}
+	return after, found
+}
+
+// QueryRepositories lists the repositories of the organization matching the query.
+// The query narrows the listing on the server side where the api allows it: the repositories
+// of a team, the repository type and, for pushed-after terms, the listing sorted by push
+// date stops at the first older repository. The other terms are evaluated client-side.
+func QueryRepositories(org string, query string) ([]github.GitHubAPIRepository, error) {
+	q, err := ParseRepoQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Repositories of the teams in the query
+	teamRepos := map[string]map[string]bool{}
+	var teamListing []github.GitHubAPIRepository
+	for _, team := range q.Teams() {
+		if _, ok := teamRepos[team]; ok {
+			continue
+		}
+		repos, err := GetAllPages[github.GitHubAPIRepository](fmt.Sprintf("/orgs/%s/teams/%s/repos?per_page=100", org, url.PathEscape(team)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the repositories of team %s: %w", team, err)
+		}
+		teamRepos[team] = map[string]bool{}
+		for _, repo := range repos {
+			teamRepos[team][repo.FullName] = true
+		}
+		teamListing = append(teamListing, repos...)
+	}
+	q.ResolveTeams(teamRepos)
+
+	var candidates []github.GitHubAPIRepository
+	switch after, ok := q.pushedAfter(); {
+	case q.teamsOnly():
+		// A single positive team term, its repositories are the candidates
+		candidates = teamListing
+	case ok:
+		endpoint := fmt.Sprintf("/orgs/%s/repos?per_page=100&type=%s&sort=pushed&direction=desc", org, q.listType())
+		err = EachPage(endpoint, func(page []github.GitHubAPIRepository) bool {
+			for _, repo := range page {
+				pushedAt, err := time.Parse(time.RFC3339, repo.PushedAt)
+				if err == nil && !pushedAt.After(after) {
+					return false
+				}
+				candidates = append(candidates, repo)
+			}
+			return true
+		})
+	default:
+		candidates, err = GetAllPages[github.GitHubAPIRepository](fmt.Sprintf("/orgs/%s/repos?per_page=100&type=%s", org, q.listType()))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []github.GitHubAPIRepository
+	seen := map[string]bool{}
+	for _, repo := range candidates {
+		if !seen[repo.FullName] && q.Matches(repo) {
+			seen[repo.FullName] = true
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+// teamsOnly returns true if the query has a positive team term, the team
+// repositories are then all the candidates
+func (q *RepoQuery) teamsOnly() bool {
+	for _, term := range q.terms {
+		if term.key == "team" && !term.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// splitValues splits the comma-separated values, a regex is kept whole
+func splitValues(key string, value string) []string {
+	if key == "name" && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") && len(value) > 1 {
+		return []string{value}
+	}
+	return strings.Split(value, ",")
+}
+
+// termMatcher returns the matcher of a term
+func termMatcher(key string, values []string) (func(repo github.GitHubAPIRepository) bool, error) {
+	var matchers []func(repo github.GitHubAPIRepository) bool
+	for _, value := range values {
+		matcher, err := valueMatcher(key, value)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return func(repo github.GitHubAPIRepository) bool {
+		for _, matcher := range matchers {
+			if matcher(repo) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// valueMatcher returns the matcher of a single value of a term
+func valueMatcher(key string, value string) (func(repo github.GitHubAPIRepository) bool, error) {
+	switch key {
+	case "name":
+		return nameMatcher(value)
+	case "topic":
+		return func(repo github.GitHubAPIRepository) bool {
+			for _, topic := range repo.Topics {
+				if strings.EqualFold(topic, value) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "language":
+		return func(repo github.GitHubAPIRepository) bool { return strings.EqualFold(repo.Language, value) }, nil
+	case "archived", "fork", "private":
+		want, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: expected true or false", key, value)
+		}
+		return func(repo github.GitHubAPIRepository) bool { return repoFlag(repo, key) == want }, nil
+	case "is":
+		switch strings.ToLower(value) {
+		case "archived", "fork", "private":
+			return func(repo github.GitHubAPIRepository) bool { return repoFlag(repo, strings.ToLower(value)) }, nil
+		case "public":
+			return func(repo github.GitHubAPIRepository) bool { return !repo.Private }, nil
+		}
+		return nil, fmt.Errorf("invalid is value %q: expected archived, fork, private or public", value)
+	case "visibility":
+		return func(repo github.GitHubAPIRepository) bool { return strings.EqualFold(repoVisibility(repo), value) }, nil
+	case "pushed":
+		return pushedMatcher(value)
+	case "team":
+		// Resolved with the team repositories by ResolveTeams
+		return func(repo github.GitHubAPIRepository) bool { return false }, nil
+	}
+	return nil, fmt.Errorf("unknown query key %q", key)
+}
+
+// nameMatcher matches the name with a /regex/, a glob or a substring
+func nameMatcher(pattern string) (func(repo github.GitHubAPIRepository) bool, error) {
+	target := func(repo github.GitHubAPIRepository) string { return repo.Name }
+
+	if strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") && len(pattern) > 1 {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid name regex %s: %w", pattern, err)
+		}
+		return func(repo github.GitHubAPIRepository) bool { return re.MatchString(repo.Name) }, nil
+	}
+
+	if strings.Contains(pattern, "/") {
+		target = func(repo github.GitHubAPIRepository) string { return repo.FullName }
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid name glob %s: %w", pattern, err)
+		}
+		return func(repo github.GitHubAPIRepository) bool {
+			matched, _ := path.Match(pattern, target(repo))
+			return matched
+		}, nil
+	}
+	return func(repo github.GitHubAPIRepository) bool { return strings.Contains(target(repo), pattern) }, nil
+}
+
+// pushedMatcher matches the push date with >, >=, < or <= a time
+func pushedMatcher(value string) (func(repo github.GitHubAPIRepository) bool, error) {
+	op := ">"
+	for _, prefix := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(value, prefix) {
+			op, value = prefix, strings.TrimPrefix(value, prefix)
+			break
+		}
+	}
+	bound, err := ParseTime(value, now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pushed value: %w", err)
+	}
+
+	return func(repo github.GitHubAPIRepository) bool {
+		pushedAt, err := time.Parse(time.RFC3339, repo.PushedAt)
+		if err != nil {
+			return false
+		}
+		switch op {
+		case ">=":
+			return !pushedAt.Before(bound)
+		case "<=":
+			return !pushedAt.After(bound)
+		case "<":
+			return pushedAt.Before(bound)
+		default:
+			return pushedAt.After(bound)
+		}
+	}, nil
+}
+
+// repoFlag returns the archived, fork or private flag of the repository
+func repoFlag(repo github.GitHubAPIRepository, flag string) bool {
+	switch flag {
+	case "archived":
+		return repo.Archived
+	case "fork":
+		return repo.Fork
+	default:
+		return repo.Private
+	}
+}
+
+// repoVisibility returns the visibility of the repository (older servers do not send it)
+func repoVisibility(repo github.GitHubAPIRepository) string {
+	if repo.Visibility != "" {
+		return repo.Visibility
+	}
+	if repo.Private {
+		return "private"
+	}
+	return "public"
+}
+
+// ParseTime parses an RFC3339 time, a date (2006-01-02) or a duration back from
+// the given time (Go durations like 12h and days like 30d)
+func ParseTime(value string, from time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return from.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return from.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339 time, date (2006-01-02) or duration (e.g. 24h, 30d)", value)
+}
@@ -0,0 +1,132 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/eltiocaballoloco/sinaloa-cli/src/models/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// queryTestRepos are the repositories of the query tests
+var queryTestRepos = []github.GitHubAPIRepository{
+	{Name: "svc-payments", FullName: "org/svc-payments", Language: "Go", Topics: []string{"payments"}, PushedAt: "2024-06-01T00:00:00Z"},
+	{Name: "svc-legacy", FullName: "org/svc-legacy", Language: "Java", Archived: true, PushedAt: "2021-01-01T00:00:00Z"},
+	{Name: "web-app", FullName: "org/web-app", Language: "TypeScript", Private: true, Visibility: "internal", PushedAt: "2024-05-01T00:00:00Z"},
+	{Name: "api-fork", FullName: "org/api-fork", Language: "Go", Fork: true, PushedAt: "2023-01-01T00:00:00Z"},
+}
+
+// queryNames returns the names of the repositories matching the query
+func queryNames(t *testing.T, query string) []string {
+	q, err := ParseRepoQuery(query)
+	assert.NoError(t, err, "Query %q should parse", query)
+	var names []string
+	for _, repo := range queryTestRepos {
+		if q.Matches(repo) {
+			names = append(names, repo.Name)
+		}
+	}
+	return names
+}
+
+func TestRepoQuery_Matches(t *testing.T) {
+	// Arrange: Queries and the repositories they match
+	tests := map[string][]string{
+		"":                                 {"svc-payments", "svc-legacy", "web-app", "api-fork"},
+		"svc-, web-":                       {"svc-payments", "svc-legacy", "web-app"},
+		"-payments,-app":                   {"svc-payments", "web-app"},
+		"-name:svc-":                       {"web-app", "api-fork"},
+		"name:svc-*":                       {"svc-payments", "svc-legacy"},
+		"name:/^(web|api)-/":               {"web-app", "api-fork"},
+		"name:org/web-*":                   {"web-app"},
+		"topic:payments":                   {"svc-payments"},
+		"lang:go -is:fork":                 {"svc-payments"},
+		"archived:false fork:false":        {"svc-payments", "web-app"},
+		"is:private":                       {"web-app"},
+		"visibility:public,internal -svc-": {"web-app", "api-fork"},
+		"pushed:>2024-01-01":               {"svc-payments", "web-app"},
+		"pushed:<2023-06-01 -is:archived":  {"api-fork"},
+	}
+
+	for query, expected := range tests {
+		// Act: Filter the repositories
+		names := queryNames(t, query)
+
+		// Assert: The matching repositories are returned
+		assert.Equal(t, expected, names, "Repositories of query %q should match", query)
+	}
+}
+
+func TestParseRepoQuery_Errors(t *testing.T) {
+	// Arrange: Invalid queries
+	for _, query := range []string{"owner:me", "name:/[/", "is:big", "private:no", "pushed:>yesterday", "topic:"} {
+		// Act: Parse the query
+		_, err := ParseRepoQuery(query)
+
+		// Assert: An error is returned
+		assert.Error(t, err, "Query %q should fail", query)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	// Arrange: A reference time
+	from := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	// Act: Parse a date, a duration and days
+	date, err1 := ParseTime("2024-01-02", from)
+	hours, err2 := ParseTime("36h", from)
+	days, err3 := ParseTime("30d", from)
+	_, err4 := ParseTime("soon", from)
+
+	// Assert: The times are resolved from the reference time
+	assert.NoError(t, err1, "Date should parse")
+	assert.NoError(t, err2, "Duration should parse")
+	assert.NoError(t, err3, "Days should parse")
+	assert.Error(t, err4, "Invalid time should fail")
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), date, "Date should match")
+	assert.Equal(t, from.Add(-36*time.Hour), hours, "Duration should match")
+	assert.Equal(t, from.AddDate(0, 0, -30), days, "Days should match")
+}
+
+func TestQueryRepositories_Team(t *testing.T) {
+	// Arrange: A team with two repositories, one of them archived
+	var paths []string
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		fmt.Fprint(w, `[{"name":"svc-a","full_name":"org/svc-a"},{"name":"svc-b","full_name":"org/svc-b","archived":true}]`)
+	})
+
+	// Act: Query the repositories of the team
+	repos, err := QueryRepositories("org", "team:platform -is:archived")
+
+	// Assert: Only the team repositories are listed and filtered
+	assert.NoError(t, err, "Query should not return an error")
+	assert.Equal(t, []string{"/orgs/org/teams/platform/repos"}, paths, "Only the team should be listed")
+	assert.Len(t, repos, 1, "Archived repository should be filtered")
+	assert.Equal(t, "svc-a", repos[0].Name, "Repository should match")
+}
+
+func TestQueryRepositories_Narrowing(t *testing.T) {
+	// Arrange: Two pages of repositories sorted by push date
+	var queries []string
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/org/repos?page=2>; rel="next"`, apiBaseURL))
+			fmt.Fprint(w, `[{"name":"new","full_name":"org/new","pushed_at":"2024-06-01T00:00:00Z"},{"name":"old","full_name":"org/old","pushed_at":"2023-01-01T00:00:00Z"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"name":"older","full_name":"org/older","pushed_at":"2022-01-01T00:00:00Z"}]`)
+	})
+
+	// Act: Query the sources pushed after a date
+	repos, err := QueryRepositories("org", "fork:false pushed:>2024-01-01")
+
+	// Assert: The listing is narrowed and stops at the first older repository
+	assert.NoError(t, err, "Query should not return an error")
+	assert.Equal(t, []string{"per_page=100&type=sources&sort=pushed&direction=desc"}, queries, "Listing should be narrowed")
+	assert.Len(t, repos, 1, "Only the recent repository should match")
+	assert.Equal(t, "new", repos[0].Name, "Repository should match")
+}
@@ -0,0 +1,112 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Retries of a request answered with a rate limit error
+	maxRateLimitRetries = 3
+	// Longest wait for a rate limit reset, beyond it the request fails
+	maxRateLimitWait = 15 * time.Minute
+	// Wait of a secondary rate limit without Retry-After, as suggested by GitHub
+	secondaryRateLimitWait = time.Minute
+)
+
+// Replaced in the tests
+var (
+	sleep = time.Sleep
+	now   = time.Now
+)
+
+// rateLimit is the last known state of a rate limit resource (core, graphql, search)
+type rateLimit struct {
+	remaining int
+	reset     time.Time
+}
+
+// rateLimiter tracks the X-RateLimit-* headers of the responses so that the requests
+// wait for the reset instead of failing once the limit is exhausted
+type rateLimiter struct {
+	mu     sync.Mutex
+	limits map[string]rateLimit
+}
+
+var limiter = &rateLimiter{limits: map[string]rateLimit{}}
+
+// wait sleeps until the reset when the rate limit of the resource is exhausted
+func (l *rateLimiter) wait(resource string) {
+	l.mu.Lock()
+	limit, ok := l.limits[resource]
+	l.mu.Unlock()
+	if !ok || limit.remaining > 0 {
+		return
+	}
+
+	delay := limit.reset.Sub(now())
+	if delay <= 0 {
+		return
+	}
+	if delay > maxRateLimitWait {
+		delay = maxRateLimitWait
+	}
+	sleep(delay)
+}
+
+// update saves the rate limit headers of a response under the resource of its endpoint,
+// the key wait reads (X-RateLimit-Resource names more resources, e.g. code_search)
+func (l *rateLimiter) update(resource string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[resource] = rateLimit{remaining: remaining, reset: time.Unix(reset, 0)}
+}
+
+// rateLimitResource returns the rate limit resource of an endpoint
+func rateLimitResource(endpoint string) string {
+	switch {
+	case endpoint == "/graphql":
+		return "graphql"
+	case strings.HasPrefix(endpoint, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// rateLimitDelay returns how long to wait when the response is a rate limit error:
+// the Retry-After of the secondary limits, the reset of an exhausted primary limit
+// or one minute for a secondary limit without Retry-After
+func rateLimitDelay(resp *http.Response, body []byte) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			delay := time.Unix(reset, 0).Sub(now())
+			if delay < time.Second {
+				delay = time.Second
+			}
+			return delay, true
+		}
+	}
+	if strings.Contains(strings.ToLower(string(body)), "secondary rate limit") {
+		return secondaryRateLimitWait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the value of a secret in the logs
+const Redacted = "[REDACTED]"
+
+// Longest body logged by the request tracing, the rest is truncated
+const maxLoggedBody = 2048
+
+// LogOutput is where the logs are written
+var LogOutput io.Writer = os.Stderr
+
+// Parts of the keys of secret values: headers, payload fields and log attributes
+var secretKeys = []string{"authorization", "password", "passwd", "pwd", "secret", "token", "private_key", "privatekey", "api_key", "apikey", "cookie", "credential"}
+
+// Contents that are secret as a whole: PEM private keys and Kubernetes secrets
+var secretContent = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----|(?m)^\s*"?kind"?\s*:\s*"?Secret"?\s*,?\s*$`)
+
+// SetupLogger sets the default slog logger writing to LogOutput with the level (debug, info, warn
+// or error, debug when empty and SINALOA_DEBUG is set in the config) and the format (text or json).
+// Secret attributes are redacted whatever the call site.
+func SetupLogger(level string, format string) error {
+	if level == "" {
+		level = "info"
+		if AppConfig.SINALOA_DEBUG {
+			level = "debug"
+		}
+	}
+	var slogLevel slog.Level
+	if err := slogLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid --log-level %q: expected debug, info, warn or error", level)
+	}
+
+	handler, err := newLogHandler(LogOutput, slogLevel, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// newLogHandler returns the text or json handler with the redaction of the secrets
+func newLogHandler(w io.Writer, level slog.Level, format string) (slog.Handler, error) {
+	options := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if IsSecretKey(attr.Key) {
+				return slog.String(attr.Key, Redacted)
+			}
+			return attr
+		},
+	}
+	switch format {
+	case "", "text":
+		return slog.NewTextHandler(w, options), nil
+	case "json":
+		return slog.NewJSONHandler(w, options), nil
+	}
+	return nil, fmt.Errorf("invalid --log-format %q: expected text or json", format)
+}
+
+// IsSecretKey returns true if the key names a secret value (not the path of a secret file)
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	if strings.HasSuffix(key, "_path") {
+		return false
+	}
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders returns the headers for the logs, with the secret ones redacted
+func RedactHeaders(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for key, values := range header {
+		if IsSecretKey(key) {
+			headers[key] = Redacted
+		} else {
+			headers[key] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+// RedactURL returns the url for the logs, with the secret query parameters redacted
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+	query := u.Query()
+	for key := range query {
+		if IsSecretKey(key) {
+			query.Set(key, Redacted)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// RedactBody returns a request or response body for the logs: secret contents are
+// dropped, the secret fields of JSON and form payloads are redacted and a long
+// body is truncated
+func RedactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if secretContent.Match(body) {
+		return fmt.Sprintf("%s (%d bytes)", Redacted, len(body))
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redacted, err := json.Marshal(redactValue(value)); err == nil {
+			body = redacted
+		}
+	} else if form, err := url.ParseQuery(string(body)); err == nil && bytes.Contains(body, []byte("=")) {
+		for key := range form {
+			if IsSecretKey(key) {
+				form.Set(key, Redacted)
+			}
+		}
+		body = []byte(form.Encode())
+	}
+
+	if len(body) > maxLoggedBody {
+		return fmt.Sprintf("%s... (%d bytes)", body[:maxLoggedBody], len(body))
+	}
+	return string(body)
+}
+
+// redactValue redacts the secret fields of a decoded JSON value
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if IsSecretKey(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,352 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the --output flag
+const (
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputTable    = "table"
+	OutputCSV      = "csv"
+	OutputJSONPath = "jsonpath"
+)
+
+// OutputFormat is the value of the global --output flag (json, yaml, table, csv or jsonpath=<expr>)
+var OutputFormat = OutputJSON
+
+// Output is where PrintOutput writes
+var Output io.Writer = os.Stdout
+
+// ValidateOutputFormat checks the value of the --output flag
+func ValidateOutputFormat(format string) error {
+	name, expr, _ := strings.Cut(format, "=")
+	switch name {
+	case OutputJSON, OutputYAML, OutputTable, OutputCSV:
+		return nil
+	case OutputJSONPath:
+		if expr == "" {
+			return fmt.Errorf("invalid --output %q: expected jsonpath=<expr>", format)
+		}
+		_, err := parseJSONPath(expr)
+		return err
+	}
+	return fmt.Errorf("invalid --output %q: expected json, yaml, table, csv or jsonpath=<expr>", format)
+}
+
+// PrintOutput prints the result of a controller in the --output format.
+// A result that is not JSON (a message, a text report) is printed as it is.
+func PrintOutput(result []byte) {
+	formatted, err := FormatOutput(result, OutputFormat)
+	if err != nil {
+		slog.Error("Failed to format the output", "format", OutputFormat, "error", err)
+		formatted = result
+	}
+	fmt.Fprintln(Output, strings.TrimRight(string(formatted), "\n"))
+}
+
+// FormatOutput converts the JSON result of a controller to the format. The table and csv
+// formats render the data of the response envelope: a list of objects is a row per object,
+// an object holding a single list of objects is that list and any other object is a
+// KEY/VALUE table.
+func FormatOutput(result []byte, format string) ([]byte, error) {
+	name, expr, _ := strings.Cut(format, "=")
+	if name == OutputJSON || name == "" || !json.Valid(result) {
+		return result, nil
+	}
+
+	// JSON is YAML: the node keeps the order of the keys
+	var document yaml.Node
+	if err := yaml.Unmarshal(result, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return result, nil
+	}
+	root := document.Content[0]
+
+	switch name {
+	case OutputYAML:
+		return marshalYAML(root)
+	case OutputTable, OutputCSV:
+		header, rows := tableRows(responseData(root))
+		if name == OutputCSV {
+			return renderCSV(header, rows)
+		}
+		return renderTable(header, rows), nil
+	case OutputJSONPath:
+		segments, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		for _, node := range evalJSONPath(root, segments) {
+			lines = append(lines, cellValue(node))
+		}
+		return []byte(strings.Join(lines, "\n")), nil
+	}
+	return nil, ValidateOutputFormat(format)
+}
+
+// marshalYAML encodes the node in block style
+func marshalYAML(node *yaml.Node) ([]byte, error) {
+	resetStyle(node)
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resetStyle drops the flow and quoted styles of the JSON source
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// responseData returns the data of a successful response envelope (response, code, message,
+// data), an error response is rendered whole so that the message is not lost
+func responseData(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return node
+	}
+	result := mappingValue(node, "response")
+	if data := mappingValue(node, "data"); data != nil && result != nil && result.Value == "true" {
+		return data
+	}
+	return node
+}
+
+// mappingValue returns the value of the key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// tableRows returns the header and the rows of the data
+func tableRows(node *yaml.Node) ([]string, [][]string) {
+	if node.Kind == yaml.MappingNode {
+		// An object holding a single list of objects (e.g. {"results": [...]})
+		var list *yaml.Node
+		for i := 1; i < len(node.Content); i += 2 {
+			if value := node.Content[i]; value.Kind == yaml.SequenceNode && isObjectList(value) {
+				if list != nil {
+					list = nil
+					break
+				}
+				list = value
+			}
+		}
+		if list == nil {
+			var rows [][]string
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				rows = append(rows, []string{node.Content[i].Value, cellValue(node.Content[i+1])})
+			}
+			return []string{"KEY", "VALUE"}, rows
+		}
+		node = list
+	}
+
+	if node.Kind != yaml.SequenceNode {
+		return []string{"VALUE"}, [][]string{{cellValue(node)}}
+	}
+	if !isObjectList(node) {
+		var rows [][]string
+		for _, item := range node.Content {
+			rows = append(rows, []string{cellValue(item)})
+		}
+		return []string{"VALUE"}, rows
+	}
+
+	// Columns in the order of the first appearance of the keys
+	var columns []string
+	index := map[string]int{}
+	for _, item := range node.Content {
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			if _, ok := index[item.Content[i].Value]; !ok {
+				index[item.Content[i].Value] = len(columns)
+				columns = append(columns, item.Content[i].Value)
+			}
+		}
+	}
+	var rows [][]string
+	for _, item := range node.Content {
+		row := make([]string, len(columns))
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			row[index[item.Content[i].Value]] = cellValue(item.Content[i+1])
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows
+}
+
+// isObjectList returns true if the sequence is a non-empty list of objects
+func isObjectList(node *yaml.Node) bool {
+	if len(node.Content) == 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// cellValue returns a scalar as it is and a list or object as compact JSON
+func cellValue(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag == "!!null" {
+			return ""
+		}
+		return node.Value
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// renderTable aligns the rows in columns under an upper-case header
+func renderTable(header []string, rows [][]string) []byte {
+	var buf bytes.Buffer
+	writer := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	upper := make([]string, len(header))
+	for i, column := range header {
+		upper[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(writer, strings.Join(upper, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(cell)
+		}
+		fmt.Fprintln(writer, strings.Join(cells, "\t"))
+	}
+	writer.Flush()
+	return buf.Bytes()
+}
+
+// renderCSV writes the header and the rows as CSV
+func renderCSV(header []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonPathSegment is a key or a list index ([N], [*]) of a JSONPath expression
+type jsonPathSegment struct {
+	key      string
+	index    int
+	wildcard bool
+	isIndex  bool
+}
+
+// parseJSONPath parses a JSONPath expression such as {.data[*].name}, $.data[0] or data.items
+func parseJSONPath(expr string) ([]jsonPathSegment, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+	expr = strings.TrimPrefix(strings.TrimPrefix(expr, "$"), ".")
+
+	var segments []jsonPathSegment
+	for expr != "" {
+		switch {
+		case strings.HasPrefix(expr, "["):
+			end := strings.Index(expr, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonpath: missing ] in %q", expr)
+			}
+			inside := strings.Trim(expr[1:end], `'"`)
+			if inside == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			} else if i, err := strconv.Atoi(inside); err == nil {
+				segments = append(segments, jsonPathSegment{index: i, isIndex: true})
+			} else if inside != "" {
+				segments = append(segments, jsonPathSegment{key: inside})
+			} else {
+				return nil, fmt.Errorf("invalid jsonpath: empty brackets")
+			}
+			expr = expr[end+1:]
+		case strings.HasPrefix(expr, "."):
+			expr = expr[1:]
+		default:
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			if key := expr[:end]; key == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			} else {
+				segments = append(segments, jsonPathSegment{key: key})
+			}
+			expr = expr[end:]
+		}
+	}
+	return segments, nil
+}
+
+// evalJSONPath returns the nodes selected by the segments
+func evalJSONPath(node *yaml.Node, segments []jsonPathSegment) []*yaml.Node {
+	nodes := []*yaml.Node{node}
+	for _, segment := range segments {
+		var next []*yaml.Node
+		for _, n := range nodes {
+			switch {
+			case segment.wildcard && n.Kind == yaml.SequenceNode:
+				next = append(next, n.Content...)
+			case segment.wildcard && n.Kind == yaml.MappingNode:
+				for i := 1; i < len(n.Content); i += 2 {
+					next = append(next, n.Content[i])
+				}
+			case segment.isIndex && n.Kind == yaml.SequenceNode:
+				i := segment.index
+				if i < 0 {
+					i += len(n.Content)
+				}
+				if i >= 0 && i < len(n.Content) {
+					next = append(next, n.Content[i])
+				}
+			case segment.key != "" && n.Kind == yaml.MappingNode:
+				if value := mappingValue(n, segment.key); value != nil {
+					next = append(next, value)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Reader of the answers to the prompts
+var promptInput = bufio.NewReader(os.Stdin)
+
+// IsTerminal returns true if the file is a terminal
+func IsTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// ReadLine prints the prompt on stderr and reads a line from stdin
+func ReadLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := promptInput.ReadString('\n')
+	if err != nil && !(err == io.EOF && line != "") {
+		return "", fmt.Errorf("failed to read the answer: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ReadSecret prints the prompt on stderr and reads a line from the terminal without echoing it
+func ReadSecret(prompt string) (string, error) {
+	if !IsTerminal(os.Stdin) {
+		return ReadLine(prompt)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the answer: %w", err)
+	}
+	return string(secret), nil
+}
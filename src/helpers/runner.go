@@ -0,0 +1,144 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the commands
+const (
+	ExitOK         = 0
+	ExitFailure    = 1 // The command ran and failed (a failed check, a failed sync)
+	ExitValidation = 2 // Invalid flags, arguments or input
+	ExitAuth       = 3 // Missing or rejected credentials (401, 403)
+	ExitNotFound   = 4 // The resource does not exist (404, no match)
+	ExitRemote     = 5 // The remote service answered with an error (5xx, other statuses)
+	ExitTimeout    = 6 // The remote service did not answer in time
+)
+
+// ExitFunc ends the process with the exit code, replaced in the tests
+var ExitFunc = os.Exit
+
+// HTTP status in an error message, e.g. "GitHub API error (status 404)" or "HTTP status 500"
+var errorStatus = regexp.MustCompile(`(?i)status:? (\d{3})\b`)
+
+// CommandError is an error with the exit code of the command
+type CommandError struct {
+	Code int
+	Err  error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// NewCommandError returns the error with the exit code
+func NewCommandError(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CommandError{Code: code, Err: err}
+}
+
+// ValidationError returns an error of invalid input (exit code 2)
+func ValidationError(format string, args ...interface{}) error {
+	return NewCommandError(ExitValidation, fmt.Errorf(format, args...))
+}
+
+// RunCommand returns the Run function of a command. The controllers print their progress
+// and diagnostics while run executes with stdout redirected to stderr, so that stdout only
+// gets the result document printed in the --output format. A failure, the error or a
+// response with "response": false, ends the process with its exit code.
+func RunCommand(run func(cmd *cobra.Command, args []string) ([]byte, error)) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		result, err := func() ([]byte, error) {
+			stdout := os.Stdout
+			os.Stdout = os.Stderr
+			defer func() { os.Stdout = stdout }()
+			return run(cmd, args)
+		}()
+
+		if len(result) > 0 {
+			PrintOutput(result)
+		}
+		// A failed response was already reported by HandleControllerApi
+		if err != nil && failedResponseStatus(result) == 0 {
+			slog.Error("Command failed", "error", err)
+		}
+		if code := ExitCode(result, err); code != ExitOK {
+			ExitFunc(code)
+		}
+	}
+}
+
+// ExitCode returns the exit code of the result and the error of a command
+func ExitCode(result []byte, err error) int {
+	status := failedResponseStatus(result)
+	if err == nil && status == 0 {
+		return ExitOK
+	}
+
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) {
+		return commandErr.Code
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ExitTimeout
+	}
+	if status == 0 && err != nil {
+		if match := errorStatus.FindStringSubmatch(err.Error()); match != nil {
+			status, _ = strconv.Atoi(match[1])
+		}
+	}
+	if status == 0 {
+		return ExitFailure
+	}
+	return ExitCodeForStatus(status)
+}
+
+// failedResponseStatus returns the status of a failed response envelope
+// (HandleControllerApi), 0 if the result is not one
+func failedResponseStatus(result []byte) int {
+	var envelope struct {
+		Response *bool  `json:"response"`
+		Code     string `json:"code"`
+	}
+	if len(result) == 0 || json.Unmarshal(result, &envelope) != nil || envelope.Response == nil || *envelope.Response {
+		return 0
+	}
+	if status, err := strconv.Atoi(envelope.Code); err == nil && status != 0 {
+		return status
+	}
+	return 500
+}
+
+// ExitCodeForStatus returns the exit code of an HTTP error status
+func ExitCodeForStatus(status int) int {
+	switch status {
+	case 400, 409, 422:
+		return ExitValidation
+	case 401, 403:
+		return ExitAuth
+	case 404:
+		return ExitNotFound
+	case 408, 504:
+		return ExitTimeout
+	default:
+		return ExitRemote
+	}
+}
@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set at build time with
+// -ldflags "-X github.com/eltiocaballoloco/sinaloa-cli/src/helpers.Version=v1.3.0 -X ...Commit=... -X ...BuildDate=..."
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// BuildInfo returns the version, commit and build date of the binary, the commit and the
+// date of a build without ldflags are taken from the VCS information of the Go toolchain
+func BuildInfo() (version string, commit string, buildDate string) {
+	version, commit, buildDate = Version, Commit, BuildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+			case setting.Key == "vcs.time" && buildDate == "":
+				buildDate = setting.Value
+			}
+		}
+	}
+	return version, commit, buildDate
+}
+
+// Platform returns the os/arch of the binary
+func Platform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
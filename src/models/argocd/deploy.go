@@ -16,5 +16,14 @@ type ArgoCDDeployParams struct {
 	ChartParams  string `json:"ARGOCD_ENV_CHART_PARAMS"`
 	ReleaseName  string `json:"ARGOCD_ENV_RELEASE_NAME"`
 
+	// Vulnerability gate for the incremental tag (disabled when the threshold is empty)
+	VulnThreshold   string `json:"ARGOCD_ENV_VULN_THRESHOLD"`    // LOW, MEDIUM, HIGH or CRITICAL
+	VulnReports     string `json:"ARGOCD_ENV_VULN_REPORTS"`      // Report file template ({repo}, {tag}) or "attestation"
+	VulnMaxFallback string `json:"ARGOCD_ENV_VULN_MAX_FALLBACK"` // Previous versions to try (default all)
+
+	// Signature of the attestations: the public key verifying them, or "true" to accept them unverified
+	VulnAttestationKey  string `json:"ARGOCD_ENV_VULN_ATTESTATION_KEY"`  // PEM public key file (cosign.pub)
+	VulnAllowUnverified string `json:"ARGOCD_ENV_VULN_ALLOW_UNVERIFIED"` // "true" to accept unsigned attestations
+
 	DockerRepo string `json:"ARGOCD_EXTRA_DOCKER_REPO"`
 }
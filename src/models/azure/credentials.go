@@ -0,0 +1,9 @@
+package azure
+
+// Credentials are the app registration and the drive used to call Microsoft Graph
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	TenantID     string
+	DriveID      string
+}
@@ -0,0 +1,46 @@
+package docker
+
+// DeleteOptions configures how the tags are deleted
+type DeleteOptions struct {
+	Concurrency int      // Number of parallel deletions
+	MaxRetries  int      // Retries on 429 and 5xx responses
+	JournalPath string   // Journal file used to resume an interrupted cleanup ("" = no journal)
+	KeepTags    []string // Tags kept by the retention policy, a manifest they point to is never deleted
+}
+
+// TagFailure holds the reason why a tag was not deleted
+type TagFailure struct {
+	Name       string `json:"name"`
+	StatusCode int    `json:"status_code"`
+	Reason     string `json:"reason"`
+}
+
+type DeletedTags struct {
+	TagList []string `json:"tags_list"`
+	Count   int      `json:"count"`
+}
+
+type NotDeletedTags struct {
+	TagList  []string     `json:"tags_list"`
+	Count    int          `json:"count"`
+	Failures []TagFailure `json:"failures"`
+}
+
+// DeleteResult is the result of a tags deletion, tags already deleted
+// in a previous (interrupted) run are reported as skipped, tags whose
+// manifest is shared with a kept tag are reported as kept
+type DeleteResult struct {
+	TagsDeleted    DeletedTags    `json:"tags_deleted"`
+	TagsNotDeleted NotDeletedTags `json:"tags_not_deleted"`
+	TagsSkipped    DeletedTags    `json:"tags_skipped"`
+	TagsKept       NotDeletedTags `json:"tags_kept"`
+}
+
+// JournalEntry is a line of the deletion journal (JSON lines)
+type JournalEntry struct {
+	Repo   string `json:"repo"`
+	Tag    string `json:"tag"`
+	Status string `json:"status"` // "deleted" or "failed"
+	Reason string `json:"reason,omitempty"`
+	Time   string `json:"time"`
+}
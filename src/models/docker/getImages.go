@@ -43,15 +43,17 @@ type TagsResponse struct {
 
 // TagInfo struct to hold detailed information about a tag (internal logic)
 type TagInfoInternal struct {
-	Name                string `json:"name"`
-	IDImage             int64  `json:"id_image"`
-	IDRepository        int    `json:"id_repository"`
-	IDCreator           int    `json:"id_creator"`
-	LastUpdaterUsername string `json:"last_updater_username"`
-	LastUpdated         string `json:"last_updated"`
-	TagLastPulled       string `json:"tag_last_pulled"`
-	TagLastPushed       string `json:"tag_last_pushed"`
-	Digest              string `json:"digest"`
+	Name                string         `json:"name"`
+	IDImage             int64          `json:"id_image"`
+	IDRepository        int            `json:"id_repository"`
+	IDCreator           int            `json:"id_creator"`
+	LastUpdaterUsername string         `json:"last_updater_username"`
+	LastUpdated         string         `json:"last_updated"`
+	TagLastPulled       string         `json:"tag_last_pulled"`
+	TagLastPushed       string         `json:"tag_last_pushed"`
+	Digest              string         `json:"digest"`
+	FullSize            int64          `json:"full_size"`
+	Platforms           []PlatformInfo `json:"platforms,omitempty"`
 }
 
 type TagResponseInternal struct {
@@ -0,0 +1,45 @@
+package docker
+
+// Structs to unmarshal the repositories list from dockerhub api
+type HubRepository struct {
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	Description string `json:"description"`
+	IsPrivate   bool   `json:"is_private"`
+	StarCount   int    `json:"star_count"`
+	PullCount   int64  `json:"pull_count"`
+	LastUpdated string `json:"last_updated"`
+}
+
+type HubRepositoriesResponse struct {
+	Count    int             `json:"count"`
+	Next     *string         `json:"next"`
+	Previous *string         `json:"previous"`
+	Results  []HubRepository `json:"results"`
+}
+
+// RepositoryInventory aggregates the tags of a single repository
+type RepositoryInventory struct {
+	Repository    string   `json:"repository"`
+	TagCount      int      `json:"tag_count"`
+	TotalSize     int64    `json:"total_size"`
+	OldestPush    string   `json:"oldest_push"`
+	NewestPush    string   `json:"newest_push"`
+	StaleTagCount int      `json:"stale_tag_count"`
+	StaleTags     []string `json:"stale_tags"`
+	UnknownTags   []string `json:"unknown_stale_tags"` // Tags without pull or push date (OCI registries), stale or not
+	Error         string   `json:"error,omitempty"`
+}
+
+// InventoryReport is the inventory of all the repositories of a namespace
+type InventoryReport struct {
+	Namespace      string                `json:"namespace"`
+	GeneratedAt    string                `json:"generated_at"`
+	StaleDays      int                   `json:"stale_days"`
+	TotalRepos     int                   `json:"total_repos"`
+	TotalTags      int                   `json:"total_tags"`
+	TotalSize      int64                 `json:"total_size"`
+	TotalStaleTags int                   `json:"total_stale_tags"`
+	TotalUnknown   int                   `json:"total_unknown_stale_tags"`
+	Repositories   []RepositoryInventory `json:"repositories"`
+}
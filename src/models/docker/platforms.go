@@ -0,0 +1,35 @@
+package docker
+
+// PlatformInfo is a single platform image of a tag (an entry of a multi-arch index)
+type PlatformInfo struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+	Digest       string `json:"digest"`
+	Size         int64  `json:"size"`
+}
+
+// String returns the platform as os/arch[/variant], e.g. linux/arm64 or linux/arm/v7
+func (p PlatformInfo) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// PlatformCheck is the result of the platforms check of a tag
+type PlatformCheck struct {
+	Name      string   `json:"name"`
+	Platforms []string `json:"platforms"`
+	Missing   []string `json:"missing"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// PlatformReport is the result of the platforms check of a repository
+type PlatformReport struct {
+	Repository string          `json:"repository"`
+	Required   []string        `json:"required"`
+	Checked    int             `json:"checked"`
+	Failed     int             `json:"failed"`
+	Tags       []PlatformCheck `json:"tags"`
+}
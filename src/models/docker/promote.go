@@ -0,0 +1,20 @@
+package docker
+
+// PromoteResult is the result of the copy of an image between repositories
+type PromoteResult struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"media_type"`
+	Manifests int    `json:"manifests"` // Platform manifests copied (multi-arch indexes)
+	Blobs     int    `json:"blobs"`     // Config and layer blobs copied or mounted
+}
+
+// BumpResult is the next semver tag of a repository
+type BumpResult struct {
+	Repository string         `json:"repository"`
+	Level      string         `json:"level"`
+	Current    string         `json:"current"`
+	Next       string         `json:"next"`
+	Promoted   *PromoteResult `json:"promoted,omitempty"` // Set when the source tag is retagged with the next version
+}
@@ -0,0 +1,33 @@
+package docker
+
+// RetentionPolicy describes which tags of a repository must survive
+// a cleanup. Every rule is optional, a zero value disables it.
+// The policy can be loaded from a YAML/JSON file or built from flags.
+type RetentionPolicy struct {
+	KeepLast             int      `yaml:"keep_last" json:"keep_last"`                             // Keep the N highest semver tags
+	KeepRegex            []string `yaml:"keep_regex" json:"keep_regex"`                           // Keep tags matching at least one regex
+	KeepPulledWithinDays int      `yaml:"keep_pulled_within_days" json:"keep_pulled_within_days"` // Keep tags pulled in the last N days
+	KeepPerMajor         int      `yaml:"keep_per_major" json:"keep_per_major"`                   // Keep the last N semver tags of every major line
+	KeepPerMinor         int      `yaml:"keep_per_minor" json:"keep_per_minor"`                   // Keep the last N semver tags of every major.minor line
+	KeepDeployedFile     string   `yaml:"keep_deployed_file" json:"keep_deployed_file"`           // File with the tags currently deployed (one per line)
+	KeepArgoCDDeployed   bool     `yaml:"keep_argocd_deployed" json:"keep_argocd_deployed"`       // Keep the tags used by the ArgoCD applications (default true)
+	NonSemverMaxAgeDays  int      `yaml:"non_semver_max_age_days" json:"non_semver_max_age_days"` // Delete non-semver tags only when older than N days (0 = always)
+}
+
+// TagDecision explains what the retention policy decided for a tag
+// and which rule took the decision (shown in the dry-run output)
+type TagDecision struct {
+	Name   string `json:"name"`
+	Action string `json:"action"` // "keep" or "delete"
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// RetentionReport is the result of a retention policy evaluation.
+// TagList and Count keep the same shape of TagResponseInternal
+// so the tags to delete can be passed as-is to the BE layer.
+type RetentionReport struct {
+	TagList   []TagInfoInternal `json:"tags_list"`
+	Count     int               `json:"count"`
+	Decisions []TagDecision     `json:"decisions"`
+}
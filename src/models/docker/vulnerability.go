@@ -0,0 +1,34 @@
+package docker
+
+// VulnerabilityGate refuses the tags whose vulnerability report has findings
+// at or above the threshold. A zero value (no threshold) disables the gate.
+type VulnerabilityGate struct {
+	Threshold   string // Severity threshold: LOW, MEDIUM, HIGH or CRITICAL
+	Reports     string // Report file template ({repo} and {tag} are replaced) or "attestation"
+	RegistryURL string // Registry of the attestations (default Docker Hub or DOCKER_REGISTRY_URL)
+	MaxFallback int    // Previous versions to try when a tag is refused (0 = all)
+
+	AttestationKey  string // PEM public key file verifying the signature of the attestations
+	AllowUnverified bool   // Accept the attestations without verifying their signature (opt-in)
+}
+
+// Vulnerability is a finding of a Trivy/Grype report
+type Vulnerability struct {
+	ID       string `json:"id"`
+	Package  string `json:"package"`
+	Severity string `json:"severity"`
+}
+
+// VulnerabilityReport is the normalized report of a tag
+type VulnerabilityReport struct {
+	Scanner         string          `json:"scanner"` // trivy or grype
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	Unverified      bool            `json:"unverified,omitempty"` // Attestation accepted without verifying its signature
+}
+
+// GateDecision explains why the gate accepted or refused a tag
+type GateDecision struct {
+	Tag      string `json:"tag"`
+	Accepted bool   `json:"accepted"`
+	Reason   string `json:"reason"`
+}
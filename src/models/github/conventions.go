@@ -0,0 +1,15 @@
+package github
+
+// Conventions are the Go templates of the names built by the deployment matrix.
+// Namespace and cluster templates get .Project, .Service (the project up to the first -),
+// .Env, .EnvPrefix, .Region and .Cluster (the cluster found in the manifest); the exposure
+// templates get .Exposure (internal or external), .Hostname, .Prefix, .Rewrite and .Env.
+// An exposure key rendered empty is skipped.
+type Conventions struct {
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Cluster   string `yaml:"cluster,omitempty" json:"cluster,omitempty"`
+	HostKey   string `yaml:"host_key,omitempty" json:"host_key,omitempty"`
+	PathKey   string `yaml:"path_key,omitempty" json:"path_key,omitempty"`
+	URLKey    string `yaml:"url_key,omitempty" json:"url_key,omitempty"`
+	URL       string `yaml:"url,omitempty" json:"url,omitempty"`
+}
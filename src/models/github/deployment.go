@@ -1,5 +1,7 @@
 package github
 
+import "github.com/eltiocaballoloco/sinaloa-cli/src/models/net"
+
 // DeploymentMatrix represents the complete output JSON structure
 type DeploymentMatrix struct {
 	Meta       Meta                  `json:"meta"`
@@ -14,6 +16,7 @@ type Meta struct {
 	GeneratedAt   string `json:"generated_at"`
 	Source        Source `json:"source"`
 	Stats         Stats  `json:"stats"`
+	Probe         *ProbeSummary `json:"probe,omitempty"`
 }
 
 // Source contains information about the scan source
@@ -70,9 +73,11 @@ type DeploymentDetail struct {
 	Region    string            `json:"region"`
 	Cluster   string            `json:"cluster"`
 	Namespace string            `json:"namespace"`
+	Replicas  int               `json:"replicas,omitempty"`
 	Hosts     map[string]string `json:"hosts,omitempty"`
 	Paths     map[string]string `json:"paths,omitempty"`
 	URLs      map[string]string `json:"urls,omitempty"`
+	Probes    map[string]net.ProbeResult `json:"probes,omitempty"` // Probes of the URLs, keyed as URLs
 }
 
 // Tables contains pre-computed tables for frontend
@@ -113,3 +118,27 @@ type RowIndex struct {
 	ByDeployKey  map[string][]string `json:"by_deploy_key"`
 }
 
+
+// ProbeSummary is the result of the probes of the matrix URLs
+type ProbeSummary struct {
+	CheckedAt    string                        `json:"checked_at"`
+	Total        int                           `json:"total"`
+	Broken       int                           `json:"broken"`
+	Environments map[string]EnvironmentProbes `json:"environments"` // Keyed on deploy key
+}
+
+// EnvironmentProbes are the probes of a deploy key
+type EnvironmentProbes struct {
+	Total  int           `json:"total"`
+	Broken []BrokenProbe `json:"broken"`
+}
+
+// BrokenProbe is a URL that did not answer or answered with an error status
+type BrokenProbe struct {
+	RepoID    string `json:"repo_id"`
+	ProjectID string `json:"project_id"`
+	URLKey    string `json:"url_key"`
+	URL       string `json:"url"`
+	Status    int    `json:"status_code,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,37 @@
+package github
+
+// MatrixDiff is the difference between two deployment matrices
+type MatrixDiff struct {
+	OldGeneratedAt  string        `json:"old_generated_at"`
+	NewGeneratedAt  string        `json:"new_generated_at"`
+	Changed         bool          `json:"changed"`
+	AddedRepos      []string      `json:"added_repos"`
+	RemovedRepos    []string      `json:"removed_repos"`
+	AddedProjects   []ProjectRef  `json:"added_projects"`
+	RemovedProjects []ProjectRef  `json:"removed_projects"`
+	Projects        []ProjectDiff `json:"projects"` // Projects in both matrices with changed deployments
+}
+
+// ProjectRef identifies a subproject of a repository
+type ProjectRef struct {
+	RepoID    string   `json:"repo_id"`
+	ProjectID string   `json:"project_id"`
+	Deploys   []string `json:"deploy_keys,omitempty"`
+}
+
+// ProjectDiff is the change of the deployments of a subproject
+type ProjectDiff struct {
+	RepoID            string             `json:"repo_id"`
+	ProjectID         string             `json:"project_id"`
+	AddedDeployKeys   []string           `json:"added_deploy_keys,omitempty"`
+	RemovedDeployKeys []string           `json:"removed_deploy_keys,omitempty"`
+	Changes           []DeploymentChange `json:"changes,omitempty"`
+}
+
+// DeploymentChange is a changed field of a deployment (cluster, namespace, hosts.<key>, ...)
+type DeploymentChange struct {
+	DeployKey string `json:"deploy_key"`
+	Field     string `json:"field"`
+	Old       string `json:"old"`
+	New       string `json:"new"`
+}
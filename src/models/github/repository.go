@@ -16,6 +16,8 @@ type Repository struct {
 	PushedAt      string `json:"pushed_at"`
 	Size          int    `json:"size"`
 	Language      string `json:"language"`
+	Topics        []string `json:"topics"`
+	Visibility    string `json:"visibility"`
 	ForksCount    int    `json:"forks_count"`
 	StargazersCount int  `json:"stargazers_count"`
 	WatchersCount int    `json:"watchers_count"`
@@ -44,6 +46,8 @@ type GitHubAPIRepository struct {
 	PushedAt      string `json:"pushed_at"`
 	Size          int    `json:"size"`
 	Language      string `json:"language"`
+	Topics        []string `json:"topics"`
+	Visibility    string `json:"visibility"`
 	ForksCount    int    `json:"forks_count"`
 	StargazersCount int  `json:"stargazers_count"`
 	WatchersCount int    `json:"watchers_count"`
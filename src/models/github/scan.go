@@ -0,0 +1,29 @@
+package github
+
+// ScanOptions controls the cache and the incremental mode of the deploy matrix scan
+type ScanOptions struct {
+	CacheDir    string // Directory of the scan and response cache
+	NoCache     bool   // Scan every repository and send unconditional requests
+	Since       string // Rescan only the repositories pushed after this time (RFC3339, date or duration)
+	MergeJSON   string // Existing matrix JSON to merge the rescanned repositories into
+	GraphQL     bool   // Fetch commits and manifests with batched GraphQL queries
+	Schema      string // Built-in manifest schema or path of a schema file
+	Conventions string // Conventions file of namespace, cluster and URL templates ("default" for the built-in profile)
+	Probe       bool   // Probe the ping URLs of the matrix
+	ProbeAll    bool   // Probe every URL, not only the ping ones
+}
+
+// RepoSnapshot is the default-branch commit of a repository and the paths of the
+// files under the scanned folders, fetched with GraphQL
+type RepoSnapshot struct {
+	CommitSHA string
+	Files     []string
+	Truncated bool // Folders deeper than the snapshot depth, the files are incomplete
+}
+
+// FileRef is a file of a repository at a ref
+type FileRef struct {
+	Repo string // Full name (owner/name)
+	Ref  string
+	Path string
+}
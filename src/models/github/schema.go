@@ -0,0 +1,20 @@
+package github
+
+// ManifestSchema maps the files of a repository to the deployment matrix with path
+// expressions: dotted keys, list indexes ([0]) and wildcards ([*]), alternatives
+// separated by | (the first found wins) and $dir[N], the name of the N-th folder
+// above the file ($dir[0] is the folder of the file).
+// Environment paths (clusters, replicas, namespace, hosts, urls, exposure) are
+// relative to the environment node.
+type ManifestSchema struct {
+	Name            string            `yaml:"name" json:"name"`                                             // Project name, the folder of the file when not found
+	Environments    string            `yaml:"environments" json:"environments"`                             // Map (keys are the names) or list of environments, empty for a file per environment
+	EnvironmentName string            `yaml:"environment_name,omitempty" json:"environment_name,omitempty"` // Name of a list item or of the file environment
+	Clusters        string            `yaml:"clusters,omitempty" json:"clusters,omitempty"`                 // Cluster or list of clusters, environments without are skipped
+	Replicas        string            `yaml:"replicas,omitempty" json:"replicas,omitempty"`
+	Namespace       string            `yaml:"namespace,omitempty" json:"namespace,omitempty"` // Built from project and environment when not found
+	Hosts           map[string]string `yaml:"hosts,omitempty" json:"hosts,omitempty"`
+	URLs            map[string]string `yaml:"urls,omitempty" json:"urls,omitempty"`
+	Exposure        string            `yaml:"exposure,omitempty" json:"exposure,omitempty"` // Ambassador outsideCluster mappings (hosts, paths and urls)
+	Conventions     Conventions       `yaml:"conventions,omitempty" json:"conventions,omitempty"`
+}
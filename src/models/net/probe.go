@@ -0,0 +1,13 @@
+package net
+
+// ProbeResult is the result of an HTTP probe of a URL
+type ProbeResult struct {
+	URL         string `json:"url"`
+	OK          bool   `json:"ok"` // Answered with a status below 400
+	StatusCode  int    `json:"status_code,omitempty"`
+	LatencyMs   int64  `json:"latency_ms"`
+	TLSExpiry   string `json:"tls_expiry,omitempty"` // Expiry of the server certificate (https)
+	TLSDaysLeft int    `json:"tls_days_left,omitempty"`
+	Error       string `json:"error,omitempty"`
+	CheckedAt   string `json:"checked_at"`
+}
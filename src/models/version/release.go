@@ -0,0 +1,47 @@
+package version
+
+// BuildInfo is the version information of the binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// VersionCheck is the comparison of the binary with the latest release
+type VersionCheck struct {
+	BuildInfo
+	Latest          string `json:"latest"`
+	UpdateAvailable bool   `json:"update_available"`
+	ReleaseURL      string `json:"release_url"`
+}
+
+// UpdateResult is the result of self-update
+type UpdateResult struct {
+	Previous string `json:"previous"`
+	Version  string `json:"version"`
+	Asset    string `json:"asset"`
+	SHA256   string `json:"sha256"`
+	Path     string `json:"path"`
+	Updated  bool   `json:"updated"`
+	Message  string `json:"message"`
+}
+
+// Release is a GitHub release
+type Release struct {
+	TagName     string         `json:"tag_name"`
+	Name        string         `json:"name"`
+	HTMLURL     string         `json:"html_url"`
+	PublishedAt string         `json:"published_at"`
+	Draft       bool           `json:"draft"`
+	Prerelease  bool           `json:"prerelease"`
+	Assets      []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file of a GitHub release
+type ReleaseAsset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}